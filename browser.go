@@ -0,0 +1,42 @@
+package tasked
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
+)
+
+// isBrowsableURL reports whether reference is an absolute URL with a scheme
+// and a host, like "https://example.com/docs", that a browser can open.
+// Local paths and other freeform references are not.
+func isBrowsableURL(reference string) bool {
+	u, err := url.ParseRequestURI(reference)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// browserCommand returns the command that opens target in the default
+// browser: open on macOS, the URL protocol handler on Windows and xdg-open
+// everywhere else.
+func browserCommand(target string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", target)
+	case "windows":
+		// Not cmd /c start: cmd would interpret metacharacters like "|" and
+		// "&" in the URL, which references written by agents may contain
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		return exec.Command("xdg-open", target)
+	}
+}
+
+// openInBrowser opens target in the default browser. It does not wait for
+// the browser, so an error only means that the opener could not be started.
+func openInBrowser(target string) error {
+	cmd := browserCommand(target)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", target, err)
+	}
+	return cmd.Process.Release()
+}
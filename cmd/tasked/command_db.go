@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dhamidi/tasked"
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Maintain the database",
+	Long:  `Maintenance operations on the database that stores all plans.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var dbVacuumCmd = &cobra.Command{
+	Use:   "vacuum",
+	Short: "Reclaim unused space in the database file",
+	Long: `Rebuild the database file to reclaim the space left behind by removed and
+compacted plans; SQLite does not shrink the file on its own. Afterwards the
+query planner statistics are refreshed with PRAGMA optimize.
+
+Vacuuming rewrites the whole database, so it can take a while for large
+databases and needs up to twice the database's size in free disk space.`,
+	Args: cobra.NoArgs,
+	RunE: runDBVacuum,
+}
+
+func init() {
+	dbCmd.AddCommand(dbVacuumCmd)
+	rootCmd.AddCommand(dbCmd)
+}
+
+func runDBVacuum(cmd *cobra.Command, args []string) error {
+	dbPath := tasked.GlobalSettings.GetDatabaseFile()
+
+	// Initialize the planner
+	p, err := tasked.GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	before, sizeKnown := fileSize(dbPath)
+
+	if err := p.Vacuum(); err != nil {
+		return err
+	}
+
+	after, _ := fileSize(dbPath)
+	if sizeKnown {
+		tasked.GlobalSettings.Successf("Vacuumed database %s: %d bytes -> %d bytes\n", dbPath, before, after)
+	} else {
+		tasked.GlobalSettings.Successf("Vacuumed database %s\n", dbPath)
+	}
+	return nil
+}
+
+// fileSize returns the size of the file at path, and false if it cannot be
+// determined, e.g. for in-memory databases.
+func fileSize(path string) (int64, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	return info.Size(), true
+}
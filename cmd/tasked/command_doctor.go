@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/dhamidi/tasked"
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that the database is reachable and its schema is current",
+	Long: `Open the configured database and check its health: all expected tables and
+columns exist, foreign key constraints are enforced, write-ahead logging is
+enabled, and no rows reference missing plans or steps (which can happen if the
+database was modified by a tool that did not enforce foreign keys). The SQLite
+version and journal mode are reported as well.
+
+The database is opened read-only and checked as it is on disk: unlike other
+commands, doctor neither creates a missing database file nor adds missing
+tables and columns. A database file that does not exist is an error.
+
+Exits with a non-zero status if the database cannot be opened or any problem is
+found, so it can be used as a check in CI pipelines.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	dbPath := tasked.GlobalSettings.GetDatabaseFile()
+
+	// Open the database without creating or migrating it, so that the checks
+	// see the schema on disk. In-memory databases have nothing on disk and
+	// are opened like by any other command.
+	var p *planner.Planner
+	var err error
+	if dbPath == ":memory:" || strings.HasPrefix(dbPath, "file:") {
+		p, err = tasked.GlobalSettings.OpenPlanner()
+	} else {
+		if _, statErr := os.Stat(dbPath); errors.Is(statErr, fs.ErrNotExist) {
+			return fmt.Errorf("database not found at %s", dbPath)
+		}
+		p, err = planner.NewReadOnly(dbPath, tasked.GlobalSettings.PlannerOptions())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	report, err := p.HealthCheck()
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+
+	violations, err := p.CheckIntegrity()
+	if err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	report.Problems = append(report.Problems, violations...)
+
+	fmt.Printf("Database:      %s\n", dbPath)
+	fmt.Printf("SQLite:        %s\n", report.SQLiteVersion)
+	fmt.Printf("Journal mode:  %s\n", report.JournalMode)
+	if report.ForeignKeys {
+		fmt.Println("Foreign keys:  enforced")
+	} else {
+		fmt.Println("Foreign keys:  not enforced")
+	}
+
+	if report.OK() {
+		fmt.Println("No problems found.")
+		return nil
+	}
+
+	fmt.Println("Problems:")
+	for _, problem := range report.Problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+	return fmt.Errorf("found %d problem(s) with database %s", len(report.Problems), dbPath)
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/dhamidi/tasked"
 	"github.com/dhamidi/tasked/planner"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/spf13/cobra"
 )
@@ -14,40 +22,144 @@ var mcpCmd = &cobra.Command{
 	Use:   "mcp",
 	Short: "Start an MCP server providing planner tools",
 	Long: `Start a Model Context Protocol (MCP) server that provides access to the planner
-functionality. The server runs on standard input/output and can be used by MCP clients
-to interact with the task planner.`,
+functionality. By default the server runs on standard input/output and can be used
+by MCP clients that launch tasked as a subprocess.
+
+With --transport sse the server instead listens for HTTP connections on --addr,
+allowing multiple MCP clients to share one long-lived server. Clients connect to
+the /sse endpoint and post messages to /message.
+
+Besides the manage_plan tool, every plan is exposed as an MCP resource with the
+URI plan://<name>, whose content is the same markdown shown by 'plan inspect'.
+
+With --read-only, the manage_plan tool only offers the actions that do not change
+plans (inspect, list_plans, get_next_step and is_completed), so that untrusted
+clients can look at plans but not modify or remove them.
+
+On SIGINT or SIGTERM the server stops serving and closes the database, moving
+the contents of the write-ahead log into the database file before it exits.`,
 	RunE: runMCPServer,
 }
 
+var mcpTransport string
+var mcpAddr string
+var mcpReadOnly bool
+
 func init() {
+	mcpCmd.Flags().StringVar(&mcpTransport, "transport", "stdio", "Transport to serve MCP over (stdio|sse)")
+	mcpCmd.Flags().StringVar(&mcpAddr, "addr", "localhost:8080", "Address to listen on when using the sse transport")
+	mcpCmd.Flags().BoolVar(&mcpReadOnly, "read-only", false, "Only offer actions that do not change plans")
 	rootCmd.AddCommand(mcpCmd)
 }
 
 func runMCPServer(cmd *cobra.Command, args []string) error {
+	if mcpTransport != "stdio" && mcpTransport != "sse" {
+		return fmt.Errorf("unknown transport '%s' (must be 'stdio' or 'sse')", mcpTransport)
+	}
+
 	// Get the database file path from settings
 	dbPath := tasked.GlobalSettings.GetDatabaseFile()
+	if err := tasked.GlobalSettings.CheckDatabaseExists(dbPath); err != nil {
+		return err
+	}
 
 	// Initialize the planner tool
-	toolInfo, err := planner.MakePlannerToolHandler(dbPath)
+	toolOptions := tasked.GlobalSettings.PlannerOptions()
+	toolOptions.ReadOnly = mcpReadOnly
+	toolInfo, err := planner.MakePlannerToolHandlerWithOptions(dbPath, toolOptions)
 	if err != nil {
 		return fmt.Errorf("failed to initialize planner tool: %w", err)
 	}
+	defer closePlanner(toolInfo.Planner)
+
+	// Initialize the plan resources
+	resourceInfo, err := planner.MakePlannerResourcesWithOptions(dbPath, tasked.GlobalSettings.PlannerOptions())
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner resources: %w", err)
+	}
+	defer closePlanner(resourceInfo.Planner)
+
+	// Plans come and go while the server runs, so the listed resources
+	// are refreshed from the database whenever a client lists them.
+	var srv *server.MCPServer
+	hooks := &server.Hooks{}
+	hooks.AddBeforeListResources(func(ctx context.Context, id any, req *mcp.ListResourcesRequest) {
+		resources, err := resourceInfo.List()
+		if err != nil {
+			log.Printf("failed to list plan resources: %v", err)
+			return
+		}
+		entries := make([]server.ServerResource, len(resources))
+		for i, resource := range resources {
+			entries[i] = server.ServerResource{Resource: resource, Handler: resourceInfo.Handler}
+		}
+		srv.SetResources(entries...)
+	})
 
 	// Create a new MCP server
-	srv := server.NewMCPServer(
+	srv = server.NewMCPServer(
 		"tasked-planner",
 		"1.0.0",
 		server.WithLogging(),
+		server.WithHooks(hooks),
+		server.WithResourceCapabilities(false, false),
 	)
 
 	// Register the planner tool
 	srv.AddTool(toolInfo.Tool, toolInfo.Handler)
 
+	// Register the plan resources
+	srv.AddResourceTemplate(resourceInfo.Template, resourceInfo.Handler)
+
+	// Stop serving on SIGINT or SIGTERM, so that the planners are closed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if mcpTransport == "sse" {
+		// Send the message endpoint as a path so clients resolve it against
+		// whatever address they used to reach the server.
+		sseServer := server.NewSSEServer(srv, server.WithUseFullURLForMessageEndpoint(false))
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), mcpShutdownTimeout)
+			defer cancel()
+			if err := sseServer.Shutdown(shutdownCtx); err != nil {
+				log.Printf("failed to shut down MCP server: %v", err)
+			}
+		}()
+
+		log.Printf("Starting MCP SSE server on %s with database: %s", mcpAddr, dbPath)
+		if err := sseServer.Start(mcpAddr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("MCP server error: %w", err)
+		}
+		log.Printf("MCP server stopped")
+		return nil
+	}
+
 	// Start the server on stdio
 	log.Printf("Starting MCP server with database: %s", dbPath)
-	if err := server.ServeStdio(srv); err != nil {
+	err = server.NewStdioServer(srv).Listen(ctx, os.Stdin, os.Stdout)
+	if err != nil && !errors.Is(err, context.Canceled) {
 		return fmt.Errorf("MCP server error: %w", err)
 	}
+	log.Printf("MCP server stopped")
 
 	return nil
 }
+
+// mcpShutdownTimeout is how long the SSE server waits for open requests
+// to finish when it is stopped.
+const mcpShutdownTimeout = 5 * time.Second
+
+// closePlanner checkpoints the write-ahead log and closes a planner used by
+// the MCP server, so that no -wal file with pending changes is left behind.
+// The server is already stopping at this point, so failures are only logged.
+func closePlanner(p *planner.Planner) {
+	if err := p.Checkpoint(); err != nil {
+		log.Printf("failed to checkpoint database: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		log.Printf("failed to close planner: %v", err)
+	}
+}
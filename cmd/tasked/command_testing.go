@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dhamidi/tasked/planner"
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/spf13/cobra"
@@ -477,7 +478,36 @@ func runManagePlanTestScenario(ctx context.Context, c *client.Client) error {
 		failTest("Expected plan to be completed, got completed=%v", isCompleted)
 	}
 
-	// Test 12: compact_plans - Cleanup completed plans
+	// Test 12: resources - The plan is listed and readable as plan://<name>
+	log.Printf("→ Resources: list and read %s", planner.PlanURI(testPlan))
+	resources, err := c.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		failTest("Failed to list resources: %v", err)
+	}
+	listed := false
+	for _, resource := range resources.Resources {
+		if resource.URI == planner.PlanURI(testPlan) {
+			listed = true
+		}
+	}
+	if !listed {
+		failTest("Expected resource %s to be listed, got %v", planner.PlanURI(testPlan), resources.Resources)
+	}
+	contents, err := c.ReadResource(ctx, mcp.ReadResourceRequest{
+		Params: mcp.ReadResourceParams{URI: planner.PlanURI(testPlan)},
+	})
+	if err != nil {
+		failTest("Failed to read resource: %v", err)
+	}
+	if len(contents.Contents) != 1 {
+		failTest("Expected 1 resource content, got %d", len(contents.Contents))
+	}
+	text, ok := contents.Contents[0].(mcp.TextResourceContents)
+	if !ok || !strings.Contains(text.Text, "step-1") {
+		failTest("Expected resource text to contain step-1, got %v", contents.Contents[0])
+	}
+
+	// Test 13: compact_plans - Cleanup completed plans
 	logToolCall("compact_plans", map[string]interface{}{
 		"plan_name": testPlan,
 		"action":    "compact_plans",
@@ -702,7 +732,7 @@ func runPlanSubcommandTest() error {
 	if err != nil {
 		return fmt.Errorf("failed to get next step: %w", err)
 	}
-	assertCommandOutput(stdout, []string{"step-1", "doc-1", "spec-A"}, "plan next-step")
+	assertCommandOutput(stdout, []string{"step-1", "doc-1", "spec-A", "steps remaining"}, "plan next-step")
 
 	// Test 6: plan mark-as-completed - Mark a step as done
 	stdout, err = execPlanCommand("mark-as-completed", []string{testPlan, "step-1"}, tempDB)
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dhamidi/tasked"
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the most recent destructive operation",
+	Long: `Revert the most recent operation recorded in the operations log: removing
+steps, removing plans, compacting, resetting or renumbering a plan. The affected plans are
+restored to the state they had before the operation, which discards any changes
+made to them since.
+
+Run undo repeatedly to revert earlier operations; the last 100 operations are
+kept. Deleting all plans with 'plan delete-all' is not recorded and cannot be
+undone.`,
+	Args: cobra.NoArgs,
+	RunE: runUndo,
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	// Initialize the planner
+	p, err := tasked.GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	op, err := p.Undo()
+	if errors.Is(err, planner.ErrNothingToUndo) {
+		fmt.Println("Nothing to undo.")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to undo: %w", err)
+	}
+
+	tasked.GlobalSettings.Successf("Undid %s from %s, restored plan(s): %s\n", op.Name, op.CreatedAt.Local().Format("2006-01-02 15:04"), strings.Join(op.Plans, ", "))
+	return nil
+}
@@ -14,6 +14,12 @@ var rootCmd = &cobra.Command{
 	Long: `Tasked is a command-line task management tool that helps you organize
 and track your tasks efficiently. Store tasks in a local SQLite database
 and manage them through simple CLI commands.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := tasked.GlobalSettings.CheckColor(); err != nil {
+			return err
+		}
+		return tasked.GlobalSettings.LoadConfig()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -29,7 +35,13 @@ var planCmd = &cobra.Command{
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&tasked.GlobalSettings.DatabaseFile, "database-file", "", "Path to the SQLite database file (default: ~/.tasked/tasks.db)")
+	rootCmd.PersistentFlags().StringVarP(&tasked.GlobalSettings.DatabaseFile, "database-file", "d", "", "Path to the SQLite database file (default: $TASKED_DATABASE or ~/.tasked/tasks.db)")
+	rootCmd.PersistentFlags().StringVar(&tasked.GlobalSettings.DatabaseFile, "db", "", "Alias for --database-file")
+	rootCmd.PersistentFlags().StringVar(&tasked.GlobalSettings.ConfigFile, "config", "", "Path to the config file (default: ~/.tasked/config.toml)")
+	rootCmd.PersistentFlags().BoolVar(&tasked.GlobalSettings.Verbose, "verbose", false, "Log SQL statements executed against the database to stderr")
+	rootCmd.PersistentFlags().StringVar(&tasked.GlobalSettings.Color, "color", "auto", "Color output of list and inspect: auto (only on a terminal without NO_COLOR), always, or never")
+	rootCmd.PersistentFlags().BoolVarP(&tasked.GlobalSettings.Quiet, "quiet", "q", false, "Suppress success messages; errors are still reported on stderr")
+	rootCmd.PersistentFlags().BoolVar(&tasked.GlobalSettings.RequireExistingDB, "require-existing-db", false, "Fail instead of creating the database file if it does not exist")
 
 	// Add plan subcommand group
 	rootCmd.AddCommand(planCmd)
@@ -46,6 +58,49 @@ func init() {
 	planCmd.AddCommand(tasked.PlanIsCompletedCmd)
 	planCmd.AddCommand(tasked.PlanAddStepCmd)
 	planCmd.AddCommand(tasked.PlanMarkAsIncompleteCmd)
+	planCmd.AddCommand(tasked.PlanCloneCmd)
+	planCmd.AddCommand(tasked.PlanEditStepCmd)
+	planCmd.AddCommand(tasked.PlanTagCmd)
+	planCmd.AddCommand(tasked.PlanLogTimeCmd)
+	planCmd.AddCommand(tasked.PlanProgressCmd)
+	planCmd.AddCommand(tasked.PlanShowStepCmd)
+	planCmd.AddCommand(tasked.PlanBlockCmd)
+	planCmd.AddCommand(tasked.PlanUnblockCmd)
+	planCmd.AddCommand(tasked.PlanStatsCmd)
+	planCmd.AddCommand(tasked.PlanArchiveCmd)
+	planCmd.AddCommand(tasked.PlanUnarchiveCmd)
+	planCmd.AddCommand(tasked.PlanMoveStepCmd)
+	planCmd.AddCommand(tasked.PlanAddStepsFromCmd)
+	planCmd.AddCommand(tasked.PlanDeleteAllCmd)
+	planCmd.AddCommand(tasked.PlanTuiCmd)
+	planCmd.AddCommand(tasked.PlanTouchCmd)
+	planCmd.AddCommand(tasked.PlanResetCmd)
+	planCmd.AddCommand(tasked.PlanStepStatusCmd)
+	planCmd.AddCommand(tasked.PlanAddCriterionCmd)
+	planCmd.AddCommand(tasked.PlanRemoveCriterionCmd)
+	planCmd.AddCommand(tasked.PlanGraphCmd)
+	planCmd.AddCommand(tasked.PlanMergeCmd)
+	planCmd.AddCommand(tasked.PlanSetStatusCmd)
+	planCmd.AddCommand(tasked.PlanValidateCmd)
+	planCmd.AddCommand(tasked.PlanPruneStepsCmd)
+	planCmd.AddCommand(tasked.PlanSaveTemplateCmd)
+	planCmd.AddCommand(tasked.PlanNewFromTemplateCmd)
+	planCmd.AddCommand(tasked.PlanListAssignedCmd)
+	planCmd.AddCommand(tasked.PlanCompleteNextCmd)
+	planCmd.AddCommand(tasked.PlanNextAcrossCmd)
+	planCmd.AddCommand(tasked.PlanExportCmd)
+	planCmd.AddCommand(tasked.PlanExportAllCmd)
+	planCmd.AddCommand(tasked.PlanHistoryCmd)
+	planCmd.AddCommand(tasked.PlanTodosCmd)
+	planCmd.AddCommand(tasked.PlanCheckCriterionCmd)
+	planCmd.AddCommand(tasked.PlanRenumberCmd)
+	planCmd.AddCommand(tasked.PlanUseCmd)
+	planCmd.AddCommand(tasked.PlanRenameCmd)
+	planCmd.AddCommand(tasked.PlanOpenReferencesCmd)
+	planCmd.AddCommand(tasked.PlanMoveToDBCmd)
+	planCmd.AddCommand(tasked.PlanOnCompleteCmd)
+	planCmd.AddCommand(tasked.PlanSetRecurrenceCmd)
+	planCmd.AddCommand(tasked.PlanDueCmd)
 }
 
 func Execute() {
@@ -0,0 +1,62 @@
+package tasked
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ANSI escape sequences used to color terminal output.
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+)
+
+// statusColors maps step and plan statuses to the color they are shown in.
+var statusColors = map[string]string{
+	"DONE":    ansiGreen,
+	"TODO":    ansiYellow,
+	"BLOCKED": ansiRed,
+}
+
+// CheckColor returns an error unless Color is empty, "auto", "always" or "never".
+func (s *Settings) CheckColor() error {
+	switch s.Color {
+	case "", "auto", "always", "never":
+		return nil
+	}
+	return fmt.Errorf("invalid --color value '%s' (must be 'auto', 'always' or 'never')", s.Color)
+}
+
+// UseColor reports whether output on stdout should be colored. With Color set
+// to "auto" (or empty), output is colored only when stdout is a terminal and
+// the NO_COLOR environment variable is not set.
+func (s *Settings) UseColor() bool {
+	switch s.Color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps text in the given ANSI color, or returns it unchanged if enabled is false.
+func colorize(text, color string, enabled bool) string {
+	if !enabled || color == "" {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// colorStatus returns "[STATUS]" colored by the status.
+func colorStatus(status string, enabled bool) string {
+	return colorize("["+status+"]", statusColors[strings.ToUpper(status)], enabled)
+}
@@ -0,0 +1,55 @@
+package tasked
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanAddCriterionCmd = &cobra.Command{
+	Use:   "add-criterion <plan-name> <step-id> <text>",
+	Short: "Add an acceptance criterion to a step",
+	Long: `Append an acceptance criterion to an existing step. The step keeps its other
+criteria, its status and its position in the plan.`,
+	Args: cobra.ExactArgs(3),
+	RunE: RunPlanAddCriterion,
+}
+
+func RunPlanAddCriterion(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+	criterion := args[2]
+
+	if strings.TrimSpace(criterion) == "" {
+		return fmt.Errorf("acceptance criterion must not be empty")
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Add the criterion to the step
+	step, err := plan.FindStep(stepID)
+	if err != nil {
+		return fmt.Errorf("failed to add criterion: %w", err)
+	}
+	step.AddCriterion(criterion)
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Added acceptance criterion %d to step '%s' in plan '%s'\n", len(step.AcceptanceCriteria()), stepID, planName)
+	return nil
+}
@@ -2,6 +2,8 @@ package tasked
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/dhamidi/tasked/planner"
@@ -9,113 +11,258 @@ import (
 )
 
 var PlanAddStepCmd = &cobra.Command{
-	Use:   "add-step [--after step-id] [--references ref1,ref2] <plan-name> <step-id> <description> <acceptance-criteria> ...",
+	Use:   "add-step [--after step-id | --before step-id] [--references ref]... [--estimate minutes] [--assignee name] [--validate-references [--strict-references]] [--allow-no-criteria] [--description-file file] [--acceptance criterion]... [--plan plan-name | <plan-name>] <step-id> <description> [<acceptance-criteria> ...]",
 	Short: "Add a new step to a plan",
 	Long: `Add a new step to an existing plan. The step can be positioned after a specific
-step using the --after flag. If no --after flag is provided, the step will be added
-at the end of the plan.
+step using the --after flag, or before one using the --before flag; the two cannot
+be combined. If neither flag is provided, the step will be added at the end of the plan.
 
-References can be added using the --references flag with comma-separated values.`,
-	Args: cobra.MinimumNArgs(3),
+The positional arguments are the plan name, the step ID, the step's description
+and then one or more acceptance criteria: every argument after the description
+is an acceptance criterion. A step without acceptance criteria is rejected
+unless --allow-no-criteria is given. The plan can also be given with --plan,
+in which case the positional arguments start with the step ID.
+
+If a default plan is set with 'plan use', the plan name can be left out when
+only the step ID and the description are given (or only the step ID with
+--description-file), e.g. with the criteria given with --acceptance; the step
+is then added to the default plan. With more arguments, the first one could be
+either a plan name or the step ID, so add-step fails unless the plan is given
+with --plan.
+
+Long, multi-line descriptions can be read from a file with --description-file,
+or from standard input with --description-file -. The description is then left
+out of the positional arguments: every argument after the step ID is an
+acceptance criterion.
+
+Acceptance criteria can also be given with the repeatable --acceptance flag,
+once per criterion. Then no argument is taken as a criterion: arguments after
+the description (or after the step ID with --description-file) are ignored
+with a warning.
+
+References can be added by repeating the --references flag, once per reference.
+Each value is kept verbatim, so references may contain commas. For backward
+compatibility, a single --references value is split on commas.
+A reference can be given a human-readable title with the "Title|URL" syntax,
+e.g. --references "Design doc|https://example.com/design".
+An estimate of the time needed for the step can be given in minutes with --estimate.
+The person owning the step can be recorded with --assignee.
+
+References are freeform and are not checked by default. With
+--validate-references, a warning is printed for every reference that is neither
+a URL (e.g. https://example.com/design) nor a local path (e.g. docs/spec.md or
+./notes.txt); with --strict-references, such references are rejected and the
+step is not added.
+
+Step IDs must not be empty, start with '-', contain control characters or
+surrounding whitespace, or be longer than 64 characters.`,
 	RunE: RunPlanAddStep,
 }
 
 var afterStepID string
-var referencesFlag string
+var beforeStepID string
+var referencesFlag []string
+var estimateFlag int
+var allowNoCriteriaFlag bool
+var assigneeFlag string
+var validateReferencesFlag bool
+var strictReferencesFlag bool
+var descriptionFileFlag string
+var acceptanceFlag []string
+var addStepPlanFlag string
 
 func init() {
 	PlanAddStepCmd.Flags().StringVar(&afterStepID, "after", "", "ID of the step after which to insert the new step")
-	PlanAddStepCmd.Flags().StringVar(&referencesFlag, "references", "", "Comma-separated list of references (URLs or other reference strings)")
+	PlanAddStepCmd.Flags().StringVar(&beforeStepID, "before", "", "ID of the step before which to insert the new step")
+	PlanAddStepCmd.Flags().StringArrayVar(&referencesFlag, "references", nil, "Reference for the step, e.g. a URL, file path or \"Title|URL\" (repeatable; a single value is split on commas)")
+	PlanAddStepCmd.Flags().IntVar(&estimateFlag, "estimate", 0, "Estimated time for the step in minutes")
+	PlanAddStepCmd.Flags().StringVar(&assigneeFlag, "assignee", "", "Who owns the step")
+	PlanAddStepCmd.Flags().BoolVar(&validateReferencesFlag, "validate-references", false, "Warn about references that are neither URLs nor local paths")
+	PlanAddStepCmd.Flags().BoolVar(&strictReferencesFlag, "strict-references", false, "Reject references that are neither URLs nor local paths (implies --validate-references)")
+	PlanAddStepCmd.Flags().BoolVar(&allowNoCriteriaFlag, "allow-no-criteria", false, "Allow adding a step without acceptance criteria")
+	PlanAddStepCmd.Flags().StringVar(&descriptionFileFlag, "description-file", "", "Read the description from a file, or from standard input if '-'")
+	PlanAddStepCmd.Flags().StringVar(&addStepPlanFlag, "plan", "", "Plan to add the step to, instead of the first positional argument")
+	PlanAddStepCmd.Flags().StringArrayVar(&acceptanceFlag, "acceptance", nil, "Acceptance criterion for the step (repeatable; arguments after the description are then ignored)")
 }
 
-func RunPlanAddStep(cmd *cobra.Command, args []string) error {
-	if len(args) < 3 {
-		return fmt.Errorf("requires at least 3 arguments: plan-name, step-id, description")
+// addStepArgs checks the positional arguments of add-step that follow the
+// plan name and names the ones that are missing, which the generic argument
+// count error does not.
+func addStepArgs(args []string) error {
+	required := []string{"<step-id>", "<description>"}
+	if descriptionFileFlag != "" {
+		required = required[:1]
+	}
+	if len(args) < len(required) {
+		return fmt.Errorf("missing %s (usage: add-step <plan-name> <step-id> <description> <acceptance-criteria> ...)",
+			strings.Join(required[len(args):], ", "))
+	}
+	return nil
+}
+
+// splitAddStepArgs returns the plan that add-step adds the step to and the
+// positional arguments that follow the plan name. The plan is planFlag if it
+// is set, and otherwise the first argument, unless defaultPlan is set and
+// there are at most minArgs arguments, the number needed after the plan name.
+// Whether the first argument names an existing plan is deliberately not
+// considered, so that a mistyped plan name is not taken as a step ID.
+// With a default plan and more arguments, the first one could be either, so
+// an error is returned.
+func splitAddStepArgs(args []string, planFlag, defaultPlan string, minArgs int) (string, []string, error) {
+	if planFlag != "" {
+		return planFlag, args, nil
 	}
+	if defaultPlan == "" {
+		if len(args) == 0 {
+			return "", nil, fmt.Errorf("missing <plan-name> (usage: add-step <plan-name> <step-id> <description> <acceptance-criteria> ...)")
+		}
+		return args[0], args[1:], nil
+	}
+	if len(args) <= minArgs {
+		return defaultPlan, args, nil
+	}
+	return "", nil, fmt.Errorf("cannot tell whether '%s' is a plan name or the ID of a step for the default plan '%s': give the plan with --plan, or leave out the plan name and give the acceptance criteria with --acceptance", args[0], defaultPlan)
+}
 
-	planName := args[0]
-	stepID := args[1]
-	description := args[2]
-	acceptanceCriteria := args[3:]
+func RunPlanAddStep(cmd *cobra.Command, args []string) error {
+	if afterStepID != "" && beforeStepID != "" {
+		return fmt.Errorf("--after and --before cannot be combined")
+	}
+	if estimateFlag < 0 {
+		return fmt.Errorf("estimate must not be negative")
+	}
 
-	// Get the database file path from settings
-	dbPath := GlobalSettings.GetDatabaseFile()
+	// Without a plan name, the step is added to the default plan
+	minArgs := 2 // <step-id> <description>
+	if descriptionFileFlag != "" {
+		minArgs = 1
+	}
+	planName, args, err := splitAddStepArgs(args, addStepPlanFlag, GlobalSettings.DefaultPlan, minArgs)
+	if err != nil {
+		return err
+	}
+	if err := addStepArgs(args); err != nil {
+		return err
+	}
 
 	// Initialize the planner
-	p, err := planner.New(dbPath)
+	p, err := GlobalSettings.OpenPlanner()
 	if err != nil {
 		return fmt.Errorf("failed to initialize planner: %w", err)
 	}
 	defer p.Close()
 
-	// Get the existing plan
-	plan, err := p.Get(planName)
-	if err != nil {
-		return fmt.Errorf("failed to get plan: %w", err)
+	stepID := args[0]
+	var description string
+	trailing := args[1:]
+	after := "description"
+	if descriptionFileFlag != "" {
+		description, err = readDescriptionFile(descriptionFileFlag)
+		if err != nil {
+			return err
+		}
+		after = "step ID"
+	} else {
+		description, trailing = trailing[0], trailing[1:]
 	}
 
-	// Check if step ID already exists
-	for _, step := range plan.Steps {
-		if step.ID() == stepID {
-			return fmt.Errorf("step with ID '%s' already exists in plan '%s'", stepID, planName)
-		}
+	// Criteria given with --acceptance take the place of trailing arguments
+	acceptanceCriteria, ignored := splitCriteria(trailing, acceptanceFlag, cmd.Flags().Changed("acceptance"))
+	if len(ignored) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %d argument(s) after the %s since --acceptance is given: %s\n",
+			len(ignored), after, strings.Join(ignored, " "))
 	}
 
-	// Find the insertion position
-	insertIndex := len(plan.Steps) // Default to end
-	if afterStepID != "" {
-		found := false
-		for i, step := range plan.Steps {
-			if step.ID() == afterStepID {
-				insertIndex = i + 1
-				found = true
-				break
-			}
-		}
-		if !found {
-			return fmt.Errorf("step with ID '%s' not found in plan '%s'", afterStepID, planName)
-		}
+	if len(acceptanceCriteria) == 0 && !allowNoCriteriaFlag && descriptionFileFlag != "" {
+		return fmt.Errorf("no acceptance criteria given for step '%s': with --description-file, every argument after the step ID is an acceptance criterion; add at least one, give them with --acceptance, or pass --allow-no-criteria", stepID)
+	}
+	if len(acceptanceCriteria) == 0 && !allowNoCriteriaFlag {
+		return fmt.Errorf("no acceptance criteria given for step '%s': every argument after the description %q is an acceptance criterion; add at least one, give them with --acceptance, or pass --allow-no-criteria", stepID, description)
+	}
+	if err := planner.ValidateStepID(stepID); err != nil {
+		return fmt.Errorf("invalid step ID: %w", err)
 	}
 
-	// Parse references from comma-separated string
-	var references []string
-	if referencesFlag != "" {
-		references = strings.Split(referencesFlag, ",")
-		// Trim whitespace from each reference
-		for i, ref := range references {
-			references[i] = strings.TrimSpace(ref)
+	// Parse references from the repeated flag values
+	references := parseReferences(referencesFlag)
+	if validateReferencesFlag || strictReferencesFlag {
+		for _, ref := range references {
+			err := ref.Validate()
+			if err == nil {
+				continue
+			}
+			if strictReferencesFlag {
+				return fmt.Errorf("invalid reference: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 		}
 	}
 
-	// Add the step at the end first (AddStep always appends)
-	plan.AddStep(stepID, description, acceptanceCriteria, references)
+	step := planner.NewStep(stepID, description, acceptanceCriteria, references)
+	step.SetEstimateMinutes(estimateFlag)
+	step.SetAssignee(assigneeFlag)
 
-	// If we need to insert it in a specific position (not at the end), reorder
-	if afterStepID != "" && insertIndex < len(plan.Steps)-1 {
-		// Create new order that puts our step in the right position
-		var newOrder []string
+	// Insert only the new step, without rewriting the rest of the plan
+	position := planner.Position{After: afterStepID, Before: beforeStepID}
+	if err := p.InsertStep(planName, step, position); err != nil {
+		return fmt.Errorf("failed to add step: %w", err)
+	}
 
-		// Add all steps before the insertion point
-		for i := 0; i < insertIndex; i++ {
-			newOrder = append(newOrder, plan.Steps[i].ID())
-		}
+	GlobalSettings.Successf("Added step '%s' to plan '%s'\n", stepID, planName)
+	return nil
+}
 
-		// Add our new step
-		newOrder = append(newOrder, stepID)
+// splitCriteria returns the acceptance criteria of the step and the trailing
+// positional arguments that are ignored. Without --acceptance (acceptanceSet),
+// every trailing argument is a criterion; with it, the criteria of the flag
+// are used and the trailing arguments are ignored.
+func splitCriteria(trailing []string, acceptance []string, acceptanceSet bool) ([]string, []string) {
+	if acceptanceSet {
+		return acceptance, trailing
+	}
+	return trailing, nil
+}
 
-		// Add all steps after the insertion point (excluding our step which is at the end)
-		for i := insertIndex; i < len(plan.Steps)-1; i++ {
-			newOrder = append(newOrder, plan.Steps[i].ID())
-		}
+// readDescriptionFile reads a step description from the file at path, or from
+// standard input if path is "-". Surrounding whitespace, such as the final
+// newline, is removed; line breaks within the description are kept.
+func readDescriptionFile(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read description: %w", err)
+	}
 
-		plan.Reorder(newOrder)
+	description := strings.TrimSpace(string(data))
+	if description == "" {
+		return "", fmt.Errorf("description read from '%s' is empty", path)
 	}
+	return description, nil
+}
 
-	// Save the updated plan
-	if err := p.Save(plan); err != nil {
-		return fmt.Errorf("failed to save plan: %w", err)
+// parseReferences returns the references given through a repeatable --references flag.
+// When the flag was given more than once, each value is one reference and is kept verbatim.
+// A single value is treated as a comma-separated list, trimming whitespace around
+// each reference and skipping empty ones, which keeps the original
+// "--references ref1,ref2" syntax working.
+// Each reference may carry a title using the "Title|URL" syntax.
+func parseReferences(values []string) []planner.Reference {
+	split := len(values) == 1
+	if split {
+		values = strings.Split(values[0], ",")
 	}
 
-	fmt.Printf("Added step '%s' to plan '%s'\n", stepID, planName)
-	return nil
+	var references []planner.Reference
+	for _, value := range values {
+		if split && strings.TrimSpace(value) == "" {
+			continue
+		}
+		references = append(references, planner.ParseReference(value))
+	}
+	return references
 }
@@ -0,0 +1,139 @@
+package tasked
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSplitAddStepArgs tests how add-step picks the plan from its arguments,
+// the --plan flag and the default plan.
+func TestSplitAddStepArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		planFlag    string
+		defaultPlan string
+		minArgs     int
+		wantPlan    string
+		wantArgs    []string
+		wantErr     bool
+	}{
+		{
+			name:     "plan name first without a default plan",
+			args:     []string{"my-plan", "step-1", "Do it", "It is done"},
+			minArgs:  2,
+			wantPlan: "my-plan",
+			wantArgs: []string{"step-1", "Do it", "It is done"},
+		},
+		{
+			name:    "no arguments without a default plan",
+			minArgs: 2,
+			wantErr: true,
+		},
+		{
+			name:        "plan flag wins over the default plan",
+			args:        []string{"step-1", "Do it", "It is done"},
+			planFlag:    "other",
+			defaultPlan: "active",
+			minArgs:     2,
+			wantPlan:    "other",
+			wantArgs:    []string{"step-1", "Do it", "It is done"},
+		},
+		{
+			name:        "default plan when only the step ID and description are given",
+			args:        []string{"step-1", "Do it"},
+			defaultPlan: "active",
+			minArgs:     2,
+			wantPlan:    "active",
+			wantArgs:    []string{"step-1", "Do it"},
+		},
+		{
+			name:        "default plan with a description file",
+			args:        []string{"step-1"},
+			defaultPlan: "active",
+			minArgs:     1,
+			wantPlan:    "active",
+			wantArgs:    []string{"step-1"},
+		},
+		{
+			name:        "ambiguous first argument with a default plan",
+			args:        []string{"my-plna", "step-1", "Do it", "It is done"},
+			defaultPlan: "active",
+			minArgs:     2,
+			wantErr:     true,
+		},
+		{
+			name:        "criteria as arguments with a default plan are ambiguous",
+			args:        []string{"step-1", "Do it", "It is done"},
+			defaultPlan: "active",
+			minArgs:     2,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, args, err := splitAddStepArgs(tt.args, tt.planFlag, tt.defaultPlan, tt.minArgs)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got plan %q and arguments %v", plan, args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitAddStepArgs failed: %v", err)
+			}
+			if plan != tt.wantPlan {
+				t.Errorf("Expected plan %q, got %q", tt.wantPlan, plan)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Expected arguments %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
+
+// TestSplitCriteria tests how add-step picks the acceptance criteria from the
+// trailing arguments and --acceptance, and which arguments it ignores.
+func TestSplitCriteria(t *testing.T) {
+	tests := []struct {
+		name          string
+		trailing      []string
+		acceptance    []string
+		acceptanceSet bool
+		wantCriteria  []string
+		wantIgnored   []string
+	}{
+		{
+			name:         "trailing arguments are criteria",
+			trailing:     []string{"Bug is fixed", "Test passes"},
+			wantCriteria: []string{"Bug is fixed", "Test passes"},
+		},
+		{
+			name:          "acceptance without trailing arguments",
+			acceptance:    []string{"Bug is fixed"},
+			acceptanceSet: true,
+			wantCriteria:  []string{"Bug is fixed"},
+		},
+		{
+			name:          "trailing arguments are ignored with acceptance",
+			trailing:      []string{"the", "bug"},
+			acceptance:    []string{"Bug is fixed"},
+			acceptanceSet: true,
+			wantCriteria:  []string{"Bug is fixed"},
+			wantIgnored:   []string{"the", "bug"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			criteria, ignored := splitCriteria(tt.trailing, tt.acceptance, tt.acceptanceSet)
+			if !reflect.DeepEqual(criteria, tt.wantCriteria) {
+				t.Errorf("Expected criteria %v, got %v", tt.wantCriteria, criteria)
+			}
+			if len(ignored) != len(tt.wantIgnored) || (len(ignored) > 0 && !reflect.DeepEqual(ignored, tt.wantIgnored)) {
+				t.Errorf("Expected ignored arguments %v, got %v", tt.wantIgnored, ignored)
+			}
+		})
+	}
+}
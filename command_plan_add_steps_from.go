@@ -0,0 +1,100 @@
+package tasked
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var PlanAddStepsFromCmd = &cobra.Command{
+	Use:   "add-steps-from <plan-name> <file>",
+	Short: "Add several steps to a plan from a file",
+	Long: `Add all steps defined in a YAML or JSON file to the end of an existing plan.
+Files ending in .json are read as JSON, all other files as YAML.
+
+The file must contain a list of step definitions, for example:
+
+  - id: setup-db
+    description: Configure the database
+    acceptance_criteria:
+      - Database connects successfully
+    references:
+      - Setup guide|https://example.com/db-setup
+      - /config/database.yml
+
+The steps are added in a single save. If any definition is invalid, for example
+because its ID is already used, no step is added.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanAddStepsFrom,
+}
+
+func RunPlanAddStepsFrom(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	fileName := args[1]
+
+	// Read the step definitions
+	specs, err := readStepSpecs(fileName)
+	if err != nil {
+		return err
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Add the steps
+	if err := plan.AddSteps(specs); err != nil {
+		return fmt.Errorf("failed to add steps: %w", err)
+	}
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Added %d step(s) to plan '%s'\n", len(specs), planName)
+	return nil
+}
+
+// readStepSpecs reads a list of step definitions from fileName.
+// Files with a .json extension are decoded as JSON, everything else as YAML.
+func readStepSpecs(fileName string) ([]planner.StepSpec, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read steps file: %w", err)
+	}
+
+	var specs []planner.StepSpec
+	if strings.EqualFold(filepath.Ext(fileName), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&specs)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&specs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse steps file '%s': %w", fileName, err)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("steps file '%s' does not define any steps", fileName)
+	}
+	return specs, nil
+}
@@ -0,0 +1,36 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanArchiveCmd = &cobra.Command{
+	Use:   "archive <plan-name>",
+	Short: "Archive a plan",
+	Long: `Archive a plan so it no longer shows up in 'plan list'. Unlike 'plan remove',
+the plan and its steps are kept and can still be inspected. Use 'plan list --all'
+to see archived plans and 'plan unarchive' to restore the plan.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanArchive,
+}
+
+func RunPlanArchive(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Archive the plan
+	if err := p.Archive(planName); err != nil {
+		return fmt.Errorf("failed to archive plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Archived plan '%s'\n", planName)
+	return nil
+}
@@ -0,0 +1,49 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanBlockCmd = &cobra.Command{
+	Use:   "block <plan-name> <step-id> <reason>",
+	Short: "Mark a step as blocked",
+	Long: `Mark a step as blocked (BLOCKED status) because of an external blocker, recording
+the reason. Blocked steps are skipped by 'plan next-step' and keep the plan from
+being completed. Use 'plan unblock' once the blocker is resolved.`,
+	Args: cobra.ExactArgs(3),
+	RunE: RunPlanBlock,
+}
+
+func RunPlanBlock(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+	reason := args[2]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Mark the step as blocked
+	if err := plan.MarkAsBlocked(stepID, reason); err != nil {
+		return fmt.Errorf("failed to block step: %w", err)
+	}
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Step '%s' in plan '%s' marked as blocked: %s\n", stepID, planName, reason)
+	return nil
+}
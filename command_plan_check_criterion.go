@@ -0,0 +1,66 @@
+package tasked
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanCheckCriterionCmd = &cobra.Command{
+	Use:   "check-criterion <plan-name> <step-id> <index>",
+	Short: "Toggle whether an acceptance criterion is met",
+	Long: `Check an acceptance criterion of a step as met, or uncheck it if it already is.
+The index is 1-based and matches the numbers shown by 'plan inspect' and
+'plan show-step', where met criteria are shown as [x] and the others as [ ].
+
+Checking criteria does not change the step's status; use
+'plan mark-as-completed --strict' to only complete steps whose criteria are all met.`,
+	Args: cobra.ExactArgs(3),
+	RunE: RunPlanCheckCriterion,
+}
+
+func RunPlanCheckCriterion(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+
+	index, err := strconv.Atoi(args[2])
+	if err != nil || index <= 0 {
+		return fmt.Errorf("index must be a positive whole number, got '%s'", args[2])
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Toggle the criterion
+	step, err := plan.FindStep(stepID)
+	if err != nil {
+		return fmt.Errorf("failed to check criterion: %w", err)
+	}
+	met := !step.CriterionMet(index)
+	if err := step.SetCriterionMet(index, met); err != nil {
+		return fmt.Errorf("failed to check criterion: %w", err)
+	}
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	if met {
+		GlobalSettings.Successf("Checked acceptance criterion %d of step '%s' in plan '%s' as met\n", index, stepID, planName)
+	} else {
+		GlobalSettings.Successf("Unchecked acceptance criterion %d of step '%s' in plan '%s'\n", index, stepID, planName)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanCloneCmd = &cobra.Command{
+	Use:   "clone <source-plan> <new-plan>",
+	Short: "Create a new plan by copying an existing one",
+	Long: `Create a new plan by copying all steps of an existing plan, including their
+descriptions, acceptance criteria, references and order. Every step in the new
+plan starts out as TODO, regardless of its status in the source plan.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanClone,
+}
+
+func RunPlanClone(cmd *cobra.Command, args []string) error {
+	sourceName := args[0]
+	newName := args[1]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Clone the plan
+	plan, err := p.Clone(sourceName, newName)
+	if err != nil {
+		return fmt.Errorf("failed to clone plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Cloned plan '%s' into '%s' (%d steps)\n", sourceName, newName, len(plan.Steps))
+	return nil
+}
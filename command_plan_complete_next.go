@@ -0,0 +1,70 @@
+package tasked
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanCompleteNextCmd = &cobra.Command{
+	Use:   "complete-next [--json] <plan-name> <step-id>",
+	Short: "Mark a step as completed and show the next step",
+	Long: `Mark a step as completed and display the plan's next incomplete step, like
+running 'plan mark-as-completed' followed by 'plan next-step', but in a single
+transaction so no other process can change the plan in between.
+
+Use --json to print the next step in the format of 'plan next-step --json';
+null is printed if there is no step left to work on.
+Setting output_format = "json" in the config file makes JSON the default;
+pass --json=false to override it.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanCompleteNext,
+}
+
+var completeNextJSONFlag bool
+
+func init() {
+	PlanCompleteNextCmd.Flags().BoolVar(&completeNextJSONFlag, "json", false, "Print the next step as JSON")
+}
+
+func RunPlanCompleteNext(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+	if !cmd.Flags().Changed("json") {
+		completeNextJSONFlag = GlobalSettings.OutputFormat == "json"
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Complete the step and get the next one
+	nextStep, err := p.CompleteAndNext(planName, stepID)
+	if err != nil {
+		return fmt.Errorf("failed to mark step as completed: %w", err)
+	}
+
+	if completeNextJSONFlag {
+		// A nil step is encoded as null
+		output, err := json.Marshal(nextStep)
+		if err != nil {
+			return fmt.Errorf("failed to encode step: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	GlobalSettings.Successf("Step '%s' in plan '%s' marked as completed\n", stepID, planName)
+	if nextStep == nil {
+		fmt.Printf("Plan '%s' has no steps left to work on\n", planName)
+		return nil
+	}
+
+	fmt.Printf("Next step: %s\n", nextStep.ID())
+	printStepDetails(nextStep)
+	return nil
+}
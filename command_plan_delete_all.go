@@ -0,0 +1,80 @@
+package tasked
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanDeleteAllCmd = &cobra.Command{
+	Use:   "delete-all [--yes]",
+	Short: "Delete every plan in the database",
+	Long: `Permanently delete all plans, including archived ones, together with their
+steps, acceptance criteria and references.
+
+The deletion must be confirmed, either by passing --yes or by answering the
+prompt shown when running in a terminal. Without confirmation nothing is deleted
+and the number of plans that would be affected is printed.`,
+	Args: cobra.NoArgs,
+	RunE: RunPlanDeleteAll,
+}
+
+var deleteAllYesFlag bool
+
+func init() {
+	PlanDeleteAllCmd.Flags().BoolVar(&deleteAllYesFlag, "yes", false, "Delete all plans without asking for confirmation")
+}
+
+func RunPlanDeleteAll(cmd *cobra.Command, args []string) error {
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Count the plans that would be deleted
+	plans, err := p.ListAll()
+	if err != nil {
+		return fmt.Errorf("failed to list plans: %w", err)
+	}
+	if len(plans) == 0 {
+		fmt.Println("No plans found.")
+		return nil
+	}
+
+	question := fmt.Sprintf("Delete all %d plan(s)? This cannot be undone.", len(plans))
+	if !deleteAllYesFlag && !confirm(question) {
+		fmt.Printf("Aborted: %d plan(s) would be deleted. Pass --yes to confirm.\n", len(plans))
+		return nil
+	}
+
+	// Delete all plans
+	removed, err := p.RemoveAll()
+	if err != nil {
+		return fmt.Errorf("failed to delete plans: %w", err)
+	}
+
+	GlobalSettings.Successf("Deleted %d plan(s)\n", removed)
+	return nil
+}
+
+// confirm asks the user the given yes/no question.
+// It returns false without asking when standard input is not a terminal.
+func confirm(question string) bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
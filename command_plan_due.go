@@ -0,0 +1,47 @@
+package tasked
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanDueCmd = &cobra.Command{
+	Use:   "due",
+	Short: "List recurring plans that are due to be started over",
+	Long: `List the recurring plans, as set with 'plan set-recurrence', that are completed
+and whose recurrence has elapsed since they were last updated: a day for daily
+plans and a week for weekly plans. Archived plans are not listed.
+
+Each line shows the plan, its recurrence and when it was last updated, followed
+by the 'plan reset' command that starts it over.`,
+	Args: cobra.NoArgs,
+	RunE: RunPlanDue,
+}
+
+func RunPlanDue(cmd *cobra.Command, args []string) error {
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the due plans
+	due, err := p.Due(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to list due plans: %w", err)
+	}
+
+	if len(due) == 0 {
+		fmt.Println("No recurring plans are due.")
+		return nil
+	}
+
+	for _, info := range due {
+		fmt.Printf("%s (%s, last updated %s): tasked plan reset %s\n",
+			info.Name, info.Recurrence, info.UpdatedAt.Local().Format("2006-01-02 15:04"), info.Name)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+)
+
+var PlanEditStepCmd = &cobra.Command{
+	Use:   "edit-step [--description text | --description-file file] [--acceptance criterion]... [--references ref]... <plan-name> <step-id>",
+	Short: "Modify an existing step",
+	Long: `Modify the description, acceptance criteria or references of an existing step.
+Only the fields given as flags are changed; the step keeps its status and its
+position in the plan.
+
+Acceptance criteria are replaced by all values passed with --acceptance, which can
+be repeated. References are replaced by all values passed with --references, which
+can also be repeated; a single --references value is split on commas.
+
+A long, multi-line description can be read from a file with --description-file,
+or from standard input with --description-file -, instead of --description.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanEditStep,
+}
+
+var editStepDescription string
+var editStepAcceptance []string
+var editStepReferences []string
+var editStepDescriptionFile string
+
+func init() {
+	PlanEditStepCmd.Flags().StringVar(&editStepDescription, "description", "", "New description for the step")
+	PlanEditStepCmd.Flags().StringVar(&editStepDescriptionFile, "description-file", "", "Read the new description from a file, or from standard input if '-'")
+	PlanEditStepCmd.Flags().StringArrayVar(&editStepAcceptance, "acceptance", nil, "Acceptance criterion for the step (repeatable, replaces existing criteria)")
+	PlanEditStepCmd.Flags().StringArrayVar(&editStepReferences, "references", nil, "Reference for the step (repeatable, replaces existing references; a single value is split on commas)")
+}
+
+func RunPlanEditStep(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+
+	flags := cmd.Flags()
+	if !flags.Changed("description") && !flags.Changed("description-file") && !flags.Changed("acceptance") && !flags.Changed("references") {
+		return fmt.Errorf("nothing to change: provide at least one of --description, --description-file, --acceptance or --references")
+	}
+	if flags.Changed("description") && flags.Changed("description-file") {
+		return fmt.Errorf("--description and --description-file cannot be combined")
+	}
+
+	var description *string
+	if flags.Changed("description") {
+		description = &editStepDescription
+	}
+	if flags.Changed("description-file") {
+		text, err := readDescriptionFile(editStepDescriptionFile)
+		if err != nil {
+			return err
+		}
+		description = &text
+	}
+
+	var acceptanceCriteria []string
+	if flags.Changed("acceptance") {
+		acceptanceCriteria = append([]string{}, editStepAcceptance...)
+	}
+
+	var references []planner.Reference
+	if flags.Changed("references") {
+		references = append([]planner.Reference{}, parseReferences(editStepReferences)...)
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Update the step
+	if err := plan.UpdateStep(stepID, description, acceptanceCriteria, references); err != nil {
+		return fmt.Errorf("failed to edit step: %w", err)
+	}
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Updated step '%s' in plan '%s'\n", stepID, planName)
+	return nil
+}
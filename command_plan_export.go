@@ -0,0 +1,75 @@
+package tasked
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanExportCmd = &cobra.Command{
+	Use:   "export [--format csv|json|markdown] [--output file] <plan-name>",
+	Short: "Export a plan for use in other tools",
+	Long: `Write a plan to standard output in a format other tools can read.
+
+Use --format to choose the output:
+  csv       one row per step with the columns step_id, status, description,
+            acceptance_criteria and references, e.g. for spreadsheets (default);
+            acceptance criteria and references are joined by ';'
+  json      the plan's ID, tags and steps as JSON, like 'plan inspect --format json'
+  markdown  a checklist, like 'plan inspect --format markdown'
+
+Use --output to write to a file instead of standard output; the file is
+replaced if it exists, and missing parent directories are created.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanExport,
+}
+
+var exportFormatFlag string
+var exportOutputFlag string
+
+func init() {
+	PlanExportCmd.Flags().StringVar(&exportFormatFlag, "format", "csv", "Output format: csv, json, or markdown")
+	PlanExportCmd.Flags().StringVarP(&exportOutputFlag, "output", "o", "", "Write the export to this file instead of standard output")
+}
+
+func RunPlanExport(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	if exportFormatFlag != "csv" && exportFormatFlag != "json" && exportFormatFlag != "markdown" {
+		return fmt.Errorf("invalid format '%s' (must be 'csv', 'json' or 'markdown')", exportFormatFlag)
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	return writeOutput(exportOutputFlag, func(w io.Writer) error {
+		switch exportFormatFlag {
+		case "json":
+			output, err := plan.ToJSON()
+			if err != nil {
+				return fmt.Errorf("failed to encode plan: %w", err)
+			}
+			_, err = fmt.Fprintln(w, string(output))
+			return err
+		case "markdown":
+			_, err := io.WriteString(w, plan.ExportMarkdown())
+			return err
+		default:
+			if err := plan.ExportCSV(w); err != nil {
+				return fmt.Errorf("failed to write plan: %w", err)
+			}
+			return nil
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package tasked
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+)
+
+var PlanExportAllCmd = &cobra.Command{
+	Use:   "export-all [--format csv] [--all] [--output file]",
+	Short: "Export the steps of all plans as CSV",
+	Long: `Write the steps of all plans to standard output as CSV, like 'plan export',
+with an additional first column 'plan' naming the plan each step belongs to.
+Plans are ordered by name. Archived plans are only included with --all.
+
+CSV is currently the only supported format. Use --output to write to a file
+instead of standard output, creating missing parent directories.`,
+	Args: cobra.NoArgs,
+	RunE: RunPlanExportAll,
+}
+
+var exportAllFormatFlag string
+var exportAllAllFlag bool
+var exportAllOutputFlag string
+
+func init() {
+	PlanExportAllCmd.Flags().StringVar(&exportAllFormatFlag, "format", "csv", "Output format: csv")
+	PlanExportAllCmd.Flags().BoolVar(&exportAllAllFlag, "all", false, "Include archived plans")
+	PlanExportAllCmd.Flags().StringVarP(&exportAllOutputFlag, "output", "o", "", "Write the export to this file instead of standard output")
+}
+
+func RunPlanExportAll(cmd *cobra.Command, args []string) error {
+	if exportAllFormatFlag != "csv" {
+		return fmt.Errorf("invalid format '%s' (must be 'csv')", exportAllFormatFlag)
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plans from the database
+	var infos []planner.PlanInfo
+	if exportAllAllFlag {
+		infos, err = p.ListAll()
+	} else {
+		infos, err = p.List()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list plans: %w", err)
+	}
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name
+	}
+	loaded, err := p.GetMany(names)
+	if err != nil {
+		return fmt.Errorf("failed to get plans: %w", err)
+	}
+	plans := make([]*planner.Plan, 0, len(names))
+	for _, name := range names {
+		if plan, ok := loaded[name]; ok {
+			plans = append(plans, plan)
+		}
+	}
+
+	return writeOutput(exportAllOutputFlag, func(w io.Writer) error {
+		if err := planner.ExportPlansCSV(w, plans, true); err != nil {
+			return fmt.Errorf("failed to write plans: %w", err)
+		}
+		return nil
+	})
+}
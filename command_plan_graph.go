@@ -0,0 +1,40 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanGraphCmd = &cobra.Command{
+	Use:   "graph <plan-name>",
+	Short: "Print a plan as a Graphviz DOT graph",
+	Long: `Print the plan as a Graphviz DOT digraph. Each step is a node colored by its
+status (green for DONE, white for TODO, red for BLOCKED), and edges connect each
+step to the next one in the plan's order.
+
+Pipe the output into Graphviz to render it, e.g.:
+  tasked plan graph my-plan | dot -Tpng -o my-plan.png`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanGraph,
+}
+
+func RunPlanGraph(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	fmt.Print(plan.ToDOT())
+	return nil
+}
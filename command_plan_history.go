@@ -0,0 +1,71 @@
+package tasked
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanHistoryCmd = &cobra.Command{
+	Use:   "history [--json] <plan-name>",
+	Short: "Show when the steps of a plan changed status",
+	Long: `Display the status changes of the steps of a plan, oldest first. Each line
+shows when the change was saved, the step ID and its old and new status, e.g.
+when a step was completed, reopened or blocked. Steps added with their initial
+status have no entry until their status changes.
+
+Use --json to print the changes as a JSON array of objects with the fields
+step_id, old_status, new_status and changed_at. Setting output_format = "json"
+in the config file makes JSON the default; pass --json=false to override it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanHistory,
+}
+
+var historyJSONFlag bool
+
+func init() {
+	PlanHistoryCmd.Flags().BoolVar(&historyJSONFlag, "json", false, "Print the status changes as JSON")
+}
+
+func RunPlanHistory(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	if !cmd.Flags().Changed("json") {
+		historyJSONFlag = GlobalSettings.OutputFormat == "json"
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the status changes from the database
+	changes, err := p.History(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get history: %w", err)
+	}
+
+	if historyJSONFlag {
+		output, err := json.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("failed to encode history: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("No status changes recorded for plan '%s'.\n", planName)
+		return nil
+	}
+
+	color := GlobalSettings.UseColor()
+	for _, change := range changes {
+		fmt.Printf("%s %s %s -> %s\n", change.ChangedAt.Local().Format("2006-01-02 15:04"), change.StepID,
+			colorStatus(change.OldStatus, color), colorStatus(change.NewStatus, color))
+	}
+	return nil
+}
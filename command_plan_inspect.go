@@ -1,29 +1,67 @@
 package tasked
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 
 	"github.com/dhamidi/tasked/planner"
 	"github.com/spf13/cobra"
 )
 
 var PlanInspectCmd = &cobra.Command{
-	Use:   "inspect <plan-name>",
+	Use:   "inspect [--format text|json|markdown] [--only-incomplete] [<plan-name>]",
 	Short: "Display detailed plan information",
 	Long: `Display detailed information about a plan including all its steps, their status,
-and acceptance criteria. This provides a comprehensive view of the plan's current state.`,
-	Args: cobra.ExactArgs(1),
+and acceptance criteria. This provides a comprehensive view of the plan's current state.
+
+Use --format to choose the output:
+  text      the detailed view described above (default)
+  json      the plan's ID, tags and steps with their criteria and references as JSON
+  markdown  a checklist with one checkbox per step, checked for done steps
+
+Setting output_format = "json" in the config file makes JSON the default;
+pass --format text to override it.
+
+With --color (auto by default), step statuses are colored in the text format:
+DONE in green, TODO in yellow and BLOCKED in red.
+
+Use --only-incomplete to only show the steps that are not DONE, numbered from 1,
+e.g. to see what is left of a large plan. It requires the text format.
+
+Without a plan name, the default plan set with 'plan use' is inspected.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: RunPlanInspect,
 }
 
+var (
+	inspectFormatFlag         string
+	inspectOnlyIncompleteFlag bool
+)
+
+func init() {
+	PlanInspectCmd.Flags().StringVar(&inspectFormatFlag, "format", "text", "Output format: text, json, or markdown")
+	PlanInspectCmd.Flags().BoolVar(&inspectOnlyIncompleteFlag, "only-incomplete", false, "Only show steps that are not DONE")
+}
+
 func RunPlanInspect(cmd *cobra.Command, args []string) error {
-	planName := args[0]
+	planName, err := GlobalSettings.PlanName(args)
+	if err != nil {
+		return err
+	}
 
-	// Get the database file path from settings
-	dbPath := GlobalSettings.GetDatabaseFile()
+	if !cmd.Flags().Changed("format") && GlobalSettings.OutputFormat == "json" && !inspectOnlyIncompleteFlag {
+		inspectFormatFlag = "json"
+	}
+	if inspectFormatFlag != "text" && inspectFormatFlag != "json" && inspectFormatFlag != "markdown" {
+		return fmt.Errorf("invalid format '%s' (must be 'text', 'json' or 'markdown')", inspectFormatFlag)
+	}
+	if inspectOnlyIncompleteFlag && inspectFormatFlag != "text" {
+		return fmt.Errorf("--only-incomplete can only be used with --format text")
+	}
 
 	// Initialize the planner
-	p, err := planner.New(dbPath)
+	p, err := GlobalSettings.OpenPlanner()
 	if err != nil {
 		return fmt.Errorf("failed to initialize planner: %w", err)
 	}
@@ -35,7 +73,38 @@ func RunPlanInspect(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get plan: %w", err)
 	}
 
-	// Display the plan details using the Inspect method
-	fmt.Print(plan.Inspect())
+	switch inspectFormatFlag {
+	case "json":
+		output, err := plan.ToJSON()
+		if err != nil {
+			return fmt.Errorf("failed to encode plan: %w", err)
+		}
+		fmt.Println(string(output))
+	case "markdown":
+		fmt.Print(plan.ExportMarkdown())
+	default:
+		var opts planner.InspectOptions
+		if inspectOnlyIncompleteFlag {
+			if len(plan.Steps) > 0 && plan.IsCompleted() {
+				GlobalSettings.Successf("All steps of plan '%s' are done\n", planName)
+				return nil
+			}
+			opts.Filter = planner.IsIncomplete
+		}
+		if GlobalSettings.UseColor() {
+			opts.FormatStatus = func(status string) string { return colorStatus(status, true) }
+			opts.FormatStepID = func(id string) string { return colorize(id, ansiBold, true) }
+		}
+
+		// Stream the plan details to stdout, buffered so that a large plan
+		// is not written with a system call per line
+		out := bufio.NewWriter(os.Stdout)
+		if err := plan.WriteInspectWith(out, opts); err != nil {
+			return fmt.Errorf("failed to write plan: %w", err)
+		}
+		if err := out.Flush(); err != nil {
+			return fmt.Errorf("failed to write plan: %w", err)
+		}
+	}
 	return nil
 }
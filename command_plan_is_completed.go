@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/dhamidi/tasked/planner"
 	"github.com/spf13/cobra"
 )
 
@@ -21,28 +20,21 @@ Exit code 0 indicates completed, exit code 1 indicates incomplete.`,
 func RunPlanIsCompleted(cmd *cobra.Command, args []string) error {
 	planName := args[0]
 
-	// Get the database file path from settings
-	dbPath := GlobalSettings.GetDatabaseFile()
-
 	// Initialize the planner
-	p, err := planner.New(dbPath)
+	p, err := GlobalSettings.OpenPlanner()
 	if err != nil {
 		return fmt.Errorf("failed to initialize planner: %w", err)
 	}
 	defer p.Close()
 
-	// Get the plan from the database
-	plan, err := p.Get(planName)
+	// Count the steps without loading the plan
+	total, done, err := p.CountSteps(planName)
 	if err != nil {
-		return fmt.Errorf("failed to get plan: %w", err)
+		return fmt.Errorf("failed to count steps: %w", err)
 	}
 
-	// Check if the plan is completed by using NextStep()
-	// If NextStep() returns nil, the plan is completed
-	nextStep := plan.NextStep()
-	isCompleted := nextStep == nil
-
-	if isCompleted {
+	// Check if the plan is completed (blocked steps count as not done)
+	if done == total {
 		fmt.Println("true")
 		os.Exit(0)
 	} else {
@@ -2,6 +2,10 @@ package tasked
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dhamidi/tasked/planner"
 	"github.com/spf13/cobra"
@@ -11,25 +15,146 @@ var PlanListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all plans with their status and task counts",
 	Long: `List all existing plans showing their names, completion status (DONE/TODO),
-and task count information. This provides a quick overview of all plans in the database.`,
+and task count information. This provides a quick overview of all plans in the database.
+
+Use --tag to only show plans carrying the given tag, and --status to only show
+plans that are done or still have steps to do.
+
+Use --since to only show plans updated after the given time, either a duration
+back from now such as 24h or 7d, or a date such as 2024-05-01 or an RFC 3339
+timestamp such as 2024-05-01T12:00:00Z. Dates without a time refer to midnight
+in the local time zone.
+
+Archived plans are hidden unless --all is given, in which case they are
+marked with [ARCHIVED].
+
+Plans are sorted by name unless --sort is given:
+  name      alphabetically by plan name (default)
+  progress  by share of completed tasks, least progressed first
+  created   by creation time, oldest first
+  updated   by last update, least recently updated first
+
+The default sort order can be changed with sort_order in the config file.
+
+Use --percent to show the share of completed tasks as a percentage instead of
+the number of completed tasks.
+
+Use --limit and --offset to page through a long list: --offset skips the given
+number of plans and --limit shows at most that many of the remaining ones.
+Paging applies after filtering and sorting.
+
+Use --databases with a comma-separated list of database files to list the plans
+of several databases together, e.g. one per project. Each line then starts with
+the database the plan comes from. All other options apply to the combined list.
+The databases are opened read-only: they must exist and are neither created nor
+migrated.
+
+With --color (auto by default), plan names are bold and statuses are colored:
+DONE in green and TODO in yellow.`,
 	RunE: RunPlanList,
 }
 
+var listTagFlag string
+var listSortFlag string
+var listAllFlag bool
+var listStatusFlag string
+var listSinceFlag string
+var listPercentFlag bool
+var listLimitFlag int
+var listOffsetFlag int
+var listDatabasesFlag []string
+
+func init() {
+	PlanListCmd.Flags().StringVar(&listTagFlag, "tag", "", "Only list plans with this tag")
+	PlanListCmd.Flags().StringVar(&listSortFlag, "sort", "name", "Sort order: name, progress, created, or updated")
+	PlanListCmd.Flags().BoolVar(&listAllFlag, "all", false, "Include archived plans")
+	PlanListCmd.Flags().StringVar(&listStatusFlag, "status", "", "Only list plans with this status: done or todo")
+	PlanListCmd.Flags().StringVar(&listSinceFlag, "since", "", "Only list plans updated after this time, e.g. 7d, 24h or 2024-05-01")
+	PlanListCmd.Flags().BoolVar(&listPercentFlag, "percent", false, "Show completion as a percentage")
+	PlanListCmd.Flags().IntVar(&listLimitFlag, "limit", 0, "Show at most this many plans (0 for no limit)")
+	PlanListCmd.Flags().IntVar(&listOffsetFlag, "offset", 0, "Skip this many plans before listing")
+	PlanListCmd.Flags().StringSliceVar(&listDatabasesFlag, "databases", nil, "List the plans of several databases (comma-separated paths) instead of the configured one")
+}
+
 func RunPlanList(cmd *cobra.Command, args []string) error {
-	// Get the database file path from settings
-	dbPath := GlobalSettings.GetDatabaseFile()
+	if !cmd.Flags().Changed("sort") && GlobalSettings.SortOrder != "" {
+		listSortFlag = GlobalSettings.SortOrder
+	}
+	if listSortFlag != "name" && listSortFlag != "progress" && listSortFlag != "created" && listSortFlag != "updated" {
+		return fmt.Errorf("invalid sort order '%s' (must be 'name', 'progress', 'created' or 'updated')", listSortFlag)
+	}
+	if listStatusFlag != "" && listStatusFlag != "done" && listStatusFlag != "todo" {
+		return fmt.Errorf("invalid status '%s' (must be 'done' or 'todo')", listStatusFlag)
+	}
+	if listLimitFlag < 0 {
+		return fmt.Errorf("invalid limit %d (must not be negative)", listLimitFlag)
+	}
+	if listOffsetFlag < 0 {
+		return fmt.Errorf("invalid offset %d (must not be negative)", listOffsetFlag)
+	}
+	var since time.Time
+	if listSinceFlag != "" {
+		var err error
+		since, err = parseSince(listSinceFlag, time.Now())
+		if err != nil {
+			return err
+		}
+	}
 
-	// Initialize the planner
-	p, err := planner.New(dbPath)
-	if err != nil {
-		return fmt.Errorf("failed to initialize planner: %w", err)
+	var plans []listedPlan
+	paged := false
+	if len(listDatabasesFlag) == 0 {
+		// Initialize the planner
+		p, err := GlobalSettings.OpenPlanner()
+		if err != nil {
+			return fmt.Errorf("failed to initialize planner: %w", err)
+		}
+		defer p.Close()
+
+		// Plans are only paged in the database when no filter or sort order
+		// is applied afterwards.
+		pageInDatabase := listLimitFlag > 0 && listStatusFlag == "" && since.IsZero() && listSortFlag == "name"
+		infos, dbPaged, err := fetchPlanInfos(p, pageInDatabase)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			plans = append(plans, listedPlan{PlanInfo: info})
+		}
+		paged = dbPaged
+	} else {
+		if GlobalSettings.DatabaseFile != "" {
+			return fmt.Errorf("--databases cannot be combined with --database-file")
+		}
+		for _, path := range listDatabasesFlag {
+			infos, err := fetchPlanInfosFrom(path)
+			if err != nil {
+				return err
+			}
+			plans = append(plans, infos...)
+		}
 	}
-	defer p.Close()
 
-	// Get all plans using the List method
-	plans, err := p.List()
-	if err != nil {
-		return fmt.Errorf("failed to list plans: %w", err)
+	// Keep only plans with the requested status that were updated after --since
+	if listStatusFlag != "" || !since.IsZero() {
+		var kept []listedPlan
+		for _, plan := range plans {
+			if listStatusFlag != "" && !strings.EqualFold(plan.Status, listStatusFlag) {
+				continue
+			}
+			if !since.IsZero() && !plan.UpdatedAt.After(since) {
+				continue
+			}
+			kept = append(kept, plan)
+		}
+		plans = kept
+	}
+
+	sortPlanInfos(plans, listSortFlag)
+
+	// Apply --offset and --limit unless the database already did
+	if !paged {
+		plans = pagePlanInfos(plans, listLimitFlag, listOffsetFlag)
 	}
 
 	// Handle empty list gracefully
@@ -39,15 +164,141 @@ func RunPlanList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Format and display the output
+	color := GlobalSettings.UseColor()
 	for _, plan := range plans {
-		status := plan.Status
+		if plan.Database != "" {
+			fmt.Printf("%s\t", plan.Database)
+		}
+		name := colorize(plan.Name, ansiBold, color)
+		status := colorStatus(plan.Status, color)
+		marker := ""
+		if plan.Archived {
+			marker = " [ARCHIVED]"
+		}
 		if plan.TotalTasks == 0 {
-			fmt.Printf("%s [%s] (no tasks)\n", plan.Name, status)
+			fmt.Printf("%s %s%s (no tasks)\n", name, status, marker)
+		} else if listPercentFlag {
+			fmt.Printf("%s %s%s (%.0f%% completed)\n", name, status, marker, plan.CompletionPercent)
 		} else {
-			fmt.Printf("%s [%s] (%d/%d tasks completed)\n",
-				plan.Name, status, plan.CompletedTasks, plan.TotalTasks)
+			fmt.Printf("%s %s%s (%d/%d tasks completed)\n",
+				name, status, marker, plan.CompletedTasks, plan.TotalTasks)
 		}
 	}
 
 	return nil
 }
+
+// fetchPlanInfos lists the plans of one database, restricted by --tag and --all.
+// With pageInDatabase set and a --limit given, --limit and --offset are applied
+// by the database query, which is reported by the second result.
+func fetchPlanInfos(p *planner.Planner, pageInDatabase bool) ([]planner.PlanInfo, bool, error) {
+	var plans []planner.PlanInfo
+	var err error
+	paged := false
+	switch {
+	case listTagFlag != "" && listAllFlag:
+		plans, err = p.ListAllByTag(listTagFlag)
+	case listTagFlag != "":
+		plans, err = p.ListByTag(listTagFlag)
+	case listAllFlag:
+		plans, err = p.ListAll()
+	case pageInDatabase && listLimitFlag > 0:
+		plans, err = p.ListPaged(listLimitFlag, listOffsetFlag)
+		paged = true
+	default:
+		plans, err = p.List()
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list plans: %w", err)
+	}
+	return plans, paged, nil
+}
+
+// listedPlan is a plan shown by plan list together with the database file it
+// comes from, which is only set when listing several databases.
+type listedPlan struct {
+	planner.PlanInfo
+	Database string
+}
+
+// fetchPlanInfosFrom lists the plans of the database at path like fetchPlanInfos,
+// recording path as their database. Since listing only reads, the database is
+// opened read-only: it is neither created nor migrated.
+func fetchPlanInfosFrom(path string) ([]listedPlan, error) {
+	p, err := planner.NewReadOnly(path, GlobalSettings.PlannerOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	infos, _, err := fetchPlanInfos(p, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	plans := make([]listedPlan, len(infos))
+	for i, info := range infos {
+		plans[i] = listedPlan{PlanInfo: info, Database: path}
+	}
+	return plans, nil
+}
+
+// sortPlanInfos sorts plans in place by the given key ("name", "progress", "created" or "updated").
+// Plans that compare equal are ordered by name so the output is stable.
+func sortPlanInfos(plans []listedPlan, key string) {
+	sort.SliceStable(plans, func(i, j int) bool {
+		a, b := plans[i], plans[j]
+		switch key {
+		case "progress":
+			// Compare completed/total fractions without floating point
+			left := a.CompletedTasks * max(b.TotalTasks, 1)
+			right := b.CompletedTasks * max(a.TotalTasks, 1)
+			if left != right {
+				return left < right
+			}
+		case "created":
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+		case "updated":
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.Before(b.UpdatedAt)
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+// pagePlanInfos skips the first offset plans and returns at most limit of
+// the remaining ones. A limit of 0 returns all remaining plans.
+func pagePlanInfos(plans []listedPlan, limit, offset int) []listedPlan {
+	if offset >= len(plans) {
+		return nil
+	}
+	plans = plans[offset:]
+	if limit > 0 && limit < len(plans) {
+		plans = plans[:limit]
+	}
+	return plans
+}
+
+// parseSince converts the value of --since into a point in time.
+// It accepts a number of days such as "7d", any duration understood by
+// time.ParseDuration such as "24h" or "90m" (both counted back from now),
+// a date such as "2024-05-01" in the local time zone, or an RFC 3339 timestamp.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+		return timestamp, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value '%s' (use a duration like 7d or 24h, a date like 2024-05-01, or an RFC 3339 timestamp)", value)
+}
@@ -0,0 +1,46 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanListAssignedCmd = &cobra.Command{
+	Use:   "list-assigned <assignee>",
+	Short: "List the steps assigned to someone",
+	Long: `List the steps assigned to the given person across all plans that are not
+archived, as set with 'plan add-step --assignee'. Each line shows the plan, the
+step ID, its status and its description, ordered by plan name and then by the
+order of the steps within the plan.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanListAssigned,
+}
+
+func RunPlanListAssigned(cmd *cobra.Command, args []string) error {
+	assignee := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the assigned steps
+	assigned, err := p.ListAssigned(assignee)
+	if err != nil {
+		return fmt.Errorf("failed to list assigned steps: %w", err)
+	}
+
+	if len(assigned) == 0 {
+		fmt.Printf("No steps assigned to '%s'.\n", assignee)
+		return nil
+	}
+
+	color := GlobalSettings.UseColor()
+	for _, a := range assigned {
+		fmt.Printf("%s/%s %s %s\n", a.Plan, a.Step.ID(), colorStatus(a.Step.Status(), color), a.Step.Description())
+	}
+	return nil
+}
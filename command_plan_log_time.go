@@ -0,0 +1,54 @@
+package tasked
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanLogTimeCmd = &cobra.Command{
+	Use:   "log-time <plan-name> <step-id> <minutes>",
+	Short: "Record time spent on a step",
+	Long: `Record time spent working on a step. The given number of minutes is added to
+the time already logged for the step, so this can be called repeatedly as work
+progresses. Estimates and actual time are shown by 'plan inspect'.`,
+	Args: cobra.ExactArgs(3),
+	RunE: RunPlanLogTime,
+}
+
+func RunPlanLogTime(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+
+	minutes, err := strconv.Atoi(args[2])
+	if err != nil || minutes <= 0 {
+		return fmt.Errorf("minutes must be a positive whole number, got '%s'", args[2])
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Add the time to the step
+	if err := plan.LogTime(stepID, minutes); err != nil {
+		return fmt.Errorf("failed to log time: %w", err)
+	}
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Logged %d minutes on step '%s' in plan '%s'\n", minutes, stepID, planName)
+	return nil
+}
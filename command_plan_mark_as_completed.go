@@ -3,51 +3,60 @@ package tasked
 import (
 	"fmt"
 
-	"github.com/dhamidi/tasked/planner"
 	"github.com/spf13/cobra"
 )
 
 var PlanMarkAsCompletedCmd = &cobra.Command{
-	Use:   "mark-as-completed <plan-name> <step-id>",
+	Use:   "mark-as-completed [--strict] <plan-name> <step-id>",
 	Short: "Mark a step as completed",
 	Long: `Mark a specific step in a plan as completed (DONE status).
-This will update the step's status to DONE and persist the change to the database.`,
+This will update the step's status to DONE and persist the change to the database.
+
+With --strict, the step is only completed if all its acceptance criteria are
+checked as met with 'plan check-criterion'; otherwise the unmet criteria are
+reported and the step is left unchanged.`,
 	Args: cobra.ExactArgs(2),
 	RunE: RunPlanMarkAsCompleted,
 }
 
+var markAsCompletedStrictFlag bool
+
+func init() {
+	PlanMarkAsCompletedCmd.Flags().BoolVar(&markAsCompletedStrictFlag, "strict", false, "Only complete the step if all its acceptance criteria are met")
+}
+
 func RunPlanMarkAsCompleted(cmd *cobra.Command, args []string) error {
 	planName := args[0]
 	stepID := args[1]
 
-	// Get the database file path from settings
-	dbPath := GlobalSettings.GetDatabaseFile()
-
 	// Initialize the planner
-	p, err := planner.New(dbPath)
+	p, err := GlobalSettings.OpenPlanner()
 	if err != nil {
 		return fmt.Errorf("failed to initialize planner: %w", err)
 	}
 	defer p.Close()
 
-	// Get the plan from the database
-	plan, err := p.Get(planName)
-	if err != nil {
-		return fmt.Errorf("failed to get plan: %w", err)
-	}
-
-	// Mark the step as completed
-	err = plan.MarkAsCompleted(stepID)
-	if err != nil {
-		return fmt.Errorf("failed to mark step as completed: %w", err)
-	}
-
-	// Save the changes to the database
-	err = p.Save(plan)
-	if err != nil {
-		return fmt.Errorf("failed to save plan: %w", err)
+	if markAsCompletedStrictFlag {
+		// Get the plan from the database to check the step's criteria
+		plan, err := p.Get(planName)
+		if err != nil {
+			return fmt.Errorf("failed to get plan: %w", err)
+		}
+		if err := plan.MarkAsCompletedStrict(stepID); err != nil {
+			return fmt.Errorf("failed to mark step as completed: %w", err)
+		}
+
+		// Save the plan
+		if err := p.Save(plan); err != nil {
+			return fmt.Errorf("failed to save plan: %w", err)
+		}
+	} else {
+		// Update the step's status without rewriting the rest of the plan
+		if err := p.SetStepStatus(planName, stepID, "DONE"); err != nil {
+			return fmt.Errorf("failed to mark step as completed: %w", err)
+		}
 	}
 
-	fmt.Printf("Step '%s' in plan '%s' marked as completed\n", stepID, planName)
+	GlobalSettings.Successf("Step '%s' in plan '%s' marked as completed\n", stepID, planName)
 	return nil
 }
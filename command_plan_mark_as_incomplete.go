@@ -3,7 +3,6 @@ package tasked
 import (
 	"fmt"
 
-	"github.com/dhamidi/tasked/planner"
 	"github.com/spf13/cobra"
 )
 
@@ -21,32 +20,18 @@ func RunPlanMarkAsIncomplete(cmd *cobra.Command, args []string) error {
 	planName := args[0]
 	stepID := args[1]
 
-	// Get the database file path from settings
-	dbPath := GlobalSettings.GetDatabaseFile()
-
 	// Initialize the planner
-	p, err := planner.New(dbPath)
+	p, err := GlobalSettings.OpenPlanner()
 	if err != nil {
 		return fmt.Errorf("failed to initialize planner: %w", err)
 	}
 	defer p.Close()
 
-	// Get the plan
-	plan, err := p.Get(planName)
-	if err != nil {
-		return fmt.Errorf("failed to get plan: %w", err)
-	}
-
-	// Mark the step as incomplete
-	if err := plan.MarkAsIncomplete(stepID); err != nil {
+	// Update the step's status without rewriting the rest of the plan
+	if err := p.SetStepStatus(planName, stepID, "TODO"); err != nil {
 		return fmt.Errorf("failed to mark step as incomplete: %w", err)
 	}
 
-	// Save the plan
-	if err := p.Save(plan); err != nil {
-		return fmt.Errorf("failed to save plan: %w", err)
-	}
-
-	fmt.Printf("Marked step '%s' in plan '%s' as incomplete\n", stepID, planName)
+	GlobalSettings.Successf("Marked step '%s' in plan '%s' as incomplete\n", stepID, planName)
 	return nil
 }
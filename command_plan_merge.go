@@ -0,0 +1,60 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+)
+
+var PlanMergeCmd = &cobra.Command{
+	Use:   "merge <dest-plan> <src-plan> [--remove-source] [--rename-conflicts]",
+	Short: "Append all steps of one plan to another",
+	Long: `Append all steps of the source plan to the end of the destination plan, in
+order. Steps keep their status, acceptance criteria and references.
+
+If a step ID of the source plan already exists in the destination plan the merge
+fails, unless --rename-conflicts is given, in which case the merged step gets a
+numeric suffix (e.g. "setup-2"). The source plan is kept unless --remove-source
+is given; 'tasked undo' then restores both plans as they were before the merge.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanMerge,
+}
+
+var (
+	mergeRemoveSourceFlag    bool
+	mergeRenameConflictsFlag bool
+)
+
+func init() {
+	PlanMergeCmd.Flags().BoolVar(&mergeRemoveSourceFlag, "remove-source", false, "Delete the source plan after merging")
+	PlanMergeCmd.Flags().BoolVar(&mergeRenameConflictsFlag, "rename-conflicts", false, "Add a numeric suffix to step IDs that already exist in the destination plan")
+}
+
+func RunPlanMerge(cmd *cobra.Command, args []string) error {
+	destName := args[0]
+	srcName := args[1]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Merge the plans
+	opts := planner.MergeOptions{
+		RenameConflicts: mergeRenameConflictsFlag,
+		RemoveSource:    mergeRemoveSourceFlag,
+	}
+	if err := p.MergeWithOptions(destName, srcName, opts); err != nil {
+		return fmt.Errorf("failed to merge plan: %w", err)
+	}
+
+	if mergeRemoveSourceFlag {
+		GlobalSettings.Successf("Merged plan '%s' into '%s' and removed '%s'\n", srcName, destName, srcName)
+	} else {
+		GlobalSettings.Successf("Merged plan '%s' into '%s'\n", srcName, destName)
+	}
+	return nil
+}
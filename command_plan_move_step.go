@@ -0,0 +1,72 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+)
+
+var PlanMoveStepCmd = &cobra.Command{
+	Use:   "move-step (--before step-id | --after step-id | --to-top | --to-bottom) <plan-name> <step-id>",
+	Short: "Move a single step within a plan",
+	Long: `Move one step to a new position without listing every step as 'reorder-steps'
+requires. Exactly one of the following must be given:
+  --before <step-id>  place the step directly before another step
+  --after <step-id>   place the step directly after another step
+  --to-top            make the step the first step of the plan
+  --to-bottom         make the step the last step of the plan
+
+All other steps keep their relative order.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanMoveStep,
+}
+
+var moveStepBefore string
+var moveStepAfter string
+var moveStepToTop bool
+var moveStepToBottom bool
+
+func init() {
+	PlanMoveStepCmd.Flags().StringVar(&moveStepBefore, "before", "", "ID of the step to move the step in front of")
+	PlanMoveStepCmd.Flags().StringVar(&moveStepAfter, "after", "", "ID of the step to move the step behind")
+	PlanMoveStepCmd.Flags().BoolVar(&moveStepToTop, "to-top", false, "Move the step to the top of the plan")
+	PlanMoveStepCmd.Flags().BoolVar(&moveStepToBottom, "to-bottom", false, "Move the step to the bottom of the plan")
+}
+
+func RunPlanMoveStep(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Move the step
+	position := planner.Position{
+		Before:   moveStepBefore,
+		After:    moveStepAfter,
+		ToTop:    moveStepToTop,
+		ToBottom: moveStepToBottom,
+	}
+	if err := plan.MoveStep(stepID, position); err != nil {
+		return fmt.Errorf("failed to move step: %w", err)
+	}
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Moved step '%s' in plan '%s'\n", stepID, planName)
+	return nil
+}
@@ -0,0 +1,75 @@
+package tasked
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanMoveToDBCmd = &cobra.Command{
+	Use:     "move-to-db --to <database-file> <plan-name>",
+	Aliases: []string{"move"},
+	Short:   "Move a plan into another database",
+	Long: `Move a plan from the current database into the database file given with --to,
+e.g. from a scratch database into a project's database. The plan keeps its
+name, steps, tags and status history, but not its on-complete command, which has
+to be set again in the target with 'plan on-complete'. The target database is created if it does
+not exist, unless --require-existing-db is given.
+
+The move is all or nothing: if the target already has a plan with the same
+name, or the plan cannot be written there, it stays in the current database.
+Removing the plan from the current database can be reverted with 'tasked undo',
+which does not remove the copy in the target database.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanMoveToDB,
+}
+
+var moveToDBToFlag string
+
+func init() {
+	PlanMoveToDBCmd.Flags().StringVar(&moveToDBToFlag, "to", "", "Database file to move the plan into")
+	PlanMoveToDBCmd.MarkFlagRequired("to")
+}
+
+func RunPlanMoveToDB(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	sourcePath := GlobalSettings.GetDatabaseFile()
+	targetPath := expandHome(moveToDBToFlag)
+	if sameFile(sourcePath, targetPath) {
+		return fmt.Errorf("plan '%s' is already stored in %s", planName, targetPath)
+	}
+
+	// Initialize the planners
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	target, err := GlobalSettings.OpenPlannerAt(targetPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner for %s: %w", targetPath, err)
+	}
+	defer target.Close()
+
+	// Move the plan
+	if err := p.MovePlan(planName, target); err != nil {
+		return fmt.Errorf("failed to move plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Moved plan '%s' to %s\n", planName, targetPath)
+	return nil
+}
+
+// sameFile reports whether the paths a and b name the same file, comparing
+// their absolute forms so that relative paths are recognized.
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return absA == absB
+}
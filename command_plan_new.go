@@ -3,43 +3,67 @@ package tasked
 import (
 	"fmt"
 
-	"github.com/dhamidi/tasked/planner"
 	"github.com/spf13/cobra"
 )
 
 var PlanNewCmd = &cobra.Command{
-	Use:   "new <plan-name>",
+	Use:   "new [--if-not-exists] [--on-complete command] <plan-name>",
 	Short: "Create a new empty plan",
 	Long: `Create a new empty plan with the specified name. The plan will be created
-in the database and can then be populated with steps using other plan commands.`,
+in the database and can then be populated with steps using other plan commands.
+
+Creating a plan that already exists is an error, unless --if-not-exists is
+given: then the existing plan is left unchanged and the command succeeds, which
+makes it safe to run repeatedly in scripts.
+
+With --on-complete, the given shell command runs whenever a change marks the
+last remaining step of the plan as done, e.g. to send a notification; see
+'plan on-complete'.`,
 	Args: cobra.ExactArgs(1),
 	RunE: RunPlanNew,
 }
 
+var newIfNotExistsFlag bool
+var newOnCompleteFlag string
+
+func init() {
+	PlanNewCmd.Flags().BoolVar(&newIfNotExistsFlag, "if-not-exists", false, "Succeed without changes if the plan already exists")
+	PlanNewCmd.Flags().StringVar(&newOnCompleteFlag, "on-complete", "", "Shell command to run when the plan becomes completed")
+}
+
 func RunPlanNew(cmd *cobra.Command, args []string) error {
 	planName := args[0]
 
-	// Get the database file path from settings
-	dbPath := GlobalSettings.GetDatabaseFile()
-
 	// Initialize the planner
-	p, err := planner.New(dbPath)
+	p, err := GlobalSettings.OpenPlanner()
 	if err != nil {
 		return fmt.Errorf("failed to initialize planner: %w", err)
 	}
 	defer p.Close()
 
+	if newIfNotExistsFlag {
+		exists, err := p.Exists(planName)
+		if err != nil {
+			return fmt.Errorf("failed to check plan: %w", err)
+		}
+		if exists {
+			GlobalSettings.Successf("Plan '%s' already exists\n", planName)
+			return nil
+		}
+	}
+
 	// Create the new plan
 	plan, err := p.Create(planName)
 	if err != nil {
 		return fmt.Errorf("failed to create plan: %w", err)
 	}
+	plan.SetOnComplete(newOnCompleteFlag)
 
 	// Save the plan to the database
 	if err := p.Save(plan); err != nil {
 		return fmt.Errorf("failed to save plan: %w", err)
 	}
 
-	fmt.Printf("Created plan '%s'\n", planName)
+	GlobalSettings.Successf("Created plan '%s'\n", planName)
 	return nil
 }
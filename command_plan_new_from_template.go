@@ -0,0 +1,37 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanNewFromTemplateCmd = &cobra.Command{
+	Use:   "new-from-template <template-name> <plan-name>",
+	Short: "Create a new plan from a template",
+	Long: `Create a new plan with all steps of a template saved with 'plan save-template'.
+Every step in the new plan starts out as TODO.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanNewFromTemplate,
+}
+
+func RunPlanNewFromTemplate(cmd *cobra.Command, args []string) error {
+	templateName := args[0]
+	planName := args[1]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Create the plan from the template
+	plan, err := p.InstantiateTemplate(templateName, planName)
+	if err != nil {
+		return fmt.Errorf("failed to create plan from template: %w", err)
+	}
+
+	GlobalSettings.Successf("Created plan '%s' from template '%s' (%d steps)\n", planName, templateName, len(plan.Steps))
+	return nil
+}
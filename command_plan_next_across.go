@@ -0,0 +1,81 @@
+package tasked
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+)
+
+var PlanNextAcrossCmd = &cobra.Command{
+	Use:     "next-across [--json] <plan-name> <plan-name>...",
+	Aliases: []string{"first-incomplete-of"},
+	Short:   "Show the next step across several plans",
+	Long: `Display the next incomplete step of the first plan, in the order given, that
+has a step to work on, together with the name of that plan. Plans that are
+completed or whose remaining steps are all blocked are skipped.
+
+Use --json to print an object with the fields plan and step, where step has the
+format of 'plan next-step --json'. If no plan has a step to work on, null is
+printed. Setting output_format = "json" in the config file makes JSON the
+default; pass --json=false to override it.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: RunPlanNextAcross,
+}
+
+var nextAcrossJSONFlag bool
+
+func init() {
+	PlanNextAcrossCmd.Flags().BoolVar(&nextAcrossJSONFlag, "json", false, "Print the plan name and step as JSON")
+}
+
+func RunPlanNextAcross(cmd *cobra.Command, args []string) error {
+	if !cmd.Flags().Changed("json") {
+		nextAcrossJSONFlag = GlobalSettings.OutputFormat == "json"
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Find the first plan with a step to work on
+	var planName string
+	var nextStep *planner.Step
+	for _, name := range args {
+		plan, err := p.Get(name)
+		if err != nil {
+			return fmt.Errorf("failed to get plan: %w", err)
+		}
+		if step := plan.NextStep(); step != nil {
+			planName, nextStep = name, step
+			break
+		}
+	}
+
+	if nextAcrossJSONFlag {
+		var result any // Encoded as null if no step was found
+		if nextStep != nil {
+			result = map[string]any{"plan": planName, "step": nextStep}
+		}
+		output, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to encode step: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+	if nextStep == nil {
+		fmt.Printf("No actionable steps in plans %s\n", strings.Join(args, ", "))
+		return nil
+	}
+
+	fmt.Printf("Plan: %s\n", planName)
+	fmt.Printf("Next step: %s\n", nextStep.ID())
+	printStepDetails(nextStep)
+	return nil
+}
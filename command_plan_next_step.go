@@ -1,6 +1,7 @@
 package tasked
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/dhamidi/tasked/planner"
@@ -8,22 +9,40 @@ import (
 )
 
 var PlanNextStepCmd = &cobra.Command{
-	Use:   "next-step <plan-name>",
+	Use:   "next-step [--json] [<plan-name>]",
 	Short: "Show the next incomplete step in a plan",
-	Long: `Display the next incomplete step in a plan. Shows the step ID, description,
-and acceptance criteria. If all steps are completed, indicates the plan is done.`,
-	Args: cobra.ExactArgs(1),
+	Long: `Display the next incomplete step in a plan. Shows the step ID, how many steps
+are left to do, description, and acceptance criteria. If all steps are completed,
+indicates the plan is done.
+
+Use --json to print the step as a JSON object with the fields id, description,
+status, acceptance_criteria and references, the same format returned by the MCP
+get_next_step action. If there is no step to work on, null is printed.
+Setting output_format = "json" in the config file makes JSON the default;
+pass --json=false to override it.
+
+Without a plan name, the default plan set with 'plan use' is used.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: RunPlanNextStep,
 }
 
-func RunPlanNextStep(cmd *cobra.Command, args []string) error {
-	planName := args[0]
+var nextStepJSONFlag bool
 
-	// Get the database file path from settings
-	dbPath := GlobalSettings.GetDatabaseFile()
+func init() {
+	PlanNextStepCmd.Flags().BoolVar(&nextStepJSONFlag, "json", false, "Print the step as JSON")
+}
+
+func RunPlanNextStep(cmd *cobra.Command, args []string) error {
+	planName, err := GlobalSettings.PlanName(args)
+	if err != nil {
+		return err
+	}
+	if !cmd.Flags().Changed("json") {
+		nextStepJSONFlag = GlobalSettings.OutputFormat == "json"
+	}
 
 	// Initialize the planner
-	p, err := planner.New(dbPath)
+	p, err := GlobalSettings.OpenPlanner()
 	if err != nil {
 		return fmt.Errorf("failed to initialize planner: %w", err)
 	}
@@ -37,29 +56,60 @@ func RunPlanNextStep(cmd *cobra.Command, args []string) error {
 
 	// Get the next step
 	nextStep := plan.NextStep()
+	if nextStepJSONFlag {
+		// A nil step is encoded as null
+		output, err := json.Marshal(nextStep)
+		if err != nil {
+			return fmt.Errorf("failed to encode step: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
 	if nextStep == nil {
-		fmt.Printf("Plan '%s' is completed - all steps are done!\n", planName)
+		if plan.IsCompleted() {
+			fmt.Printf("Plan '%s' is completed - all steps are done!\n", planName)
+		} else {
+			fmt.Printf("Plan '%s' has no actionable steps - all remaining steps are blocked\n", planName)
+		}
 		return nil
 	}
 
 	// Display the next step details
-	fmt.Printf("Next step: %s\n", nextStep.ID())
-	fmt.Printf("Status: %s\n", nextStep.Status())
-	fmt.Printf("\n%s\n", nextStep.Description())
+	fmt.Printf("Next step: %s (%d of %d steps remaining)\n", nextStep.ID(), plan.RemainingCount(), len(plan.Steps))
+	printStepDetails(nextStep)
+
+	return nil
+}
 
-	if len(nextStep.AcceptanceCriteria()) > 0 {
+// printStepDetails prints a step's status, assignee, completion time, description, acceptance criteria and references.
+func printStepDetails(step *planner.Step) {
+	fmt.Printf("Status: %s\n", step.Status())
+	if step.Status() == "BLOCKED" {
+		fmt.Printf("Blocked: %s\n", step.BlockedReason())
+	}
+	if step.Assignee() != "" {
+		fmt.Printf("Assignee: %s\n", step.Assignee())
+	}
+	if completedAt, ok := step.CompletedAt(); ok {
+		fmt.Printf("Completed: %s\n", completedAt.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("\n%s\n", step.Description())
+
+	if len(step.AcceptanceCriteria()) > 0 {
 		fmt.Printf("\nAcceptance Criteria:\n")
-		for i, criterion := range nextStep.AcceptanceCriteria() {
-			fmt.Printf("%d. %s\n", i+1, criterion)
+		for i, criterion := range step.AcceptanceCriteria() {
+			checkbox := "[ ]"
+			if step.CriterionMet(i + 1) {
+				checkbox = "[x]"
+			}
+			fmt.Printf("%d. %s %s\n", i+1, checkbox, criterion)
 		}
 	}
 
-	if len(nextStep.References()) > 0 {
+	if len(step.References()) > 0 {
 		fmt.Printf("\nReferences:\n")
-		for i, reference := range nextStep.References() {
+		for i, reference := range step.References() {
 			fmt.Printf("%d. %s\n", i+1, reference)
 		}
 	}
-
-	return nil
 }
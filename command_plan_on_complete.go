@@ -0,0 +1,77 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+)
+
+var PlanOnCompleteCmd = &cobra.Command{
+	Use:   "on-complete [--clear] <plan-name> [<command>]",
+	Short: "Set the command run when a plan is completed",
+	Long: `Set a shell command that runs whenever a change completes the plan, that is when
+a step is marked as done and no step of the plan is left that is not done, e.g.
+to send a notification or commit the work. The command runs once per such
+change, in the background with sh -c (cmd /C on Windows); saving a plan that
+already is completed does not run it again. The name of the plan is passed in
+the ` + planner.OnCompleteEnvVar + ` environment variable, and the command's output goes to
+standard error.
+
+Without a command, the current command is printed. Use --clear to remove it.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: RunPlanOnComplete,
+}
+
+var onCompleteClearFlag bool
+
+func init() {
+	PlanOnCompleteCmd.Flags().BoolVar(&onCompleteClearFlag, "clear", false, "Remove the command")
+}
+
+func RunPlanOnComplete(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	if onCompleteClearFlag && len(args) > 1 {
+		return fmt.Errorf("--clear cannot be combined with a command")
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	if len(args) == 1 && !onCompleteClearFlag {
+		if plan.OnComplete() == "" {
+			fmt.Printf("No on-complete command set for plan '%s'\n", planName)
+			return nil
+		}
+		fmt.Println(plan.OnComplete())
+		return nil
+	}
+
+	command := ""
+	if len(args) > 1 {
+		command = args[1]
+	}
+	plan.SetOnComplete(command)
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	if command == "" {
+		GlobalSettings.Successf("Removed on-complete command of plan '%s'\n", planName)
+		return nil
+	}
+	GlobalSettings.Successf("On-complete command of plan '%s' set\n", planName)
+	return nil
+}
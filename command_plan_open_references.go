@@ -0,0 +1,79 @@
+package tasked
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanOpenReferencesCmd = &cobra.Command{
+	Use:   "open-references [--print] <plan-name> <step-id>",
+	Short: "Open the references of a step in the browser",
+	Long: `Open every reference of a step that is a URL, like https://example.com/docs, in
+the default browser, using open on macOS, the URL protocol handler of rundll32 on
+Windows and xdg-open on other systems. References that are not URLs, like local
+paths, are printed instead.
+
+Use --print to only list all references, one per line, without opening any.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanOpenReferences,
+}
+
+var openReferencesPrintFlag bool
+
+func init() {
+	PlanOpenReferencesCmd.Flags().BoolVar(&openReferencesPrintFlag, "print", false, "List the references instead of opening them")
+}
+
+func RunPlanOpenReferences(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Find the step
+	step, err := plan.FindStep(stepID)
+	if err != nil {
+		return fmt.Errorf("failed to find step: %w", err)
+	}
+
+	references := step.References()
+	if openReferencesPrintFlag {
+		for _, ref := range references {
+			fmt.Println(ref.URL)
+		}
+		return nil
+	}
+
+	if len(references) == 0 {
+		GlobalSettings.Successf("Step '%s' has no references\n", stepID)
+		return nil
+	}
+
+	// Keep going when one reference cannot be opened, and report all failures
+	var errs []error
+	for _, ref := range references {
+		if !isBrowsableURL(ref.URL) {
+			fmt.Printf("Not a URL, not opened: %s\n", ref.URL)
+			continue
+		}
+		if err := openInBrowser(ref.URL); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		GlobalSettings.Successf("Opened %s\n", ref.URL)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,89 @@
+package tasked
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+)
+
+var PlanProgressCmd = &cobra.Command{
+	Use:   "progress [--weighted] <plan-name>",
+	Short: "Show a one-line progress summary for a plan",
+	Long: `Show how many steps of a plan are done as a single line with a percentage and
+a progress bar. This is a lighter alternative to 'plan inspect' for checking a
+plan's status at a glance.
+
+With --weighted, the percentage and progress bar weight each step by its
+estimate (see 'plan add-step --estimate'), so a large step counts for more than
+a small one. Steps without an estimate then have no weight; if no step has an
+estimate, the step count is used as without --weighted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanProgress,
+}
+
+// progressBarWidth is the number of characters inside the progress bar brackets.
+const progressBarWidth = 20
+
+var progressWeightedFlag bool
+
+func init() {
+	PlanProgressCmd.Flags().BoolVar(&progressWeightedFlag, "weighted", false, "Weight steps by their estimates")
+}
+
+func RunPlanProgress(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	if progressWeightedFlag {
+		return printWeightedProgress(p, planName)
+	}
+
+	// Count the steps without loading the plan
+	total, done, err := p.CountSteps(planName)
+	if err != nil {
+		return fmt.Errorf("failed to count steps: %w", err)
+	}
+
+	if total == 0 {
+		fmt.Printf("%s: no steps\n", planName)
+		return nil
+	}
+
+	percent := done * 100 / total
+	filled := done * progressBarWidth / total
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled)
+
+	fmt.Printf("%s: %d/%d steps done (%d%%) [%s]\n", planName, done, total, percent, bar)
+	return nil
+}
+
+// printWeightedProgress prints the progress line of 'plan progress --weighted'.
+func printWeightedProgress(p *planner.Planner, planName string) error {
+	// Get the plan from the database, without criteria and references
+	plan, err := p.GetSummary(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	done, total := plan.Progress()
+	if total == 0 {
+		fmt.Printf("%s: no steps\n", planName)
+		return nil
+	}
+
+	progress := plan.WeightedProgress()
+	filled := int(math.Round(progress * progressBarWidth))
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled)
+
+	fmt.Printf("%s: %d/%d steps done (%d%% weighted by estimate) [%s]\n", planName, done, total, int(math.Round(progress*100)), bar)
+	return nil
+}
@@ -0,0 +1,74 @@
+package tasked
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanPruneStepsCmd = &cobra.Command{
+	Use:   "prune-steps [--dry-run] <plan-name>",
+	Short: "Remove all completed steps from a plan",
+	Long: `Remove every DONE step from a plan while keeping the plan and its remaining
+steps, e.g. to trim a long-running runbook. Unlike compacting, which removes whole
+completed plans, this works within a plan that is still in progress.
+
+With --dry-run, the steps that would be removed are listed and nothing is
+changed. The removal can be reverted with 'tasked undo'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanPruneSteps,
+}
+
+var pruneStepsDryRunFlag bool
+
+func init() {
+	PlanPruneStepsCmd.Flags().BoolVar(&pruneStepsDryRunFlag, "dry-run", false, "List the steps that would be removed without removing them")
+}
+
+func RunPlanPruneSteps(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	if pruneStepsDryRunFlag {
+		var completed []string
+		for _, step := range plan.Steps {
+			if strings.ToUpper(step.Status()) == "DONE" {
+				completed = append(completed, step.ID())
+			}
+		}
+		if len(completed) == 0 {
+			fmt.Printf("No completed steps in plan '%s'\n", planName)
+			return nil
+		}
+		fmt.Printf("Would remove %d completed step(s) from plan '%s': %s\n", len(completed), planName, strings.Join(completed, ", "))
+		return nil
+	}
+
+	// Remove the completed steps
+	removed := plan.RemoveCompleted()
+	if removed == 0 {
+		GlobalSettings.Successf("No completed steps in plan '%s'\n", planName)
+		return nil
+	}
+
+	// Save the plan
+	if err := p.SaveLogged(plan, "prune-steps"); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Removed %d completed step(s) from plan '%s'\n", removed, planName)
+	return nil
+}
@@ -3,7 +3,6 @@ package tasked
 import (
 	"fmt"
 
-	"github.com/dhamidi/tasked/planner"
 	"github.com/spf13/cobra"
 )
 
@@ -11,7 +10,8 @@ var PlanRemoveCmd = &cobra.Command{
 	Use:   "remove <plan-name> [plan-name...]",
 	Short: "Remove one or more plans",
 	Long: `Remove one or more plans by name. This will permanently delete the plans
-and all their associated steps and acceptance criteria from the database.`,
+and all their associated steps and acceptance criteria from the database.
+The removal can be reverted with 'tasked undo'.`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: RunPlanRemove,
 }
@@ -19,11 +19,8 @@ and all their associated steps and acceptance criteria from the database.`,
 func RunPlanRemove(cmd *cobra.Command, args []string) error {
 	planNames := args
 
-	// Get the database file path from settings
-	dbPath := GlobalSettings.GetDatabaseFile()
-
 	// Initialize the planner
-	p, err := planner.New(dbPath)
+	p, err := GlobalSettings.OpenPlanner()
 	if err != nil {
 		return fmt.Errorf("failed to initialize planner: %w", err)
 	}
@@ -39,7 +36,7 @@ func RunPlanRemove(cmd *cobra.Command, args []string) error {
 			fmt.Printf("Failed to remove plan '%s': %v\n", planName, err)
 			hasErrors = true
 		} else {
-			fmt.Printf("Removed plan '%s'\n", planName)
+			GlobalSettings.Successf("Removed plan '%s'\n", planName)
 		}
 	}
 
@@ -0,0 +1,58 @@
+package tasked
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanRemoveCriterionCmd = &cobra.Command{
+	Use:   "remove-criterion <plan-name> <step-id> <index>",
+	Short: "Remove an acceptance criterion from a step",
+	Long: `Remove an acceptance criterion from an existing step. The index is 1-based and
+matches the numbers shown by 'plan inspect' and 'plan show-step'; the remaining
+criteria are renumbered.`,
+	Args: cobra.ExactArgs(3),
+	RunE: RunPlanRemoveCriterion,
+}
+
+func RunPlanRemoveCriterion(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+
+	index, err := strconv.Atoi(args[2])
+	if err != nil || index <= 0 {
+		return fmt.Errorf("index must be a positive whole number, got '%s'", args[2])
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Remove the criterion from the step
+	step, err := plan.FindStep(stepID)
+	if err != nil {
+		return fmt.Errorf("failed to remove criterion: %w", err)
+	}
+	if err := step.RemoveCriterion(index); err != nil {
+		return fmt.Errorf("failed to remove criterion: %w", err)
+	}
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Removed acceptance criterion %d from step '%s' in plan '%s'\n", index, stepID, planName)
+	return nil
+}
@@ -3,7 +3,6 @@ package tasked
 import (
 	"fmt"
 
-	"github.com/dhamidi/tasked/planner"
 	"github.com/spf13/cobra"
 )
 
@@ -11,8 +10,8 @@ var PlanRemoveStepsCmd = &cobra.Command{
 	Use:   "remove-steps <plan-name> <step-id> [step-id]...",
 	Short: "Remove steps from a plan",
 	Long: `Remove one or more steps from a plan by their step IDs. This will delete
-the specified steps and their acceptance criteria from the plan. The operation
-is permanent and cannot be undone.`,
+the specified steps and their acceptance criteria from the plan. The removal
+can be reverted with 'tasked undo'.`,
 	Args: cobra.MinimumNArgs(2),
 	RunE: RunPlanRemoveSteps,
 }
@@ -21,11 +20,8 @@ func RunPlanRemoveSteps(cmd *cobra.Command, args []string) error {
 	planName := args[0]
 	stepIDs := args[1:]
 
-	// Get the database file path from settings
-	dbPath := GlobalSettings.GetDatabaseFile()
-
 	// Initialize the planner
-	p, err := planner.New(dbPath)
+	p, err := GlobalSettings.OpenPlanner()
 	if err != nil {
 		return fmt.Errorf("failed to initialize planner: %w", err)
 	}
@@ -52,7 +48,7 @@ func RunPlanRemoveSteps(cmd *cobra.Command, args []string) error {
 	plan.RemoveSteps(stepIDs)
 
 	// Save the updated plan to the database
-	err = p.Save(plan)
+	err = p.SaveLogged(plan, "remove-steps")
 	if err != nil {
 		return fmt.Errorf("failed to save plan: %w", err)
 	}
@@ -61,7 +57,7 @@ func RunPlanRemoveSteps(cmd *cobra.Command, args []string) error {
 	hasErrors := false
 	for _, stepID := range stepIDs {
 		if stepsFound[stepID] {
-			fmt.Printf("Removed step '%s' from plan '%s'\n", stepID, planName)
+			GlobalSettings.Successf("Removed step '%s' from plan '%s'\n", stepID, planName)
 		} else {
 			fmt.Printf("Step '%s' not found in plan '%s'\n", stepID, planName)
 			hasErrors = true
@@ -0,0 +1,44 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanRenameCmd = &cobra.Command{
+	Use:   "rename <plan-name> <new-name>",
+	Short: "Change the name of a plan",
+	Long: `Change the name of a plan. Its steps, tags and status history are kept under the
+new name. The new name must not be used by another plan yet. If the plan is the
+default plan set with 'plan use', the default is changed to the new name.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanRename,
+}
+
+func RunPlanRename(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	newName := args[1]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Rename the plan
+	if err := p.Rename(planName, newName); err != nil {
+		return fmt.Errorf("failed to rename plan: %w", err)
+	}
+
+	// Keep the default plan pointing at the renamed plan
+	if GlobalSettings.DefaultPlan == planName && planName != newName {
+		if err := GlobalSettings.SetDefaultPlan(newName); err != nil {
+			return fmt.Errorf("failed to update default plan: %w", err)
+		}
+	}
+
+	GlobalSettings.Successf("Renamed plan '%s' to '%s'\n", planName, newName)
+	return nil
+}
@@ -0,0 +1,94 @@
+package tasked
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+)
+
+var PlanRenumberCmd = &cobra.Command{
+	Use:   "renumber [--prefix prefix] [--json] <plan-name>",
+	Short: "Give the steps of a plan sequential IDs",
+	Long: `Assign new IDs to all steps of a plan in their current order, made of the prefix
+followed by the step's position: step-1, step-2, ... for the default prefix "step-".
+This cleans up numbered IDs after steps were inserted, moved or removed. Status,
+acceptance criteria and references of the steps are kept.
+
+Every step whose ID changed is listed as "old-id -> new-id", so that references
+to the steps elsewhere can be updated. Use --json to print this mapping as a
+JSON object from old to new IDs instead. The renumbering can be reverted with
+'tasked undo'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanRenumber,
+}
+
+var renumberPrefixFlag string
+var renumberJSONFlag bool
+
+func init() {
+	PlanRenumberCmd.Flags().StringVar(&renumberPrefixFlag, "prefix", "step-", "Prefix of the new step IDs")
+	PlanRenumberCmd.Flags().BoolVar(&renumberJSONFlag, "json", false, "Print the mapping from old to new step IDs as JSON")
+}
+
+func RunPlanRenumber(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	if !cmd.Flags().Changed("json") {
+		renumberJSONFlag = GlobalSettings.OutputFormat == "json"
+	}
+
+	// The number makes the ID non-empty, but the prefix must still be usable
+	if err := planner.ValidateStepID(renumberPrefixFlag + "1"); err != nil {
+		return fmt.Errorf("invalid prefix '%s': %w", renumberPrefixFlag, err)
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Renumber the steps
+	renamed := plan.Renumber(renumberPrefixFlag)
+	if len(renamed) > 0 {
+		// Save the plan
+		if err := p.SaveLogged(plan, "renumber"); err != nil {
+			return fmt.Errorf("failed to save plan: %w", err)
+		}
+	}
+
+	if renumberJSONFlag {
+		output, err := json.Marshal(renamed)
+		if err != nil {
+			return fmt.Errorf("failed to encode step IDs: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(renamed) == 0 {
+		GlobalSettings.Successf("Steps of plan '%s' are already numbered\n", planName)
+		return nil
+	}
+
+	GlobalSettings.Successf("Renumbered %d step(s) in plan '%s':\n", len(renamed), planName)
+	oldIDs := make(map[string]string, len(renamed))
+	for oldID, newID := range renamed {
+		oldIDs[newID] = oldID
+	}
+	for _, step := range plan.Steps {
+		if oldID, ok := oldIDs[step.ID()]; ok {
+			fmt.Printf("  %s -> %s\n", oldID, step.ID())
+		}
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package tasked
 import (
 	"fmt"
 
-	"github.com/dhamidi/tasked/planner"
 	"github.com/spf13/cobra"
 )
 
@@ -21,11 +20,8 @@ func RunPlanReorderSteps(cmd *cobra.Command, args []string) error {
 	planName := args[0]
 	stepIDs := args[1:]
 
-	// Get the database file path from settings
-	dbPath := GlobalSettings.GetDatabaseFile()
-
 	// Initialize the planner
-	p, err := planner.New(dbPath)
+	p, err := GlobalSettings.OpenPlanner()
 	if err != nil {
 		return fmt.Errorf("failed to initialize planner: %w", err)
 	}
@@ -57,6 +53,6 @@ func RunPlanReorderSteps(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to save plan: %w", err)
 	}
 
-	fmt.Printf("Reordered steps in plan '%s'\n", planName)
+	GlobalSettings.Successf("Reordered steps in plan '%s'\n", planName)
 	return nil
 }
@@ -0,0 +1,75 @@
+package tasked
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanResetCmd = &cobra.Command{
+	Use:   "reset [--confirm] <plan-name>",
+	Short: "Mark all steps of a plan as TODO again",
+	Long: `Set every step of a plan back to TODO, clearing completion times and blocked
+reasons, so a plan used as a repeatable checklist or runbook can be started over.
+Steps, acceptance criteria, references and logged time are kept.
+
+Because this discards the plan's completion state, it must be confirmed, either
+by passing --confirm or by answering the prompt shown when running in a terminal.
+Without confirmation nothing is changed and the number of steps that would be
+reset is printed. The reset can be reverted with 'tasked undo'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanReset,
+}
+
+var resetConfirmFlag bool
+
+func init() {
+	PlanResetCmd.Flags().BoolVar(&resetConfirmFlag, "confirm", false, "Reset the plan without asking for confirmation")
+}
+
+func RunPlanReset(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Count the steps that would be reset
+	pending := 0
+	for _, step := range plan.Steps {
+		if strings.ToUpper(step.Status()) != "TODO" {
+			pending++
+		}
+	}
+	if pending == 0 {
+		GlobalSettings.Successf("All steps in plan '%s' are already TODO\n", planName)
+		return nil
+	}
+
+	question := fmt.Sprintf("Reset %d step(s) in plan '%s' to TODO?", pending, planName)
+	if !resetConfirmFlag && !confirm(question) {
+		fmt.Printf("Aborted: %d step(s) in plan '%s' would be reset. Pass --confirm to confirm.\n", pending, planName)
+		return nil
+	}
+
+	// Reset all steps
+	reset := plan.ResetAll()
+
+	// Save the plan
+	if err := p.SaveLogged(plan, "reset"); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Reset %d step(s) in plan '%s' to TODO\n", reset, planName)
+	return nil
+}
@@ -0,0 +1,40 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanSaveTemplateCmd = &cobra.Command{
+	Use:   "save-template <plan-name> <template-name>",
+	Short: "Save the steps of a plan as a reusable template",
+	Long: `Save the steps of an existing plan as a template, including their
+descriptions, acceptance criteria, references, estimates and order. Step
+statuses and logged time are not saved.
+
+Templates are stored separately from plans and do not appear in 'plan list'.
+Use 'plan new-from-template' to start a new plan from a template.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanSaveTemplate,
+}
+
+func RunPlanSaveTemplate(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	templateName := args[1]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Save the template
+	if err := p.SaveTemplate(planName, templateName); err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+
+	GlobalSettings.Successf("Saved plan '%s' as template '%s'\n", planName, templateName)
+	return nil
+}
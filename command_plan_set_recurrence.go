@@ -0,0 +1,53 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanSetRecurrenceCmd = &cobra.Command{
+	Use:   "set-recurrence <plan-name> <daily|weekly|none>",
+	Short: "Set how often a plan is repeated",
+	Long: `Mark a plan as a recurring checklist that is repeated daily or weekly, or pass
+none to make it a one-off plan again.
+
+Once a recurring plan is completed and a day (daily) or a week (weekly) has
+passed since it was last updated, 'plan due' lists it as due, to be started over
+with 'plan reset'.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanSetRecurrence,
+}
+
+func RunPlanSetRecurrence(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	if err := plan.SetRecurrence(args[1]); err != nil {
+		return err
+	}
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	if plan.Recurrence() == "" {
+		GlobalSettings.Successf("Plan '%s' is no longer recurring\n", planName)
+		return nil
+	}
+	GlobalSettings.Successf("Plan '%s' now recurs %s\n", planName, plan.Recurrence())
+	return nil
+}
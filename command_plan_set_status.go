@@ -0,0 +1,55 @@
+package tasked
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanSetStatusCmd = &cobra.Command{
+	Use:   "set-status <plan-name> <step-id> <status>",
+	Short: "Set the status of a step",
+	Long: `Set the status of a step in a plan to TODO or DONE (case-insensitive), which
+makes it easy to script status changes with a variable. The MCP status values
+"completed" and "incomplete" are accepted as well.
+
+Setting DONE is equivalent to mark-as-completed and setting TODO to
+mark-as-incomplete. To block a step, use the block command, which also
+records the reason.`,
+	Args: cobra.ExactArgs(3),
+	RunE: RunPlanSetStatus,
+}
+
+// stepStatusAliases maps the status values accepted by set-status to step statuses.
+var stepStatusAliases = map[string]string{
+	"todo":       "TODO",
+	"done":       "DONE",
+	"incomplete": "TODO",
+	"completed":  "DONE",
+}
+
+func RunPlanSetStatus(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+
+	status, ok := stepStatusAliases[strings.ToLower(args[2])]
+	if !ok {
+		return fmt.Errorf("invalid status '%s' (must be TODO or DONE)", args[2])
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Update the step's status without rewriting the rest of the plan
+	if err := p.SetStepStatus(planName, stepID, status); err != nil {
+		return fmt.Errorf("failed to set step status: %w", err)
+	}
+
+	GlobalSettings.Successf("Step '%s' in plan '%s' set to %s\n", stepID, planName, status)
+	return nil
+}
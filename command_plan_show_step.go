@@ -0,0 +1,47 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanShowStepCmd = &cobra.Command{
+	Use:   "show-step <plan-name> <step-id>",
+	Short: "Show the details of a single step",
+	Long: `Display a single step of a plan, including its ID, status, description,
+acceptance criteria and references. Use this instead of 'plan inspect' when
+only one step is of interest.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanShowStep,
+}
+
+func RunPlanShowStep(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Find the step
+	step, err := plan.FindStep(stepID)
+	if err != nil {
+		return fmt.Errorf("failed to find step: %w", err)
+	}
+
+	// Display the step details
+	fmt.Printf("Step: %s\n", step.ID())
+	printStepDetails(step)
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package tasked
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show statistics across all plans",
+	Long: `Show aggregate statistics across all plans in the database: the number of plans
+and how many of them are completed, the number of steps by status, and the
+average number of steps per plan.
+
+Use --json to print the statistics as a JSON object. Setting
+output_format = "json" in the config file makes JSON the default; pass
+--json=false to override it.`,
+	Args: cobra.NoArgs,
+	RunE: RunPlanStats,
+}
+
+var statsJSONFlag bool
+
+func init() {
+	PlanStatsCmd.Flags().BoolVar(&statsJSONFlag, "json", false, "Print statistics as JSON")
+}
+
+func RunPlanStats(cmd *cobra.Command, args []string) error {
+	if !cmd.Flags().Changed("json") {
+		statsJSONFlag = GlobalSettings.OutputFormat == "json"
+	}
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Aggregate statistics across all plans
+	stats, err := p.Stats()
+	if err != nil {
+		return fmt.Errorf("failed to compute statistics: %w", err)
+	}
+
+	if statsJSONFlag {
+		output, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode statistics: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	fmt.Printf("Plans: %d (%d completed)\n", stats.TotalPlans, stats.CompletedPlans)
+	fmt.Printf("Steps: %d (%d done, %d todo, %d blocked)\n",
+		stats.TotalSteps, stats.DoneSteps, stats.TodoSteps, stats.BlockedSteps)
+	fmt.Printf("Average steps per plan: %.1f\n", stats.AverageStepsPerPlan)
+	return nil
+}
@@ -0,0 +1,53 @@
+package tasked
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanStepStatusCmd = &cobra.Command{
+	Use:   "step-status <plan-name> <step-id>",
+	Short: "Print a step's status and report it through the exit code",
+	Long: `Print the status of a single step (DONE, TODO or BLOCKED) so shell scripts can
+gate on individual steps. Exit code 0 indicates the step is done, exit code 1
+indicates it is not, and exit code 2 indicates that the plan or the step does
+not exist.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanStepStatus,
+}
+
+func RunPlanStepStatus(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database, without criteria and references
+	plan, err := p.GetSummary(planName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to get plan: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Find the step
+	step, err := plan.FindStep(stepID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	fmt.Println(step.Status())
+	if step.Status() == "DONE" {
+		os.Exit(0)
+	}
+	os.Exit(1)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package tasked
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanTagCmd = &cobra.Command{
+	Use:   "tag [--tag tag]... [--remove tag]... <plan-name>",
+	Short: "Add or remove tags on a plan",
+	Long: `Add or remove tags used to categorize a plan. Both --tag and --remove can be
+repeated. Without any flags, the plan's current tags are printed.
+
+Tagged plans can be filtered with 'plan list --tag <tag>'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanTag,
+}
+
+var tagsToAdd []string
+var tagsToRemove []string
+
+func init() {
+	PlanTagCmd.Flags().StringArrayVar(&tagsToAdd, "tag", nil, "Tag to add to the plan (repeatable)")
+	PlanTagCmd.Flags().StringArrayVar(&tagsToRemove, "remove", nil, "Tag to remove from the plan (repeatable)")
+}
+
+func RunPlanTag(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	if len(tagsToAdd) > 0 || len(tagsToRemove) > 0 {
+		for _, tag := range tagsToAdd {
+			tag = strings.TrimSpace(tag)
+			if tag == "" {
+				return fmt.Errorf("tag cannot be empty")
+			}
+			plan.AddTag(tag)
+		}
+		for _, tag := range tagsToRemove {
+			plan.RemoveTag(strings.TrimSpace(tag))
+		}
+
+		// Save the plan
+		if err := p.Save(plan); err != nil {
+			return fmt.Errorf("failed to save plan: %w", err)
+		}
+	}
+
+	if len(plan.Tags()) == 0 {
+		fmt.Printf("Plan '%s' has no tags\n", planName)
+	} else {
+		fmt.Printf("Plan '%s' tags: %s\n", planName, strings.Join(plan.Tags(), ", "))
+	}
+	return nil
+}
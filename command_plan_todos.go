@@ -0,0 +1,73 @@
+package tasked
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanTodosCmd = &cobra.Command{
+	Use:   "todos [--json]",
+	Short: "List the steps left to do across all plans",
+	Long: `List every step with status TODO in all plans that are not archived, grouped
+by plan. Plans are ordered by name and steps by their order within the plan.
+Blocked steps are not listed. The steps are read with a single query, so this
+stays fast with many plans.
+
+Use --json to print a JSON array of objects with the fields plan, step_id,
+description and status. Setting output_format = "json" in the config file makes
+JSON the default; pass --json=false to override it.`,
+	Args: cobra.NoArgs,
+	RunE: RunPlanTodos,
+}
+
+var todosJSONFlag bool
+
+func init() {
+	PlanTodosCmd.Flags().BoolVar(&todosJSONFlag, "json", false, "Print the steps as JSON")
+}
+
+func RunPlanTodos(cmd *cobra.Command, args []string) error {
+	if !cmd.Flags().Changed("json") {
+		todosJSONFlag = GlobalSettings.OutputFormat == "json"
+	}
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the steps left to do
+	steps, err := p.ListSteps("TODO")
+	if err != nil {
+		return fmt.Errorf("failed to list steps: %w", err)
+	}
+
+	if todosJSONFlag {
+		output, err := json.Marshal(steps)
+		if err != nil {
+			return fmt.Errorf("failed to encode steps: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(steps) == 0 {
+		fmt.Println("No steps left to do.")
+		return nil
+	}
+
+	for i, step := range steps {
+		if i == 0 || steps[i-1].Plan != step.Plan {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", step.Plan)
+		}
+		fmt.Printf("  %s %s\n", step.StepID, step.Description)
+	}
+	return nil
+}
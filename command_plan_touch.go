@@ -0,0 +1,36 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanTouchCmd = &cobra.Command{
+	Use:   "touch <plan-name>",
+	Short: "Update a plan's last-updated time",
+	Long: `Set the plan's last-updated time to now without changing the plan, e.g. to
+record that you looked at it today. Combine with 'plan list --sort updated' to
+find plans that have not been looked at in a while.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanTouch,
+}
+
+func RunPlanTouch(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Touch the plan
+	if err := p.Touch(planName); err != nil {
+		return fmt.Errorf("failed to touch plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Touched plan '%s'\n", planName)
+	return nil
+}
@@ -0,0 +1,179 @@
+package tasked
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dhamidi/tasked/planner"
+	"github.com/spf13/cobra"
+)
+
+var PlanTuiCmd = &cobra.Command{
+	Use:   "tui <plan-name>",
+	Short: "Edit a plan interactively in the terminal",
+	Long: `Open a terminal user interface listing the steps of a plan.
+
+Keys:
+  up/down   select a step
+  space     toggle the selected step between DONE and TODO
+  k/j       move the selected step up/down
+  q         save the changes and quit
+  ctrl+c    quit without saving
+
+All changes are saved in a single transaction when quitting with q.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanTui,
+}
+
+func RunPlanTui(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Run the interface until the user quits
+	result, err := tea.NewProgram(&planTuiModel{plan: plan}).Run()
+	if err != nil {
+		return fmt.Errorf("failed to run terminal interface: %w", err)
+	}
+	model := result.(*planTuiModel)
+	if !model.save || !model.changed {
+		return nil
+	}
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Saved plan '%s'\n", planName)
+	return nil
+}
+
+// planTuiModel is the bubbletea model behind 'plan tui'.
+// All changes are made to plan in memory; RunPlanTui saves them afterwards.
+type planTuiModel struct {
+	plan    *planner.Plan
+	cursor  int    // Index of the selected step
+	changed bool   // Whether the plan was modified
+	save    bool   // Whether the user asked to save on quit
+	err     string // Last error, shown below the step list
+}
+
+func (m *planTuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *planTuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	m.err = ""
+
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "q":
+		m.save = true
+		return m, tea.Quit
+	case "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down":
+		if m.cursor < len(m.plan.Steps)-1 {
+			m.cursor++
+		}
+	case " ":
+		m.toggle()
+	case "k":
+		m.move(-1)
+	case "j":
+		m.move(1)
+	}
+	return m, nil
+}
+
+// toggle flips the selected step between DONE and TODO.
+func (m *planTuiModel) toggle() {
+	if len(m.plan.Steps) == 0 {
+		return
+	}
+	step := m.plan.Steps[m.cursor]
+	var err error
+	if step.Status() == "DONE" {
+		err = m.plan.MarkAsIncomplete(step.ID())
+	} else {
+		err = m.plan.MarkAsCompleted(step.ID())
+	}
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.changed = true
+}
+
+// move swaps the selected step with its neighbour in direction (-1 for up, 1 for down).
+// The selection follows the moved step.
+func (m *planTuiModel) move(direction int) {
+	target := m.cursor + direction
+	if len(m.plan.Steps) == 0 || target < 0 || target >= len(m.plan.Steps) {
+		return
+	}
+	step := m.plan.Steps[m.cursor]
+	neighbour := m.plan.Steps[target]
+
+	position := planner.Position{Before: neighbour.ID()}
+	if direction > 0 {
+		position = planner.Position{After: neighbour.ID()}
+	}
+	if err := m.plan.MoveStep(step.ID(), position); err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.cursor = target
+	m.changed = true
+}
+
+func (m *planTuiModel) View() string {
+	var builder strings.Builder
+
+	done, total := m.plan.Progress()
+	builder.WriteString(fmt.Sprintf("Plan: %s (%d/%d steps done)\n\n", m.plan.ID, done, total))
+
+	if total == 0 {
+		builder.WriteString("  This plan has no steps.\n")
+	}
+	for i, step := range m.plan.Steps {
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+		mark := " "
+		switch step.Status() {
+		case "DONE":
+			mark = "x"
+		case "BLOCKED":
+			mark = "!"
+		}
+		builder.WriteString(fmt.Sprintf("%s [%s] %s: %s\n", cursor, mark, step.ID(), step.Description()))
+	}
+
+	if m.err != "" {
+		builder.WriteString("\nError: " + m.err + "\n")
+	}
+	builder.WriteString("\nup/down: select  space: toggle done  k/j: move step  q: save and quit  ctrl+c: quit without saving\n")
+	return builder.String()
+}
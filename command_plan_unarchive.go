@@ -0,0 +1,34 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanUnarchiveCmd = &cobra.Command{
+	Use:   "unarchive <plan-name>",
+	Short: "Restore an archived plan",
+	Long:  `Restore an archived plan so it shows up in 'plan list' again.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  RunPlanUnarchive,
+}
+
+func RunPlanUnarchive(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Unarchive the plan
+	if err := p.Unarchive(planName); err != nil {
+		return fmt.Errorf("failed to unarchive plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Unarchived plan '%s'\n", planName)
+	return nil
+}
@@ -0,0 +1,47 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanUnblockCmd = &cobra.Command{
+	Use:   "unblock <plan-name> <step-id>",
+	Short: "Unblock a blocked step",
+	Long: `Set a blocked step back to TODO and clear its blocked reason, making it
+available to 'plan next-step' again.`,
+	Args: cobra.ExactArgs(2),
+	RunE: RunPlanUnblock,
+}
+
+func RunPlanUnblock(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+	stepID := args[1]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// Unblock the step
+	if err := plan.Unblock(stepID); err != nil {
+		return fmt.Errorf("failed to unblock step: %w", err)
+	}
+
+	// Save the plan
+	if err := p.Save(plan); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Step '%s' in plan '%s' unblocked\n", stepID, planName)
+	return nil
+}
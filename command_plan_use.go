@@ -0,0 +1,55 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanUseCmd = &cobra.Command{
+	Use:   "use [<plan-name>]",
+	Short: "Set the default plan",
+	Long: `Make a plan the default plan, which is used by next-step, inspect and add-step
+when no plan name is given. The default is stored as default_plan in the config
+file (~/.tasked/config.toml unless --config is given); the rest of the file is
+kept. The plan must exist.
+
+Without a plan name, the current default plan is printed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: RunPlanUse,
+}
+
+func RunPlanUse(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		if GlobalSettings.DefaultPlan == "" {
+			fmt.Println("No default plan set")
+			return nil
+		}
+		fmt.Println(GlobalSettings.DefaultPlan)
+		return nil
+	}
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Only existing plans can become the default
+	exists, err := p.Exists(planName)
+	if err != nil {
+		return fmt.Errorf("failed to check plan: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("plan '%s' not found", planName)
+	}
+
+	if err := GlobalSettings.SetDefaultPlan(planName); err != nil {
+		return fmt.Errorf("failed to set default plan: %w", err)
+	}
+
+	GlobalSettings.Successf("Default plan set to '%s'\n", planName)
+	return nil
+}
@@ -0,0 +1,44 @@
+package tasked
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var PlanValidateCmd = &cobra.Command{
+	Use:   "validate <plan-name>",
+	Short: "Check that a plan is valid",
+	Long: `Check an existing plan for problems that would prevent it from being saved:
+steps with empty IDs, duplicate step IDs, statuses other than TODO, DONE and
+BLOCKED, or references without a URL.
+
+Exits with a non-zero status and reports the first problem found if the plan
+is invalid.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RunPlanValidate,
+}
+
+func RunPlanValidate(cmd *cobra.Command, args []string) error {
+	planName := args[0]
+
+	// Initialize the planner
+	p, err := GlobalSettings.OpenPlanner()
+	if err != nil {
+		return fmt.Errorf("failed to initialize planner: %w", err)
+	}
+	defer p.Close()
+
+	// Get the plan from the database
+	plan, err := p.Get(planName)
+	if err != nil {
+		return fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	if err := plan.Validate(); err != nil {
+		return fmt.Errorf("plan '%s' is invalid: %w", planName, err)
+	}
+
+	GlobalSettings.Successf("Plan '%s' is valid (%d steps)\n", planName, len(plan.Steps))
+	return nil
+}
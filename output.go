@@ -0,0 +1,44 @@
+package tasked
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Successf prints a message confirming that a command succeeded, formatted
+// like fmt.Printf. Nothing is printed when Quiet is set, so scripts only see
+// errors, which cobra writes to stderr.
+func (s *Settings) Successf(format string, args ...any) {
+	if s.Quiet {
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// writeOutput calls write with standard output if path is empty, and
+// otherwise with the file at path, which is created or truncated along with
+// any missing parent directories. This is shared by the export commands'
+// --output flag so that every format is written the same way.
+func writeOutput(path string, write func(w io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
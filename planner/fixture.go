@@ -0,0 +1,23 @@
+package planner
+
+import "fmt"
+
+// BuildPlan creates a plan named name with a step for each of specs, in
+// order, saves it and returns it as loaded back from the database.
+// It is meant for setting up plans in tests, including tests of programs
+// built on this package, e.g. against a planner opened with New(":memory:").
+// The specs are validated like for Plan.AddSteps, and it returns an error if
+// a plan with this name already exists.
+func BuildPlan(p *Planner, name string, specs ...StepSpec) (*Plan, error) {
+	plan, err := p.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := plan.AddSteps(specs); err != nil {
+		return nil, fmt.Errorf("failed to add steps to plan '%s': %w", name, err)
+	}
+	if err := p.Save(plan); err != nil {
+		return nil, err
+	}
+	return p.Get(name)
+}
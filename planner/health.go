@@ -0,0 +1,150 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// expectedTables lists the tables created by schema.sql.
+var expectedTables = []string{"plans", "steps", "step_acceptance_criteria", "step_references", "plan_tags", "operations_log", "plan_templates", "step_status_history"}
+
+// HealthReport describes the state of a planner's database.
+// This is returned by the HealthCheck method.
+type HealthReport struct {
+	SQLiteVersion  string   `json:"sqlite_version"`
+	JournalMode    string   `json:"journal_mode"` // "wal" when write-ahead logging is enabled, "memory" for in-memory databases
+	ForeignKeys    bool     `json:"foreign_keys"`
+	MissingTables  []string `json:"missing_tables"`
+	MissingColumns []string `json:"missing_columns"` // As "table.column"
+	Problems       []string `json:"problems"`        // Human-readable descriptions of everything that is wrong
+}
+
+// OK reports whether the health check found no problems.
+func (r HealthReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// HealthCheck inspects the database: it reports the SQLite version and
+// journal mode, and checks that foreign keys are enforced, that WAL is
+// enabled, and that all expected tables and migrated columns exist.
+// Problems found are listed in the report; an error is only returned if
+// the database could not be queried.
+func (p *Planner) HealthCheck() (HealthReport, error) {
+	var report HealthReport
+
+	if err := p.db.QueryRow("SELECT sqlite_version()").Scan(&report.SQLiteVersion); err != nil {
+		return report, fmt.Errorf("failed to query SQLite version: %w", err)
+	}
+
+	if err := p.db.QueryRow("PRAGMA journal_mode").Scan(&report.JournalMode); err != nil {
+		return report, fmt.Errorf("failed to query journal mode: %w", err)
+	}
+	report.JournalMode = strings.ToLower(report.JournalMode)
+	// In-memory databases cannot use WAL and report "memory" instead
+	if report.JournalMode != "wal" && report.JournalMode != "memory" {
+		report.Problems = append(report.Problems, fmt.Sprintf("journal mode is '%s', expected 'wal'", report.JournalMode))
+	}
+
+	var foreignKeys int
+	if err := p.db.QueryRow("PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return report, fmt.Errorf("failed to query foreign key enforcement: %w", err)
+	}
+	report.ForeignKeys = foreignKeys == 1
+	if !report.ForeignKeys {
+		report.Problems = append(report.Problems, "foreign key constraints are not enforced")
+	}
+
+	missing := make(map[string]bool)
+	for _, table := range expectedTables {
+		var count int
+		err := p.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
+		if err != nil {
+			return report, fmt.Errorf("failed to check table '%s': %w", table, err)
+		}
+		if count == 0 {
+			missing[table] = true
+			report.MissingTables = append(report.MissingTables, table)
+			report.Problems = append(report.Problems, fmt.Sprintf("table '%s' is missing", table))
+		}
+	}
+
+	for _, migration := range columnMigrations {
+		if missing[migration.table] {
+			continue
+		}
+		exists, err := columnExists(p.db, migration.table, migration.column)
+		if err != nil {
+			return report, err
+		}
+		if !exists {
+			column := migration.table + "." + migration.column
+			report.MissingColumns = append(report.MissingColumns, column)
+			report.Problems = append(report.Problems, fmt.Sprintf("column '%s' is missing", column))
+		}
+	}
+
+	return report, nil
+}
+
+// integrityKeyColumns names, for each table with foreign keys, the two columns
+// that identify an orphaned row in the reports of CheckIntegrity.
+var integrityKeyColumns = map[string][2]string{
+	"steps":                    {"plan_id", "id"},
+	"step_acceptance_criteria": {"plan_id", "step_id"},
+	"step_references":          {"plan_id", "step_id"},
+	"plan_tags":                {"plan_id", "tag"},
+}
+
+// CheckIntegrity runs SQLite's foreign key check over the whole database and
+// describes every row that references a missing parent, e.g. a step whose plan
+// was deleted while foreign keys were not enforced. It returns no descriptions
+// if the database is consistent.
+// The check reads every table, so it is not run when a planner is opened;
+// HealthCheck does not include it either.
+func (p *Planner) CheckIntegrity() ([]string, error) {
+	type violation struct {
+		table  string
+		rowid  int64
+		parent string
+	}
+
+	rows, err := p.db.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	var violations []violation
+	for rows.Next() {
+		var v violation
+		var fkid int
+		if err := rows.Scan(&v.table, &v.rowid, &v.parent, &fkid); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan foreign key violation: %w", err)
+		}
+		violations = append(violations, v)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	rows.Close()
+
+	// Look up the orphaned rows only after the check is done, since in-memory
+	// databases only have a single connection
+	var problems []string
+	for _, v := range violations {
+		columns, ok := integrityKeyColumns[v.table]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("row %d in table '%s' references a missing row in '%s'", v.rowid, v.table, v.parent))
+			continue
+		}
+
+		var planID, key string
+		query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE rowid = ?", columns[0], columns[1], v.table)
+		if err := p.db.QueryRow(query, v.rowid).Scan(&planID, &key); err != nil {
+			return nil, fmt.Errorf("failed to query orphaned row %d in table '%s': %w", v.rowid, v.table, err)
+		}
+		problems = append(problems, fmt.Sprintf("orphaned row in '%s' (%s '%s', %s '%s') references a missing row in '%s'",
+			v.table, columns[0], planID, columns[1], key, v.parent))
+	}
+	return problems, nil
+}
@@ -0,0 +1,109 @@
+package planner
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatusChange describes a change of a step's status.
+// This is returned by the History method.
+type StatusChange struct {
+	StepID    string    `json:"step_id"`
+	OldStatus string    `json:"old_status"` // "DONE", "TODO" or "BLOCKED"
+	NewStatus string    `json:"new_status"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// recordStatusChange writes a change of the status of the step stepID from
+// oldStatus to newStatus to the status history as part of tx.
+// Nothing is written if the status did not change.
+func recordStatusChange(tx *sql.Tx, planID, stepID, oldStatus, newStatus string) error {
+	if oldStatus == newStatus {
+		return nil
+	}
+	_, err := tx.Exec("INSERT INTO step_status_history (plan_id, step_id, old_status, new_status, changed_at) VALUES (?, ?, ?, ?, ?)",
+		planID, stepID, oldStatus, newStatus, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record status change of step '%s' in plan '%s': %w", stepID, planID, err)
+	}
+	return nil
+}
+
+// deleteHistory removes the status history of the plan planName as part of tx.
+// The history has no foreign key on plans, so it is not removed along with
+// the plan.
+func deleteHistory(tx *sql.Tx, planName string) error {
+	if _, err := tx.Exec("DELETE FROM step_status_history WHERE plan_id = ?", planName); err != nil {
+		return fmt.Errorf("failed to delete status history of plan '%s': %w", planName, err)
+	}
+	return nil
+}
+
+// renameStepHistory moves the status changes recorded for renamed steps to
+// their new IDs as part of tx. renamed maps new step IDs to old ones, see
+// Plan.Renumber. All steps are renamed in a single statement, so that IDs
+// can be swapped between steps.
+func renameStepHistory(tx *sql.Tx, planID string, renamed map[string]string) error {
+	if len(renamed) == 0 {
+		return nil
+	}
+
+	var cases strings.Builder
+	var caseArgs, oldIDs []any
+	for newID, oldID := range renamed {
+		cases.WriteString(" WHEN ? THEN ?")
+		caseArgs = append(caseArgs, oldID, newID)
+		oldIDs = append(oldIDs, oldID)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(oldIDs)), ", ")
+	query := "UPDATE step_status_history SET step_id = CASE step_id" + cases.String() + " END WHERE plan_id = ? AND step_id IN (" + placeholders + ")"
+
+	args := append(append(caseArgs, planID), oldIDs...)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to rename status history of steps in plan '%s': %w", planID, err)
+	}
+	return nil
+}
+
+// History returns the status changes of the steps of the plan planName,
+// oldest first. Changes are recorded whenever Save, SetStepStatus or
+// CompleteAndNext persist a step with a different status than the one stored;
+// steps added with their initial status have no entry. The history of removed
+// steps is kept. The history of plans removed with Remove or Compact is
+// removed with them and restored by Undo.
+// It returns an error wrapping ErrPlanNotFound if the plan does not exist.
+func (p *Planner) History(planName string) ([]StatusChange, error) {
+	exists, err := p.Exists(planName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("cannot get history of plan '%s': %w", planName, ErrPlanNotFound)
+	}
+	return queryHistory(p.db, planName)
+}
+
+// queryHistory returns the status changes recorded for the plan planName on
+// a database or inside a transaction, oldest first.
+func queryHistory(q querier, planName string) ([]StatusChange, error) {
+	rows, err := q.Query("SELECT step_id, old_status, new_status, changed_at FROM step_status_history WHERE plan_id = ? ORDER BY changed_at ASC, id ASC", planName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history of plan '%s': %w", planName, err)
+	}
+	defer rows.Close()
+
+	changes := []StatusChange{}
+	for rows.Next() {
+		var change StatusChange
+		if err := rows.Scan(&change.StepID, &change.OldStatus, &change.NewStatus, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan history of plan '%s': %w", planName, err)
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating history of plan '%s': %w", planName, err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,108 @@
+package planner
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// OnCompleteEnvVar names the environment variable that holds the name of the
+// completed plan when its on-complete command runs, see Plan.SetOnComplete.
+const OnCompleteEnvVar = "TASKED_PLAN"
+
+// OnComplete returns the shell command run when the plan becomes completed,
+// or an empty string if none is set.
+func (pl *Plan) OnComplete() string {
+	return pl.onComplete
+}
+
+// SetOnComplete sets the shell command to run when a change completes the
+// plan, that is when a Save, SetStepStatus or CompleteAndNext turns a plan
+// with a step that is not "DONE" into one whose steps are all "DONE".
+// The command runs once per such change, after it was committed, and not
+// when a plan that already was completed is saved again. It is run with
+// sh -c (cmd /C on Windows) without waiting for it to finish, with the
+// plan's name in the environment variable named by OnCompleteEnvVar; its
+// output goes to standard error. An empty command removes the hook.
+// The command is persisted on the next Save.
+func (pl *Plan) SetOnComplete(command string) {
+	pl.onComplete = command
+}
+
+// completionHook is the on-complete command of a plan that a change in a
+// transaction completed, to be run once the transaction is committed.
+type completionHook struct {
+	planName string
+	command  string
+}
+
+// planCompleted reports whether the plan planName has steps and all of them
+// are "DONE". Unlike Plan.IsCompleted, a plan without steps is not completed,
+// so that creating a plan does not run its hook.
+func planCompleted(q rowQuerier, planName string) (bool, error) {
+	var total, done int
+	err := q.QueryRow("SELECT COUNT(*), COALESCE(SUM(status = 'DONE'), 0) FROM steps WHERE plan_id = ?", planName).Scan(&total, &done)
+	if err != nil {
+		return false, fmt.Errorf("failed to check completion of plan '%s': %w", planName, err)
+	}
+	return total > 0 && done == total, nil
+}
+
+// watchCompletion calls change, which modifies the plan planName as part of
+// tx, and returns the plan's hook if the change completed the plan and the
+// plan has an on-complete command. Otherwise, it returns nil.
+func watchCompletion(tx *sql.Tx, planName string, change func() error) (*completionHook, error) {
+	wasCompleted, err := planCompleted(tx, planName)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(); err != nil {
+		return nil, err
+	}
+	if wasCompleted {
+		return nil, nil
+	}
+
+	completed, err := planCompleted(tx, planName)
+	if err != nil || !completed {
+		return nil, err
+	}
+
+	var command sql.NullString
+	if err := tx.QueryRow("SELECT on_complete FROM plans WHERE id = ?", planName).Scan(&command); err != nil {
+		return nil, fmt.Errorf("failed to query on-complete command of plan '%s': %w", planName, err)
+	}
+	if command.String == "" {
+		return nil, nil
+	}
+	return &completionHook{planName: planName, command: command.String}, nil
+}
+
+// run starts the hook's command in the background. The change that
+// completed the plan is already committed at this point, so a command that
+// cannot be started is reported on standard error instead of failing it.
+// Calling run on a nil hook does nothing.
+func (h *completionHook) run() {
+	if h == nil {
+		return
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", h.command)
+	} else {
+		cmd = exec.Command("sh", "-c", h.command)
+	}
+	cmd.Env = append(os.Environ(), OnCompleteEnvVar+"="+h.planName)
+	// Standard output may be in use, e.g. by the MCP server's stdio transport
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to run on-complete command of plan '%s': %v\n", h.planName, err)
+		return
+	}
+	go cmd.Wait() // Reap the process in long-running servers
+}
@@ -0,0 +1,196 @@
+package planner
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// stepOrderGap is the distance between the step_order values that Save
+// assigns to consecutive steps. A step inserted with InsertStep takes the
+// value halfway between its neighbours, so that no other step has to be
+// rewritten. Once two neighbours are only 1 apart, InsertStep falls back to
+// renumbering the plan's steps with this gap again before inserting.
+// Only the relative order of step_order values matters; Step.Order is always
+// the step's position in its plan.
+const stepOrderGap = 1024
+
+// NewStep returns a step with status "TODO" that is not part of any plan yet,
+// e.g. to be added with InsertStep.
+func NewStep(id, description string, acceptanceCriteria []string, references []Reference) *Step {
+	return &Step{
+		id:          id,
+		description: description,
+		status:      "TODO",
+		acceptance:  acceptanceCriteria,
+		references:  references,
+	}
+}
+
+// InsertStep adds step to the stored plan planName at the given position,
+// writing only the new step instead of saving the whole plan like Save.
+// Without a target in position, the step is added at the end of the plan;
+// otherwise exactly one target must be given, like for Plan.MoveStep.
+// The step ID is checked with ValidateStepID and must not be used in the plan
+// yet. The plan's version is incremented, so copies of the plan loaded
+// earlier can no longer be saved.
+// It returns an error wrapping ErrPlanNotFound if the plan does not exist,
+// one wrapping ErrDuplicateStepID if the plan already has a step with the ID,
+// and one wrapping ErrTooManySteps if the plan already has as many steps as
+// the planner loads at most, see Options.MaxSteps.
+func (p *Planner) InsertStep(planName string, step *Step, position Position) error {
+	if err := ValidateStepID(step.id); err != nil {
+		return err
+	}
+	if err := step.Validate(); err != nil {
+		return err
+	}
+	targets := 0
+	for _, set := range []bool{position.Before != "", position.After != "", position.ToTop, position.ToBottom} {
+		if set {
+			targets++
+		}
+	}
+	if targets > 1 {
+		return fmt.Errorf("at most one of before, after, to top or to bottom can be given")
+	}
+
+	return p.WithTx(func(tx *Tx) error {
+		return insertStep(tx.tx, planName, step, position, tx.maxSteps)
+	})
+}
+
+// insertStep implements InsertStep as part of tx. Plans that already have
+// maxSteps steps are rejected, unless maxSteps is 0.
+func insertStep(tx *sql.Tx, planName string, step *Step, position Position, maxSteps int) error {
+	result, err := tx.Exec("UPDATE plans SET version = version + 1 WHERE id = ?", planName)
+	if err != nil {
+		return fmt.Errorf("failed to update version of plan '%s': %w", planName, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check version of plan '%s': %w", planName, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("cannot insert step into plan '%s': %w", planName, ErrPlanNotFound)
+	}
+
+	ids, orders, err := stepOrders(tx, planName)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if id == step.id {
+			return fmt.Errorf("step with ID '%s' already exists in plan '%s': %w", step.id, planName, ErrDuplicateStepID)
+		}
+	}
+	if maxSteps > 0 && len(ids) >= maxSteps {
+		return fmt.Errorf("cannot insert step into plan '%s': it would have more than %d steps: %w", planName, maxSteps, ErrTooManySteps)
+	}
+
+	// The step goes before the step at index
+	index := len(ids)
+	if position.ToTop {
+		index = 0
+	}
+	anchor := position.Before
+	if position.After != "" {
+		anchor = position.After
+	}
+	if anchor != "" {
+		index = -1
+		for i, id := range ids {
+			if id == anchor {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("step with ID '%s' not found in plan '%s'", anchor, planName)
+		}
+		if position.After != "" {
+			index++
+		}
+	}
+
+	order, ok := orderBetween(orders, index)
+	if !ok {
+		// No gap is left between the neighbours, so spread the steps out again
+		for i, id := range ids {
+			orders[i] = i * stepOrderGap
+			if _, err := tx.Exec("UPDATE steps SET step_order = ? WHERE plan_id = ? AND id = ?", orders[i], planName, id); err != nil {
+				return fmt.Errorf("failed to renumber step '%s' in plan '%s': %w", id, planName, err)
+			}
+		}
+		order, _ = orderBetween(orders, index)
+	}
+
+	_, err = tx.Exec("INSERT INTO steps (id, plan_id, description, status, step_order, estimate_minutes, actual_minutes, blocked_reason, completed_at, assignee) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		step.id, planName, step.description, step.status, order, step.estimate, step.actual, nullableString(step.blocked), nullableTime(step.completedAt), nullableString(step.assignee))
+	if err != nil {
+		return fmt.Errorf("failed to insert step '%s' into plan '%s': %w", step.id, planName, err)
+	}
+
+	if err := insertAcceptanceCriteria(tx, planName, step.id, step.acceptance, step.met); err != nil {
+		return err
+	}
+
+	// The same URL is only stored once per step, keeping the first occurrence
+	step.references = uniqueReferences(step.references)
+	for j, ref := range step.references {
+		_, err = tx.Exec("INSERT INTO step_references (plan_id, step_id, reference_order, reference_url, title) VALUES (?, ?, ?, ?, ?)",
+			planName, step.id, j, ref.URL, nullableString(ref.Title))
+		if err != nil {
+			return fmt.Errorf("failed to insert reference for step '%s' in plan '%s': %w", step.id, planName, err)
+		}
+	}
+
+	step.stepOrder = index
+	return nil
+}
+
+// stepOrders returns the IDs and step_order values of the steps of the plan
+// planName, ordered by step_order.
+func stepOrders(tx *sql.Tx, planName string) ([]string, []int, error) {
+	rows, err := tx.Query("SELECT id, step_order FROM steps WHERE plan_id = ? ORDER BY step_order ASC", planName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query steps for plan '%s': %w", planName, err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	var orders []int
+	for rows.Next() {
+		var id string
+		var order int
+		if err := rows.Scan(&id, &order); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan step for plan '%s': %w", planName, err)
+		}
+		ids = append(ids, id)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating steps for plan '%s': %w", planName, err)
+	}
+	return ids, orders, nil
+}
+
+// orderBetween returns a step_order value for a step placed before the step
+// at index in orders, which holds the step_order values of a plan in
+// ascending order. It reports false if no integer is left between the
+// neighbouring values.
+func orderBetween(orders []int, index int) (int, bool) {
+	switch {
+	case len(orders) == 0:
+		return 0, true
+	case index == 0:
+		return orders[0] - stepOrderGap, true
+	case index == len(orders):
+		return orders[len(orders)-1] + stepOrderGap, true
+	}
+
+	before, after := orders[index-1], orders[index]
+	if after-before < 2 {
+		return 0, false
+	}
+	return before + (after-before)/2, true
+}
@@ -0,0 +1,127 @@
+package planner
+
+import (
+	"context"
+	"database/sql/driver"
+	"log"
+	"strings"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// loggingConnector opens SQLite connections that report every statement
+// they execute to a logger. It is used by NewWithOptions when a logger is set.
+type loggingConnector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+	logger *log.Logger
+}
+
+func (c *loggingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &loggingConn{conn: conn.(*sqlite3.SQLiteConn), logger: c.logger}, nil
+}
+
+func (c *loggingConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// loggingConn wraps a SQLite connection and logs statements before running them.
+type loggingConn struct {
+	conn   *sqlite3.SQLiteConn
+	logger *log.Logger
+}
+
+func (c *loggingConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *loggingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &loggingStmt{stmt: stmt.(*sqlite3.SQLiteStmt), query: query, logger: c.logger}, nil
+}
+
+func (c *loggingConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *loggingConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *loggingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.conn.BeginTx(ctx, opts)
+}
+
+func (c *loggingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	logStatement(c.logger, query, args)
+	return c.conn.ExecContext(ctx, query, args)
+}
+
+func (c *loggingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	logStatement(c.logger, query, args)
+	return c.conn.QueryContext(ctx, query, args)
+}
+
+// loggingStmt wraps a prepared statement so each execution is logged with its arguments.
+type loggingStmt struct {
+	stmt   *sqlite3.SQLiteStmt
+	query  string
+	logger *log.Logger
+}
+
+func (s *loggingStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *loggingStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *loggingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *loggingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *loggingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	logStatement(s.logger, s.query, args)
+	return s.stmt.ExecContext(ctx, args)
+}
+
+func (s *loggingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	logStatement(s.logger, s.query, args)
+	return s.stmt.QueryContext(ctx, args)
+}
+
+// namedValues converts positional driver values to ordinal named values.
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+// logStatement writes query on a single line, followed by its arguments if there are any.
+func logStatement(logger *log.Logger, query string, args []driver.NamedValue) {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(args) == 0 {
+		logger.Print(query)
+		return
+	}
+
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	logger.Printf("%s %v", query, values)
+}
@@ -175,6 +175,45 @@ func TestDatabaseMigration(t *testing.T) {
 			t.Fatalf("step_references table should exist after migration, but found %d tables", count)
 		}
 
+		// Verify columns added after the steps table was created now exist
+		for _, migration := range columnMigrations {
+			exists, err := columnExists(planner.db, migration.table, migration.column)
+			if err != nil {
+				t.Fatalf("Failed to check for column '%s' after migration: %v", migration.column, err)
+			}
+			if !exists {
+				t.Fatalf("Column '%s' should exist on %s after migration", migration.column, migration.table)
+			}
+		}
+
+		// Verify the redundant index on steps(plan_id) was dropped
+		err = planner.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='index' AND name='idx_steps_plan_id'").Scan(&count)
+		if err != nil {
+			t.Fatalf("Failed to check for idx_steps_plan_id after migration: %v", err)
+		}
+		if count != 0 {
+			t.Fatalf("idx_steps_plan_id should be dropped after migration, but found %d indexes", count)
+		}
+
+		// Verify the steps table was rebuilt to allow the BLOCKED status
+		var stepsSQL string
+		err = planner.db.QueryRow("SELECT sql FROM sqlite_master WHERE type='table' AND name='steps'").Scan(&stepsSQL)
+		if err != nil {
+			t.Fatalf("Failed to read steps table definition after migration: %v", err)
+		}
+		if !contains(stepsSQL, "'BLOCKED'") {
+			t.Fatalf("steps table should allow BLOCKED status after migration, got:\n%s", stepsSQL)
+		}
+
+		// Verify triggers dropped during the rebuild were restored
+		err = planner.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='trigger'").Scan(&count)
+		if err != nil {
+			t.Fatalf("Failed to count triggers after migration: %v", err)
+		}
+		if count != 4 {
+			t.Fatalf("Expected 4 triggers after migration, found %d", count)
+		}
+
 		// Verify all existing data is still intact
 		var planID string
 		err = planner.db.QueryRow("SELECT id FROM plans WHERE id = 'test-plan'").Scan(&planID)
@@ -286,7 +325,7 @@ func TestDatabaseMigration(t *testing.T) {
 		// Add a step with references to the existing plan
 		plan.AddStep("step2", "Test step with references",
 			[]string{"Reference criterion"},
-			[]string{"https://example.com/ref1", "https://example.com/ref2"})
+			[]Reference{{URL: "https://example.com/ref1"}, {URL: "https://example.com/ref2"}})
 
 		err = planner.Save(plan)
 		if err != nil {
@@ -319,10 +358,10 @@ func TestDatabaseMigration(t *testing.T) {
 		if len(newStep.References()) != 2 {
 			t.Fatalf("Expected 2 references, got %d", len(newStep.References()))
 		}
-		if newStep.References()[0] != "https://example.com/ref1" {
+		if newStep.References()[0].URL != "https://example.com/ref1" {
 			t.Fatalf("Expected first reference 'https://example.com/ref1', got '%s'", newStep.References()[0])
 		}
-		if newStep.References()[1] != "https://example.com/ref2" {
+		if newStep.References()[1].URL != "https://example.com/ref2" {
 			t.Fatalf("Expected second reference 'https://example.com/ref2', got '%s'", newStep.References()[1])
 		}
 
@@ -358,7 +397,7 @@ func TestDatabaseMigration(t *testing.T) {
 
 		// Add steps without references (old API usage)
 		plan.AddStep("step1", "Step without references", []string{"Criterion 1"}, nil)
-		plan.AddStep("step2", "Another step", []string{}, []string{}) // Empty slices
+		plan.AddStep("step2", "Another step", []string{}, []Reference{}) // Empty slices
 
 		err = planner.Save(plan)
 		if err != nil {
@@ -417,7 +456,7 @@ func TestSchemaValidation(t *testing.T) {
 	schema := string(schemaContent)
 
 	// Check that all CREATE TABLE statements use IF NOT EXISTS
-	tables := []string{"plans", "steps", "step_acceptance_criteria", "step_references"}
+	tables := []string{"plans", "steps", "step_acceptance_criteria", "step_references", "plan_tags"}
 	for _, table := range tables {
 		expectedPattern := "CREATE TABLE IF NOT EXISTS " + table
 		if !contains(schema, expectedPattern) {
@@ -427,9 +466,11 @@ func TestSchemaValidation(t *testing.T) {
 
 	// Check that all CREATE INDEX statements use IF NOT EXISTS
 	indexes := []string{
-		"idx_steps_plan_id",
+		"idx_steps_status",
+		"idx_steps_plan_status",
 		"idx_step_acceptance_criteria_plan_step",
 		"idx_step_references_plan_step",
+		"idx_plan_tags_tag",
 	}
 	for _, index := range indexes {
 		expectedPattern := "CREATE INDEX IF NOT EXISTS " + index
@@ -0,0 +1,200 @@
+package planner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// columnMigration describes a column that was added to a table after the table
+// was first introduced. SQLite has no "ADD COLUMN IF NOT EXISTS", so columns
+// missing from databases created by older versions are added when the database is opened.
+type columnMigration struct {
+	table      string
+	column     string
+	definition string // Column definition as used in ALTER TABLE ... ADD COLUMN
+}
+
+// columnMigrations lists all columns added after their table's creation.
+// New databases get these columns from schema.sql directly.
+var columnMigrations = []columnMigration{
+	{table: "steps", column: "estimate_minutes", definition: "estimate_minutes INTEGER"},
+	{table: "steps", column: "actual_minutes", definition: "actual_minutes INTEGER"},
+	{table: "plans", column: "version", definition: "version INTEGER NOT NULL DEFAULT 0"},
+	{table: "steps", column: "blocked_reason", definition: "blocked_reason TEXT"},
+	{table: "plans", column: "archived", definition: "archived INTEGER NOT NULL DEFAULT 0"},
+	{table: "step_references", column: "title", definition: "title TEXT"},
+	{table: "steps", column: "completed_at", definition: "completed_at TIMESTAMP"},
+	{table: "steps", column: "assignee", definition: "assignee TEXT"},
+	{table: "step_acceptance_criteria", column: "met", definition: "met INTEGER NOT NULL DEFAULT 0"},
+	{table: "plans", column: "on_complete", definition: "on_complete TEXT"},
+	{table: "plans", column: "recurrence", definition: "recurrence TEXT"},
+}
+
+// migrateColumns adds any columns from columnMigrations that are missing in db.
+func migrateColumns(db *sql.DB) error {
+	for _, migration := range columnMigrations {
+		exists, err := columnExists(db, migration.table, migration.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", migration.table, migration.definition))
+		if err != nil {
+			return fmt.Errorf("failed to add column '%s' to table '%s': %w", migration.column, migration.table, err)
+		}
+	}
+	return nil
+}
+
+// columnExists reports whether table has a column with the given name.
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to query columns of table '%s': %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return false, fmt.Errorf("failed to scan column of table '%s': %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("error iterating columns of table '%s': %w", table, err)
+	}
+	return false, nil
+}
+
+// constraintMigration describes a change to a constraint in a table's definition.
+// SQLite cannot alter constraints in place, so tables whose stored definition
+// still contains oldClause are rebuilt with newClause instead.
+type constraintMigration struct {
+	table     string
+	oldClause string
+	newClause string
+}
+
+// constraintMigrations lists all constraint changes made after a table's creation.
+// New databases get the current constraints from schema.sql directly.
+var constraintMigrations = []constraintMigration{
+	{
+		table:     "steps",
+		oldClause: "CHECK(status IN ('TODO', 'DONE'))",
+		newClause: "CHECK(status IN ('TODO', 'DONE', 'BLOCKED'))",
+	},
+}
+
+// migrateConstraints rebuilds every table from constraintMigrations whose
+// definition still contains the old clause. The schema is executed again
+// afterwards to restore the indexes and triggers dropped with the old tables.
+func migrateConstraints(db *sql.DB) error {
+	rebuilt := false
+	for _, migration := range constraintMigrations {
+		var tableSQL string
+		err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", migration.table).Scan(&tableSQL)
+		if err != nil {
+			return fmt.Errorf("failed to query definition of table '%s': %w", migration.table, err)
+		}
+		if !strings.Contains(tableSQL, migration.oldClause) {
+			continue
+		}
+		newSQL := strings.Replace(tableSQL, migration.oldClause, migration.newClause, 1)
+		if err := rebuildTable(db, migration.table, newSQL); err != nil {
+			return err
+		}
+		rebuilt = true
+	}
+
+	if rebuilt {
+		if _, err := db.Exec(string(embeddedSchema)); err != nil {
+			return fmt.Errorf("failed to restore schema after rebuilding tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// rebuildTable replaces table with a new table created from createSQL
+// (a CREATE TABLE statement for table with the same columns), copying all rows.
+// Foreign key enforcement is disabled while the table is swapped so that rows
+// in tables referencing it are neither deleted nor rejected.
+// All triggers are dropped, since triggers on other tables that mention table
+// would make the rename fail; the caller must recreate them from the schema.
+func rebuildTable(db *sql.DB, table, createSQL string) error {
+	ctx := context.Background()
+
+	// PRAGMA foreign_keys is per connection and has no effect inside a
+	// transaction, so the rebuild runs on a dedicated connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection to rebuild table '%s': %w", table, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF;"); err != nil {
+		return fmt.Errorf("failed to disable foreign keys to rebuild table '%s': %w", table, err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON;")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction to rebuild table '%s': %w", table, err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	var triggers []string
+	rows, err := tx.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'trigger'")
+	if err != nil {
+		return fmt.Errorf("failed to query triggers to rebuild table '%s': %w", table, err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan trigger name: %w", err)
+		}
+		triggers = append(triggers, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating triggers: %w", err)
+	}
+
+	tempTable := table + "_migrated"
+	tempSQL := strings.Replace(createSQL, "CREATE TABLE "+table, "CREATE TABLE "+tempTable, 1)
+
+	var statements []string
+	for _, trigger := range triggers {
+		statements = append(statements, fmt.Sprintf("DROP TRIGGER %s", trigger))
+	}
+	statements = append(statements,
+		tempSQL,
+		fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", tempTable, table),
+		fmt.Sprintf("DROP TABLE %s", table),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", tempTable, table),
+	)
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to rebuild table '%s': %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rebuild of table '%s': %w", table, err)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package planner
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxLoggedOperations is the number of operations kept in the operations log.
+// Older entries are discarded when a new operation is logged.
+const maxLoggedOperations = 100
+
+// ErrNothingToUndo is returned by Undo when the operations log is empty.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// Operation describes an entry in the operations log.
+// This is returned by the Undo method.
+type Operation struct {
+	Name      string    `json:"name"`  // e.g. "remove-steps", "prune-steps", "remove", "compact", "reset", "renumber", "move" or "merge"
+	Plans     []string  `json:"plans"` // Names of the plans affected by the operation
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// planSnapshot is the state of a plan as recorded in the operations log.
+type planSnapshot struct {
+	ID         string         `json:"id"`
+	Archived   bool           `json:"archived"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Tags       []string       `json:"tags"`
+	OnComplete string         `json:"on_complete,omitempty"`
+	Recurrence string         `json:"recurrence,omitempty"`
+	History    []StatusChange `json:"history"` // Only recorded for removed plans, see addHistory; nil otherwise
+	Steps      []stepSnapshot `json:"steps"`
+}
+
+// stepSnapshot is the state of a step as recorded in the operations log.
+type stepSnapshot struct {
+	ID          string      `json:"id"`
+	Description string      `json:"description"`
+	Status      string      `json:"status"`
+	Acceptance  []string    `json:"acceptance"`
+	Met         []bool      `json:"met,omitempty"`
+	References  []Reference `json:"references"`
+	Estimate    int         `json:"estimate"`
+	Actual      int         `json:"actual"`
+	Blocked     string      `json:"blocked"`
+	CompletedAt time.Time   `json:"completed_at"`
+	Assignee    string      `json:"assignee"`
+}
+
+// loggedOperation is an operation waiting to be written to the operations log
+// in the same transaction as the change it records.
+type loggedOperation struct {
+	name  string
+	plans []planSnapshot
+}
+
+// snapshotPlans records the current state of the named plans, read from a
+// database or inside a transaction. Names that do not exist are skipped.
+// Like getMany, it fails for plans with more than maxSteps steps, unless
+// maxSteps is 0.
+func snapshotPlans(q querier, names []string, maxSteps int) ([]planSnapshot, error) {
+	plans, err := getMany(q, names, maxSteps)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []planSnapshot
+	for _, name := range names {
+		plan, ok := plans[name]
+		if !ok {
+			continue
+		}
+
+		snapshot := planSnapshot{ID: plan.ID, Tags: plan.tags, OnComplete: plan.onComplete, Recurrence: plan.recurrence, Steps: []stepSnapshot{}}
+		err := q.QueryRow("SELECT archived, created_at FROM plans WHERE id = ?", plan.ID).Scan(&snapshot.Archived, &snapshot.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query plan '%s': %w", plan.ID, err)
+		}
+		for _, step := range plan.Steps {
+			snapshot.Steps = append(snapshot.Steps, stepSnapshot{
+				ID:          step.id,
+				Description: step.description,
+				Status:      step.status,
+				Acceptance:  step.acceptance,
+				Met:         step.met,
+				References:  step.references,
+				Estimate:    step.estimate,
+				Actual:      step.actual,
+				Blocked:     step.blocked,
+				CompletedAt: step.completedAt,
+				Assignee:    step.assignee,
+			})
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// addHistory records the status history of each plan in snapshots, read from
+// a database or inside a transaction. It is used for plans that are about to
+// be removed, whose history is removed with them and restored by Undo.
+func addHistory(q querier, snapshots []planSnapshot) error {
+	for i := range snapshots {
+		history, err := queryHistory(q, snapshots[i].ID)
+		if err != nil {
+			return err
+		}
+		snapshots[i].History = history
+	}
+	return nil
+}
+
+// logOperation writes op to the operations log as part of tx and discards
+// entries beyond the newest maxLoggedOperations.
+func logOperation(tx *sql.Tx, op *loggedOperation) error {
+	snapshot, err := json.Marshal(op.plans)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot for operation '%s': %w", op.name, err)
+	}
+
+	_, err = tx.Exec("INSERT INTO operations_log (operation, snapshot) VALUES (?, ?)", op.name, string(snapshot))
+	if err != nil {
+		return fmt.Errorf("failed to log operation '%s': %w", op.name, err)
+	}
+
+	_, err = tx.Exec("DELETE FROM operations_log WHERE id <= (SELECT MAX(id) FROM operations_log) - ?", maxLoggedOperations)
+	if err != nil {
+		return fmt.Errorf("failed to prune operations log: %w", err)
+	}
+	return nil
+}
+
+// SaveLogged is like Save, but records the state of the plan before the
+// change in the operations log, so that the change can be reverted with Undo.
+// operation names the change, e.g. "remove-steps".
+func (p *Planner) SaveLogged(plan *Plan, operation string) error {
+	return p.saveOperation(context.Background(), plan, operation)
+}
+
+// Undo reverts the most recent operation in the operations log and removes
+// it from the log. Every plan affected by the operation is restored to the
+// state it had before the operation, including plans that were removed;
+// changes made to these plans since then are discarded.
+// It returns ErrNothingToUndo if no operation has been logged.
+// The transaction is retried with backoff while the database is busy.
+func (p *Planner) Undo() (Operation, error) {
+	var op Operation
+	err := withRetry(maxTransactionAttempts, func() error {
+		var err error
+		op, err = p.undo()
+		return err
+	})
+	return op, err
+}
+
+// undo runs a single attempt of Undo.
+func (p *Planner) undo() (Operation, error) {
+	var op Operation
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return op, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	var id int64
+	var encoded string
+	err = tx.QueryRow("SELECT id, operation, snapshot, created_at FROM operations_log ORDER BY id DESC LIMIT 1").Scan(&id, &op.Name, &encoded, &op.CreatedAt)
+	if err == sql.ErrNoRows {
+		return op, ErrNothingToUndo
+	}
+	if err != nil {
+		return op, fmt.Errorf("failed to query operations log: %w", err)
+	}
+
+	var snapshots []planSnapshot
+	if err := json.Unmarshal([]byte(encoded), &snapshots); err != nil {
+		return op, fmt.Errorf("failed to decode snapshot of operation '%s': %w", op.Name, err)
+	}
+
+	for _, snapshot := range snapshots {
+		if err := restorePlan(tx, snapshot); err != nil {
+			return op, err
+		}
+		op.Plans = append(op.Plans, snapshot.ID)
+	}
+
+	if _, err := tx.Exec("DELETE FROM operations_log WHERE id = ?", id); err != nil {
+		return op, fmt.Errorf("failed to remove operation '%s' from log: %w", op.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return op, fmt.Errorf("failed to commit undo of operation '%s': %w", op.Name, err)
+	}
+	return op, nil
+}
+
+// restorePlan replaces the plan with the given snapshot as part of tx,
+// recreating it if it no longer exists. The plan's version is incremented,
+// so copies of the plan loaded earlier can no longer be saved.
+// If the snapshot records a status history, which it does for removed plans,
+// it replaces the history of the plan; otherwise the history is kept.
+func restorePlan(tx *sql.Tx, snapshot planSnapshot) error {
+	var version int
+	err := tx.QueryRow("SELECT version FROM plans WHERE id = ?", snapshot.ID).Scan(&version)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to query plan '%s': %w", snapshot.ID, err)
+	}
+	if snapshot.History != nil {
+		if err := deleteHistory(tx, snapshot.ID); err != nil {
+			return err
+		}
+		for _, change := range snapshot.History {
+			_, err := tx.Exec("INSERT INTO step_status_history (plan_id, step_id, old_status, new_status, changed_at) VALUES (?, ?, ?, ?, ?)",
+				snapshot.ID, change.StepID, change.OldStatus, change.NewStatus, change.ChangedAt.UTC())
+			if err != nil {
+				return fmt.Errorf("failed to restore status history of plan '%s': %w", snapshot.ID, err)
+			}
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM plans WHERE id = ?", snapshot.ID); err != nil {
+		return fmt.Errorf("failed to delete plan '%s' before restoring it: %w", snapshot.ID, err)
+	}
+
+	_, err = tx.Exec("INSERT INTO plans (id, version, archived, created_at, on_complete, recurrence) VALUES (?, ?, ?, ?, ?, ?)",
+		snapshot.ID, version+1, snapshot.Archived, snapshot.CreatedAt.UTC().Format(time.DateTime), nullableString(snapshot.OnComplete), nullableString(snapshot.Recurrence))
+	if err != nil {
+		return fmt.Errorf("failed to restore plan '%s': %w", snapshot.ID, err)
+	}
+
+	for _, tag := range snapshot.Tags {
+		_, err = tx.Exec("INSERT INTO plan_tags (plan_id, tag) VALUES (?, ?)", snapshot.ID, tag)
+		if err != nil {
+			return fmt.Errorf("failed to restore tag '%s' for plan '%s': %w", tag, snapshot.ID, err)
+		}
+	}
+
+	for i, step := range snapshot.Steps {
+		_, err = tx.Exec("INSERT INTO steps (id, plan_id, description, status, step_order, estimate_minutes, actual_minutes, blocked_reason, completed_at, assignee) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			step.ID, snapshot.ID, step.Description, step.Status, i*stepOrderGap, step.Estimate, step.Actual, nullableString(step.Blocked), nullableTime(step.CompletedAt), nullableString(step.Assignee))
+		if err != nil {
+			return fmt.Errorf("failed to restore step '%s' in plan '%s': %w", step.ID, snapshot.ID, err)
+		}
+
+		if err := insertAcceptanceCriteria(tx, snapshot.ID, step.ID, step.Acceptance, step.Met); err != nil {
+			return err
+		}
+
+		for j, ref := range step.References {
+			_, err = tx.Exec("INSERT INTO step_references (plan_id, step_id, reference_order, reference_url, title) VALUES (?, ?, ?, ?, ?)",
+				snapshot.ID, step.ID, j, ref.URL, nullableString(ref.Title))
+			if err != nil {
+				return fmt.Errorf("failed to restore reference for step '%s' in plan '%s': %w", step.ID, snapshot.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -1,14 +1,26 @@
 package planner
 
 import (
+	"context"
 	"database/sql"
 	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
-	_ "github.com/mattn/go-sqlite3" // SQLite driver
+	sqlite3 "github.com/mattn/go-sqlite3" // SQLite driver
 )
 
 //go:embed schema.sql
@@ -16,55 +28,358 @@ var embeddedSchema []byte
 
 // Planner manages plans using a SQLite database.
 type Planner struct {
-	db *sql.DB
+	db         *sql.DB
+	sharedPath string // Set for planners created by NewShared
+	maxSteps   int    // Limit of steps loaded per plan, see Options.MaxSteps
 }
 
 // Plan represents a collection of steps.
 type Plan struct {
-	ID    string  `json:"id"` // Unique identifier for the plan, e.g., "active"
-	Steps []*Step `json:"steps"`
-	isNew bool    // Internal flag to indicate if the plan is new and not yet saved
+	ID      string   `json:"id"` // Unique identifier for the plan, e.g., "active"
+	Steps   []*Step  `json:"steps"`
+	tags    []string // Tags used to categorize the plan
+	version int      // Version of the plan when it was loaded, used to detect concurrent saves
+	isNew   bool     // Internal flag to indicate if the plan is new and not yet saved
+	summary bool     // Set by GetSummary; such plans lack criteria and references and cannot be saved
+
+	// onComplete is the shell command run when a change completes the plan,
+	// see SetOnComplete. Empty if none is set.
+	onComplete string
+
+	// recurrence is how often the plan is repeated, see SetRecurrence.
+	// Empty if the plan is not recurring.
+	recurrence string
+
+	// renamed maps the new IDs of steps renamed by Renumber since the plan
+	// was loaded to their stored IDs, so that Save can carry over their history.
+	renamed map[string]string
 }
 
+// ErrConcurrentModification is returned by Save when the plan was saved by
+// someone else after it was loaded.
+var ErrConcurrentModification = errors.New("plan was modified concurrently")
+
+// ErrDuplicateStepID is returned by Save when the plan contains more than one
+// step with the same ID, and by InsertStep when the plan already has the step.
+var ErrDuplicateStepID = errors.New("duplicate step ID")
+
+// ErrCriteriaNotMet is returned by MarkAsCompletedStrict when some acceptance
+// criteria of the step are not checked as met.
+var ErrCriteriaNotMet = errors.New("acceptance criteria not met")
+
+// ErrTooManySteps is returned by Get, GetSummary and the other methods that
+// load or import whole plans when a plan has more steps than the planner's
+// limit, see Options.MaxSteps.
+var ErrTooManySteps = errors.New("plan has too many steps")
+
+// DefaultMaxSteps is the number of steps Get loads at most when
+// Options.MaxSteps is not set.
+const DefaultMaxSteps = 10000
+
+// ErrPlanNotFound is wrapped by the errors of methods that need an existing
+// plan, such as Touch, Rename or ExportPlan, when no plan with the given name
+// exists. The doc comment of each such method says so.
+var ErrPlanNotFound = errors.New("plan not found")
+
 // PlanInfo holds summary information about a plan.
 // This is used by the List method.
 type PlanInfo struct {
-	Name           string `json:"name"`
-	Status         string `json:"status"` // "DONE" or "TODO"
-	TotalTasks     int    `json:"total_tasks"`
-	CompletedTasks int    `json:"completed_tasks"`
+	Name              string    `json:"name"`
+	Status            string    `json:"status"` // "DONE" or "TODO"
+	TotalTasks        int       `json:"total_tasks"`
+	CompletedTasks    int       `json:"completed_tasks"`
+	CompletionPercent float64   `json:"completion_percent"` // 0 to 100, 0 when the plan has no tasks
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	Archived          bool      `json:"archived"`
+	Recurrence        string    `json:"recurrence,omitempty"` // "daily" or "weekly", empty if the plan is not recurring
+}
+
+// Stats holds aggregate information about all plans in the database.
+// This is returned by the Stats method.
+type Stats struct {
+	TotalPlans          int     `json:"total_plans"`
+	CompletedPlans      int     `json:"completed_plans"` // Plans with at least one step, all "DONE"
+	TotalSteps          int     `json:"total_steps"`
+	DoneSteps           int     `json:"done_steps"`
+	TodoSteps           int     `json:"todo_steps"`
+	BlockedSteps        int     `json:"blocked_steps"`
+	AverageStepsPerPlan float64 `json:"average_steps_per_plan"`
+}
+
+// Reference points to a resource relevant for a step, such as a URL or a file path.
+type Reference struct {
+	Title string `json:"title,omitempty"` // Optional human-readable title
+	URL   string `json:"url"`
+}
+
+// ParseReference parses a reference written as "Title|URL" or as a bare URL.
+// Surrounding whitespace is trimmed from the title and the URL.
+func ParseReference(text string) Reference {
+	title, url, found := strings.Cut(text, "|")
+	if !found {
+		return Reference{URL: strings.TrimSpace(text)}
+	}
+	return Reference{Title: strings.TrimSpace(title), URL: strings.TrimSpace(url)}
+}
+
+// String renders the reference as a markdown link "[Title](URL)",
+// or as the bare URL if it has no title.
+func (r Reference) String() string {
+	if r.Title == "" {
+		return r.URL
+	}
+	return fmt.Sprintf("[%s](%s)", r.Title, r.URL)
+}
+
+// Validate checks that the reference looks like a URL or a local path.
+// URLs must parse with url.ParseRequestURI and have a scheme. Local paths
+// must not contain whitespace and must either start with "/", "./", "../"
+// or "~/", contain a path separator, or have a file extension, so that
+// "docs/spec.md" and "README.md" pass while "doc-1" does not.
+// References are freeform, so Validate is only used when asked for.
+func (r Reference) Validate() error {
+	if r.URL == "" {
+		return fmt.Errorf("reference must not be empty")
+	}
+	if u, err := url.ParseRequestURI(r.URL); err == nil && u.Scheme != "" {
+		return nil
+	}
+	if strings.Contains(r.URL, "://") {
+		return fmt.Errorf("reference '%s' is not a valid URL", r.URL)
+	}
+	if strings.ContainsFunc(r.URL, unicode.IsSpace) {
+		return fmt.Errorf("reference '%s' is neither a URL nor a local path", r.URL)
+	}
+	for _, prefix := range []string{"/", "./", "../", "~/"} {
+		if strings.HasPrefix(r.URL, prefix) {
+			return nil
+		}
+	}
+	if strings.ContainsRune(r.URL, '/') || filepath.Ext(r.URL) != "" {
+		return nil
+	}
+	return fmt.Errorf("reference '%s' is neither a URL nor a local path", r.URL)
 }
 
 // Step represents a single task in a plan.
 type Step struct {
-	id          string   `json:"id"` // Short identifier, e.g., "add-tests"
-	description string   `json:"description"`
-	status      string   `json:"status"` // "DONE" or "TODO"
-	acceptance  []string `json:"acceptance"`
-	references  []string `json:"references"`
-	stepOrder   int      // Internal field to keep track of order from DB
+	id          string      `json:"id"` // Short identifier, e.g., "add-tests"
+	description string      `json:"description"`
+	status      string      `json:"status"` // "DONE", "TODO" or "BLOCKED"
+	acceptance  []string    `json:"acceptance"`
+	met         []bool      // Whether each acceptance criterion is met, by index; missing entries are not met
+	references  []Reference `json:"references"`
+	estimate    int         // Estimated time in minutes, 0 if not estimated
+	actual      int         // Time actually spent in minutes
+	blocked     string      // Reason the step is blocked, empty unless status is "BLOCKED"
+	completedAt time.Time   // When the step was marked as done, zero if unknown or not done
+	assignee    string      // Who owns the step, empty if unassigned
+	stepOrder   int         // Position of the step in its plan as of the last Get or Save, see Order
+}
+
+// Options configures how a Planner talks to its database.
+type Options struct {
+	// Logger receives every SQL statement executed by the planner together
+	// with its arguments. When nil, nothing is logged.
+	Logger *log.Logger
+
+	// ReadOnly restricts the manage_plan tool made by
+	// MakePlannerToolHandlerWithOptions to the actions that do not change
+	// the database, see readOnlyActions. The Planner itself is not restricted.
+	ReadOnly bool
+
+	// MaxSteps is the number of steps of a plan that Get, GetSummary,
+	// GetMany, ListAssigned, ListSteps, ExportPlan and their Tx counterparts
+	// load at most, and that ImportPlan and InsertStep accept. Plans with more steps are
+	// rejected with ErrTooManySteps instead of being loaded, so that a
+	// pathological plan cannot exhaust memory. The snapshots recorded for
+	// Undo are not limited, so that such plans can still be saved or removed.
+	// Zero means DefaultMaxSteps and a negative value disables the limit.
+	MaxSteps int
+}
+
+// maxSteps returns the limit of steps to load for opts.MaxSteps, where 0
+// means no limit.
+func (opts Options) maxSteps() int {
+	switch {
+	case opts.MaxSteps == 0:
+		return DefaultMaxSteps
+	case opts.MaxSteps < 0:
+		return 0
+	}
+	return opts.MaxSteps
 }
 
 // New creates a new Planner instance connected to a SQLite database.
 // It ensures the database and necessary tables are initialized.
 // databasePath specifies the path to the SQLite database file.
+// Passing ":memory:" opens a fresh in-memory database for every call, which is
+// discarded when the planner is closed; "file::memory:?cache=shared" shares one
+// in-memory database between all planners in the process.
 func New(databasePath string) (*Planner, error) {
-	// Ensure the directory for the database file exists.
-	dbDir := filepath.Dir(databasePath)
-	if err := os.MkdirAll(dbDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create directory for database %s: %w", dbDir, err)
+	return NewWithOptions(databasePath, Options{})
+}
+
+// NewWithOptions is like New but allows configuring the planner through opts.
+func NewWithOptions(databasePath string, opts Options) (*Planner, error) {
+	db, err := openDatabase(databasePath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Planner{
+		db:       db,
+		maxSteps: opts.maxSteps(),
+	}, nil
+}
+
+// NewReadOnly opens the existing SQLite database at databasePath for reading
+// only. Unlike New, it neither creates the database nor runs the schema or
+// migrations, so the file is left exactly as it is; databases last written by
+// an older version may therefore lack columns that listing plans needs.
+// Every method that changes the database fails on the returned planner.
+func NewReadOnly(databasePath string, opts Options) (*Planner, error) {
+	if _, err := os.Stat(databasePath); err != nil {
+		return nil, fmt.Errorf("cannot open database %s: %w", databasePath, err)
 	}
 
-	db, err := sql.Open("sqlite3", databasePath)
+	// Open the file through a URI with an absolute path, so that mode=ro
+	// applies and characters like "?" in the path are escaped
+	absPath, err := filepath.Abs(databasePath)
 	if err != nil {
+		return nil, fmt.Errorf("cannot open database %s: %w", databasePath, err)
+	}
+	uriPath := filepath.ToSlash(absPath)
+	if !strings.HasPrefix(uriPath, "/") {
+		uriPath = "/" + uriPath // Windows paths like C:/db start with a drive letter
+	}
+	// Like connectionPragmas, but without changing the journal mode, which
+	// would write to the database
+	query := "mode=ro&_foreign_keys=on&_busy_timeout=5000"
+	dsn := (&url.URL{Scheme: "file", Path: uriPath, RawQuery: query}).String()
+	var db *sql.DB
+	if opts.Logger != nil {
+		db = sql.OpenDB(&loggingConnector{
+			dsn:    dsn,
+			driver: &sqlite3.SQLiteDriver{},
+			logger: opts.Logger,
+		})
+	} else {
+		db, err = sql.Open("sqlite3", dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database at %s: %w", databasePath, err)
+		}
+	}
+
+	if err = db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to open database at %s: %w", databasePath, err)
 	}
 
-	// Enable foreign key constraints
-	_, err = db.Exec("PRAGMA foreign_keys = ON;")
-	if err != nil {
-		db.Close() // Close the DB if PRAGMA fails
-		return nil, fmt.Errorf("failed to enable foreign key constraints: %w", err)
+	return &Planner{
+		db:       db,
+		maxSteps: opts.maxSteps(),
+	}, nil
+}
+
+// NewShared returns a Planner backed by a connection that is shared with all
+// other shared planners for the same databasePath within this process.
+// The database is opened and the schema is executed only the first time a
+// path is requested; later calls reuse the cached connection.
+// The underlying connection is closed once every shared planner for the path
+// has been closed.
+func NewShared(databasePath string) (*Planner, error) {
+	return NewSharedWithOptions(databasePath, Options{})
+}
+
+// NewSharedWithOptions is like NewShared but configures the connection
+// through opts. Options that configure the connection, like Logger, only
+// take effect when the connection for databasePath is first opened; MaxSteps
+// applies to every planner.
+func NewSharedWithOptions(databasePath string, opts Options) (*Planner, error) {
+	sharedConnectionsMu.Lock()
+	defer sharedConnectionsMu.Unlock()
+
+	conn, ok := sharedConnections[databasePath]
+	if !ok {
+		db, err := openDatabase(databasePath, opts)
+		if err != nil {
+			return nil, err
+		}
+		conn = &sharedConnection{db: db}
+		sharedConnections[databasePath] = conn
+	}
+	conn.refs++
+
+	return &Planner{
+		db:         conn.db,
+		sharedPath: databasePath,
+		maxSteps:   opts.maxSteps(),
+	}, nil
+}
+
+// sharedConnection is a reference-counted database connection used by NewShared.
+type sharedConnection struct {
+	db   *sql.DB
+	refs int
+}
+
+var (
+	sharedConnectionsMu sync.Mutex
+	sharedConnections   = make(map[string]*sharedConnection)
+)
+
+// isInMemory reports whether databasePath names an in-memory SQLite database,
+// either ":memory:" or a URI such as "file::memory:?cache=shared".
+func isInMemory(databasePath string) bool {
+	return databasePath == ":memory:" ||
+		strings.HasPrefix(databasePath, "file::memory:") ||
+		(strings.HasPrefix(databasePath, "file:") && strings.Contains(databasePath, "mode=memory"))
+}
+
+// openDatabase opens the SQLite database at databasePath, creating its
+// directory if necessary, and initializes the schema.
+// In-memory databases (see isInMemory) are opened without touching the file system.
+// If opts.Logger is set, all statements run on the database are logged to it.
+func openDatabase(databasePath string, opts Options) (*sql.DB, error) {
+	inMemory := isInMemory(databasePath)
+	if !inMemory {
+		// Ensure the directory for the database file exists.
+		dbDir := filepath.Dir(databasePath)
+		if err := os.MkdirAll(dbDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for database %s: %w", dbDir, err)
+		}
+	}
+
+	dsn := connectionDSN(databasePath)
+	var db *sql.DB
+	var err error
+	if opts.Logger != nil {
+		db = sql.OpenDB(&loggingConnector{
+			dsn:    dsn,
+			driver: &sqlite3.SQLiteDriver{},
+			logger: opts.Logger,
+		})
+	} else {
+		db, err = sql.Open("sqlite3", dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database at %s: %w", databasePath, err)
+		}
+	}
+
+	// Every connection to ":memory:" opens a separate, empty database,
+	// so all statements have to go through a single connection.
+	if inMemory {
+		db.SetMaxOpenConns(1)
+	}
+
+	// The driver enables foreign keys, WAL and the busy timeout for every
+	// connection it opens (see connectionDSN). Connect once so that a
+	// database that cannot be opened is reported here.
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database at %s: %w", databasePath, err)
 	}
 
 	// Use embedded schema
@@ -77,16 +392,87 @@ func New(databasePath string) (*Planner, error) {
 		return nil, fmt.Errorf("failed to execute schema: %w", err)
 	}
 
-	return &Planner{
-		db: db,
-	}, nil
+	// Add columns introduced after a table was first created
+	if err := migrateColumns(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	// Rebuild tables whose constraints changed after they were first created
+	if err := migrateConstraints(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	return db, nil
+}
+
+// connectionPragmas are the settings every connection to a database needs,
+// as parameters understood by the SQLite driver: foreign keys are enforced,
+// so that ON DELETE CASCADE removes dependent rows; write-ahead logging lets
+// readers and a writer work concurrently; and connections wait for locks
+// held by others instead of failing immediately. PRAGMAs only apply to the
+// connection running them, so passing them to the driver is the only way to
+// cover every connection of the database/sql pool.
+const connectionPragmas = "_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL"
+
+// connectionDSN returns the data source name for databasePath with
+// connectionPragmas added to any parameters it already has.
+func connectionDSN(databasePath string) string {
+	if strings.Contains(databasePath, "?") {
+		return databasePath + "&" + connectionPragmas
+	}
+	return databasePath + "?" + connectionPragmas
 }
 
 // Close closes the database connection.
 // It is the caller's responsibility to close the planner when done.
+// Changes still in the write-ahead log stay there until SQLite checkpoints
+// them; long-running users like the MCP server call Checkpoint first.
+// Calling Close more than once is safe. For planners obtained through
+// NewShared, the connection is only closed when the last user releases it.
 func (p *Planner) Close() error {
-	if p.db != nil {
-		return p.db.Close()
+	if p.db == nil {
+		return nil
+	}
+	db := p.db
+	p.db = nil
+
+	if p.sharedPath == "" {
+		return closeDatabase(db)
+	}
+
+	sharedConnectionsMu.Lock()
+	defer sharedConnectionsMu.Unlock()
+
+	conn, ok := sharedConnections[p.sharedPath]
+	if !ok || conn.db != db {
+		return nil // Connection was already released
+	}
+	conn.refs--
+	if conn.refs > 0 {
+		return nil
+	}
+	delete(sharedConnections, p.sharedPath)
+	return closeDatabase(db)
+}
+
+// closeDatabase closes db.
+func closeDatabase(db *sql.DB) error {
+	if err := db.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+	return nil
+}
+
+// Checkpoint moves the contents of the write-ahead log into the database
+// file and truncates the log, so that no changes are left behind in the
+// -wal file. This is not needed for correctness, but keeps the database a
+// single file, e.g. before the MCP server exits. Since it waits for other
+// connections to finish writing, it is not done by every Close.
+func (p *Planner) Checkpoint() error {
+	if _, err := p.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint database: %w", err)
 	}
 	return nil
 }
@@ -105,14 +491,98 @@ func (p *Planner) Create(name string) (*Plan, error) {
 	return &Plan{
 		ID:    name,
 		Steps: []*Step{},
+		tags:  []string{},
 		isNew: true, // Mark as new
 	}, nil
 }
 
-// Get retrieves a plan and its steps from the database.
-func (p *Planner) Get(name string) (*Plan, error) {
+// Exists reports whether a plan with the given name is stored in the database,
+// including archived plans. Unlike a failing Get, an error always means that
+// the database could not be queried.
+func (p *Planner) Exists(name string) (bool, error) {
+	return planExists(p.db, name)
+}
+
+// rowQuerier is implemented by both *sql.DB and *sql.Tx.
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	rowQuerier
+	Query(query string, args ...any) (*sql.Rows, error)
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// contextQuerier is implemented by both *sql.DB and *sql.Tx.
+type contextQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// ctxQuerier is a querier that runs its queries with ctx, so that helpers
+// written against querier stop once ctx is cancelled.
+type ctxQuerier struct {
+	ctx context.Context
+	q   contextQuerier
+}
+
+// withContext returns a querier running the queries of q with ctx.
+func withContext(ctx context.Context, q contextQuerier) querier {
+	return ctxQuerier{ctx: ctx, q: q}
+}
+
+func (c ctxQuerier) QueryRow(query string, args ...any) *sql.Row {
+	return c.q.QueryRowContext(c.ctx, query, args...)
+}
+
+func (c ctxQuerier) Query(query string, args ...any) (*sql.Rows, error) {
+	return c.q.QueryContext(c.ctx, query, args...)
+}
+
+func (c ctxQuerier) Exec(query string, args ...any) (sql.Result, error) {
+	return c.q.ExecContext(c.ctx, query, args...)
+}
+
+// planExists implements Exists on a database or inside a transaction.
+func planExists(q rowQuerier, name string) (bool, error) {
+	var one int
+	err := q.QueryRow("SELECT 1 FROM plans WHERE id = ?", name).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check whether plan '%s' exists: %w", name, err)
+	}
+	return true, nil
+}
+
+// GetSummary retrieves a plan, its tags and its steps without the steps'
+// acceptance criteria and references, which takes three queries (the plan,
+// its tags and its steps) instead of two more per step. It is meant for
+// callers that only look at the steps' IDs, descriptions and statuses; the
+// returned plan cannot be saved, since saving it would remove the criteria
+// and references it lacks.
+func (p *Planner) GetSummary(name string) (*Plan, error) {
+	plan, err := getSummary(p.db, name, p.maxSteps)
+	if err != nil {
+		return nil, err
+	}
+	plan.summary = true
+	return plan, nil
+}
+
+// getSummary implements GetSummary on a database or inside a transaction
+// and loads everything getPlan needs except the acceptance criteria and references.
+// It returns an error wrapping ErrTooManySteps if the plan has more than
+// maxSteps steps, unless maxSteps is 0.
+func getSummary(q querier, name string, maxSteps int) (*Plan, error) {
 	var planID string
-	err := p.db.QueryRow("SELECT id FROM plans WHERE id = ?", name).Scan(&planID)
+	var version int
+	var onComplete, recurrence sql.NullString
+	err := q.QueryRow("SELECT id, version, on_complete, recurrence FROM plans WHERE id = ?", name).Scan(&planID, &version, &onComplete, &recurrence)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("plan with name '%s' not found", name)
@@ -121,39 +591,95 @@ func (p *Planner) Get(name string) (*Plan, error) {
 	}
 
 	plan := &Plan{
-		ID:    planID,
-		Steps: []*Step{},
-		isNew: false, // Explicitly set isNew to false for a plan loaded from DB
+		ID:         planID,
+		Steps:      []*Step{},
+		tags:       []string{},
+		version:    version,
+		isNew:      false, // Explicitly set isNew to false for a plan loaded from DB
+		onComplete: onComplete.String,
+		recurrence: recurrence.String,
 	}
 
-	rows, err := p.db.Query("SELECT id, description, status, step_order FROM steps WHERE plan_id = ? ORDER BY step_order ASC", planID)
+	tagRows, err := q.Query("SELECT tag FROM plan_tags WHERE plan_id = ? ORDER BY tag ASC", planID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags for plan '%s': %w", name, err)
+	}
+	for tagRows.Next() {
+		var tag string
+		if err := tagRows.Scan(&tag); err != nil {
+			tagRows.Close()
+			return nil, fmt.Errorf("failed to scan tag for plan '%s': %w", name, err)
+		}
+		plan.tags = append(plan.tags, tag)
+	}
+	if err = tagRows.Err(); err != nil {
+		tagRows.Close()
+		return nil, fmt.Errorf("error iterating tags for plan '%s': %w", name, err)
+	}
+	tagRows.Close()
+
+	rows, err := q.Query("SELECT id, description, status, estimate_minutes, actual_minutes, blocked_reason, completed_at, assignee FROM steps WHERE plan_id = ? ORDER BY step_order ASC", planID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query steps for plan '%s': %w", name, err)
 	}
 	defer rows.Close()
 
-	// Use a map to temporarily store steps by ID for efficient lookup when adding acceptance criteria
-	stepsByID := make(map[string]*Step)
-
 	for rows.Next() {
+		// Stop before reading the rest of the steps, let alone their criteria and references
+		if maxSteps > 0 && len(plan.Steps) == maxSteps {
+			return nil, fmt.Errorf("plan '%s' has more than %d steps: %w", name, maxSteps, ErrTooManySteps)
+		}
 		step := &Step{}
-		err := rows.Scan(&step.id, &step.description, &step.status, &step.stepOrder)
+		var estimate, actual sql.NullInt64 // Steps created before time tracking have NULL minutes
+		var blockedReason sql.NullString
+		var completedAt sql.NullTime // NULL unless the step was completed after completion times were introduced
+		var assignee sql.NullString
+		err := rows.Scan(&step.id, &step.description, &step.status, &estimate, &actual, &blockedReason, &completedAt, &assignee)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan step for plan '%s': %w", name, err)
 		}
-		step.acceptance = []string{} // Initialize acceptance criteria slice
-		step.references = []string{} // Initialize references slice
+		step.stepOrder = len(plan.Steps) // Stored orders may have gaps, see stepOrderGap
+		step.estimate = int(estimate.Int64)
+		step.actual = int(actual.Int64)
+		step.blocked = blockedReason.String
+		step.completedAt = completedAt.Time
+		step.assignee = assignee.String
+		step.acceptance = []string{}    // Initialize acceptance criteria slice
+		step.references = []Reference{} // Initialize references slice
 		plan.Steps = append(plan.Steps, step)
-		stepsByID[step.id] = step // Store step by ID for later lookup
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating steps for plan '%s': %w", name, err)
 	}
 
+	return plan, nil
+}
+
+// Get retrieves a plan and its steps from the database.
+// It returns an error wrapping ErrTooManySteps if the plan has more steps
+// than allowed by Options.MaxSteps.
+func (p *Planner) Get(name string) (*Plan, error) {
+	return p.GetCtx(context.Background(), name)
+}
+
+// GetCtx is like Get, but stops loading the plan once ctx is cancelled and
+// returns an error wrapping ctx's error.
+func (p *Planner) GetCtx(ctx context.Context, name string) (*Plan, error) {
+	return getPlan(withContext(ctx, p.db), name, p.maxSteps)
+}
+
+// getPlan implements Get on a database or inside a transaction.
+func getPlan(q querier, name string, maxSteps int) (*Plan, error) {
+	plan, err := getSummary(q, name, maxSteps)
+	if err != nil {
+		return nil, err
+	}
+	planID := plan.ID
+
 	// Now, fetch acceptance criteria and references for each step
 	// Iterate over the plan.Steps to maintain the order from the database query
 	for _, step := range plan.Steps {
-		acRows, err := p.db.Query("SELECT criterion FROM step_acceptance_criteria WHERE step_id = ? AND plan_id = ? ORDER BY criterion_order ASC", step.id, planID)
+		acRows, err := q.Query("SELECT criterion, met FROM step_acceptance_criteria WHERE step_id = ? AND plan_id = ? ORDER BY criterion_order ASC", step.id, planID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to query acceptance criteria for step '%s' in plan '%s': %w", step.id, name, err)
 		}
@@ -162,12 +688,14 @@ func (p *Planner) Get(name string) (*Plan, error) {
 
 		for acRows.Next() {
 			var acDescription string
-			err := acRows.Scan(&acDescription)
+			var met bool
+			err := acRows.Scan(&acDescription, &met)
 			if err != nil {
 				acRows.Close() // Ensure closure on error
 				return nil, fmt.Errorf("failed to scan acceptance criterion for step '%s' in plan '%s': %w", step.id, name, err)
 			}
 			step.acceptance = append(step.acceptance, acDescription)
+			step.met = append(step.met, met)
 		}
 		if err = acRows.Err(); err != nil {
 			acRows.Close() // Ensure closure on error
@@ -176,19 +704,21 @@ func (p *Planner) Get(name string) (*Plan, error) {
 		acRows.Close() // Close after successful iteration
 
 		// Fetch references for this step
-		refRows, err := p.db.Query("SELECT reference_url FROM step_references WHERE step_id = ? AND plan_id = ? ORDER BY reference_order ASC", step.id, planID)
+		refRows, err := q.Query("SELECT reference_url, title FROM step_references WHERE step_id = ? AND plan_id = ? ORDER BY reference_order ASC", step.id, planID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to query references for step '%s' in plan '%s': %w", step.id, name, err)
 		}
 
 		for refRows.Next() {
-			var refText string
-			err := refRows.Scan(&refText)
+			var ref Reference
+			var title sql.NullString // NULL for references saved without a title
+			err := refRows.Scan(&ref.URL, &title)
 			if err != nil {
 				refRows.Close() // Ensure closure on error
 				return nil, fmt.Errorf("failed to scan reference for step '%s' in plan '%s': %w", step.id, name, err)
 			}
-			step.references = append(step.references, refText)
+			ref.Title = title.String
+			step.references = append(step.references, ref)
 		}
 		if err = refRows.Err(); err != nil {
 			refRows.Close() // Ensure closure on error
@@ -200,111 +730,937 @@ func (p *Planner) Get(name string) (*Plan, error) {
 	return plan, nil
 }
 
-func (pl *Plan) Inspect() string {
-	var builder strings.Builder
+// GetMany retrieves several plans at once, keyed by name.
+// Unlike calling Get in a loop, the number of queries does not depend on the
+// number of plans or steps: plans, tags, steps, acceptance criteria and
+// references are each loaded with a single query.
+// Names that do not exist are absent from the result rather than causing an error.
+// Like Get, it returns an error wrapping ErrTooManySteps if one of the plans
+// has more steps than the planner's limit, see Options.MaxSteps.
+func (p *Planner) GetMany(names []string) (map[string]*Plan, error) {
+	return getMany(p.db, names, p.maxSteps)
+}
 
-	// Maybe add a title for the plan itself?
-	// builder.WriteString(fmt.Sprintf("# Plan: %s\n\n", pl.ID))
+// getMany implements GetMany on a database or inside a transaction.
+// It returns an error wrapping ErrTooManySteps if a plan has more than
+// maxSteps steps, unless maxSteps is 0.
+func getMany(q querier, names []string, maxSteps int) (map[string]*Plan, error) {
+	plans := make(map[string]*Plan)
+	if len(names) == 0 {
+		return plans, nil
+	}
 
-	for i, step := range pl.Steps {
-		// Headline: includes step number, status, and ID.
-		header := fmt.Sprintf("## %d. [%s] %s\n", i+1, strings.ToUpper(step.status), step.id) // Use fields
-		builder.WriteString(header)
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+	inClause := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ") + ")"
 
-		// Description paragraph (if not empty)
-		if step.description != "" {
-			builder.WriteString("\n" + step.description + "\n") // Add blank lines around description
+	planRows, err := q.Query("SELECT id, version, on_complete, recurrence FROM plans WHERE id IN "+inClause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query plans: %w", err)
+	}
+	for planRows.Next() {
+		plan := &Plan{Steps: []*Step{}, tags: []string{}}
+		var onComplete, recurrence sql.NullString
+		if err := planRows.Scan(&plan.ID, &plan.version, &onComplete, &recurrence); err != nil {
+			planRows.Close()
+			return nil, fmt.Errorf("failed to scan plan: %w", err)
 		}
-		builder.WriteString("\n") // Ensure a blank line after header or description
+		plan.onComplete = onComplete.String
+		plan.recurrence = recurrence.String
+		plans[plan.ID] = plan
+	}
+	if err = planRows.Err(); err != nil {
+		planRows.Close()
+		return nil, fmt.Errorf("error iterating plans: %w", err)
+	}
+	planRows.Close()
 
-		// Acceptance criteria numbered list
-		if len(step.acceptance) > 0 { // Use field
-			builder.WriteString("Acceptance Criteria:\n")
-			for j, criterion := range step.acceptance { // Use field
-				builder.WriteString(fmt.Sprintf("%d. %s\n", j+1, criterion))
-			}
-			builder.WriteString("\n") // Add a newline after the list
+	tagRows, err := q.Query("SELECT plan_id, tag FROM plan_tags WHERE plan_id IN "+inClause+" ORDER BY plan_id, tag ASC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	for tagRows.Next() {
+		var planID, tag string
+		if err := tagRows.Scan(&planID, &tag); err != nil {
+			tagRows.Close()
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
 		}
+		plans[planID].tags = append(plans[planID].tags, tag)
+	}
+	if err = tagRows.Err(); err != nil {
+		tagRows.Close()
+		return nil, fmt.Errorf("error iterating tags: %w", err)
+	}
+	tagRows.Close()
 
-		// References numbered list
-		if len(step.references) > 0 { // Use field
-			builder.WriteString("References:\n")
-			for j, reference := range step.references { // Use field
-				builder.WriteString(fmt.Sprintf("%d. %s\n", j+1, reference))
-			}
-			builder.WriteString("\n") // Add a newline after the list
+	// Steps by plan ID and step ID, for attaching criteria and references
+	stepsByID := make(map[string]map[string]*Step, len(plans))
+	for planID := range plans {
+		stepsByID[planID] = make(map[string]*Step)
+	}
+
+	stepRows, err := q.Query("SELECT plan_id, id, description, status, estimate_minutes, actual_minutes, blocked_reason, completed_at, assignee FROM steps WHERE plan_id IN "+inClause+" ORDER BY plan_id, step_order ASC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query steps: %w", err)
+	}
+	for stepRows.Next() {
+		var planID string
+		step := &Step{acceptance: []string{}, references: []Reference{}}
+		var estimate, actual sql.NullInt64 // Steps created before time tracking have NULL minutes
+		var blockedReason sql.NullString
+		var completedAt sql.NullTime
+		var assignee sql.NullString
+		if err := stepRows.Scan(&planID, &step.id, &step.description, &step.status, &estimate, &actual, &blockedReason, &completedAt, &assignee); err != nil {
+			stepRows.Close()
+			return nil, fmt.Errorf("failed to scan step: %w", err)
+		}
+		if maxSteps > 0 && len(plans[planID].Steps) == maxSteps {
+			stepRows.Close()
+			return nil, fmt.Errorf("plan '%s' has more than %d steps: %w", planID, maxSteps, ErrTooManySteps)
 		}
+		step.stepOrder = len(plans[planID].Steps) // Stored orders may have gaps, see stepOrderGap
+		step.estimate = int(estimate.Int64)
+		step.actual = int(actual.Int64)
+		step.blocked = blockedReason.String
+		step.completedAt = completedAt.Time
+		step.assignee = assignee.String
+		plans[planID].Steps = append(plans[planID].Steps, step)
+		stepsByID[planID][step.id] = step
 	}
+	if err = stepRows.Err(); err != nil {
+		stepRows.Close()
+		return nil, fmt.Errorf("error iterating steps: %w", err)
+	}
+	stepRows.Close()
 
-	return builder.String()
-}
+	acRows, err := q.Query("SELECT plan_id, step_id, criterion, met FROM step_acceptance_criteria WHERE plan_id IN "+inClause+" ORDER BY plan_id, step_id, criterion_order ASC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query acceptance criteria: %w", err)
+	}
+	for acRows.Next() {
+		var planID, stepID, criterion string
+		var met bool
+		if err := acRows.Scan(&planID, &stepID, &criterion, &met); err != nil {
+			acRows.Close()
+			return nil, fmt.Errorf("failed to scan acceptance criterion: %w", err)
+		}
+		if step, ok := stepsByID[planID][stepID]; ok {
+			step.acceptance = append(step.acceptance, criterion)
+			step.met = append(step.met, met)
+		}
+	}
+	if err = acRows.Err(); err != nil {
+		acRows.Close()
+		return nil, fmt.Errorf("error iterating acceptance criteria: %w", err)
+	}
+	acRows.Close()
 
-// NextStep returns the first step in the plan that is not marked as "DONE".
-// It returns nil if all steps are completed.
-func (pl *Plan) NextStep() *Step {
-	for _, step := range pl.Steps {
-		// Case-insensitive comparison just in case
-		if strings.ToUpper(step.status) != "DONE" { // Use field
-			return step
+	refRows, err := q.Query("SELECT plan_id, step_id, reference_url, title FROM step_references WHERE plan_id IN "+inClause+" ORDER BY plan_id, step_id, reference_order ASC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query references: %w", err)
+	}
+	for refRows.Next() {
+		var planID, stepID string
+		var ref Reference
+		var title sql.NullString // NULL for references saved without a title
+		if err := refRows.Scan(&planID, &stepID, &ref.URL, &title); err != nil {
+			refRows.Close()
+			return nil, fmt.Errorf("failed to scan reference: %w", err)
+		}
+		ref.Title = title.String
+		if step, ok := stepsByID[planID][stepID]; ok {
+			step.references = append(step.references, ref)
 		}
 	}
-	return nil // All steps are done
-}
+	if err = refRows.Err(); err != nil {
+		refRows.Close()
+		return nil, fmt.Errorf("error iterating references: %w", err)
+	}
+	refRows.Close()
 
-// ID returns the short identifier of the step.
-func (step *Step) ID() string {
-	return step.id
+	return plans, nil
 }
 
-// Status returns the current status of the step ("DONE" or "TODO").
-func (step *Step) Status() string {
-	// Ensure status is always returned in uppercase as per requirement.
-	return strings.ToUpper(step.status)
-}
+// Clone copies the plan named source into a new plan named dest and saves it.
+// All steps are copied in order, including their descriptions, acceptance
+// criteria, references and estimates, but every step's status is reset to
+// "TODO" and no actual time is carried over.
+// It returns an error if source does not exist or dest already exists.
+// The plan is read and copied in a single transaction, see WithTx.
+func (p *Planner) Clone(source, dest string) (*Plan, error) {
+	var destPlan *Plan
+	err := p.WithTx(func(tx *Tx) error {
+		sourcePlan, err := tx.Get(source)
+		if err != nil {
+			return err
+		}
 
-// Description returns the text description of the step.
-func (step *Step) Description() string {
-	return step.description
+		exists, err := tx.Exists(dest)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("plan with name '%s' already exists", dest)
+		}
+
+		destPlan, err = p.Create(dest)
+		if err != nil {
+			return err
+		}
+
+		for _, step := range sourcePlan.Steps {
+			acceptance := append([]string{}, step.acceptance...)
+			references := append([]Reference{}, step.references...)
+			destPlan.AddStep(step.id, step.description, acceptance, references)
+			destPlan.Steps[len(destPlan.Steps)-1].estimate = step.estimate
+		}
+
+		return tx.Save(destPlan)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return destPlan, nil
 }
 
-// AcceptanceCriteria returns the list of acceptance criteria for the step.
-func (step *Step) AcceptanceCriteria() []string {
-	// Return a copy to prevent modification of the internal slice? No, requirement is just to return.
-	return step.acceptance
+// MergeOptions configures how Planner.MergeWithOptions combines two plans.
+type MergeOptions struct {
+	// RenameConflicts gives steps whose ID already exists in the destination
+	// plan a numeric suffix ("-2", "-3", ...) instead of failing the merge.
+	RenameConflicts bool
+
+	// RemoveSource deletes the source plan after its steps have been merged.
+	RemoveSource bool
 }
 
-// References returns the list of references for the step.
-func (step *Step) References() []string {
-	return step.references
+// Merge appends all steps of the plan named src to the end of the plan named
+// dest and saves it. It is equivalent to MergeWithOptions with no options set.
+func (p *Planner) Merge(dest, src string) error {
+	return p.MergeWithOptions(dest, src, MergeOptions{})
 }
 
-// MarkAsCompleted sets the status of the step with the given stepID to "DONE" in-memory.
-// It returns an error if the step is not found.
-func (pl *Plan) MarkAsCompleted(stepID string) error {
-	for _, step := range pl.Steps {
-		if step.id == stepID {
-			step.status = "DONE"
-			return nil
-		}
+// MergeWithOptions appends all steps of the plan named src to the end of the
+// plan named dest, in order, and saves it. Unlike Clone, the steps keep their
+// status, blocker, completion time, acceptance criteria, references, estimate
+// and actual time.
+// A step whose ID already exists in dest is an error unless
+// opts.RenameConflicts is set. The source plan is left untouched unless
+// opts.RemoveSource is set, in which case the merge is recorded in the
+// operations log as "merge", with the destination plan as it was before and
+// the removed source plan, so that Undo reverts both plans together.
+// Everything happens in a single transaction, see WithTx: if any part of the
+// merge fails, neither plan is changed.
+func (p *Planner) MergeWithOptions(dest, src string, opts MergeOptions) error {
+	if dest == src {
+		return fmt.Errorf("cannot merge plan '%s' into itself", src)
 	}
-	return fmt.Errorf("step with ID '%s' not found in plan '%s'", stepID, pl.ID)
-}
 
-// MarkAsIncomplete sets the status of the step with the given stepID to "TODO" in-memory.
-// It returns an error if the step is not found.
-func (pl *Plan) MarkAsIncomplete(stepID string) error {
-	for _, step := range pl.Steps {
-		if step.id == stepID {
-			step.status = "TODO"
-			return nil
+	return p.WithTx(func(tx *Tx) error {
+		destPlan, err := tx.Get(dest)
+		if err != nil {
+			return err
+		}
+		srcPlan, err := tx.Get(src)
+		if err != nil {
+			return err
 		}
-	}
-	return fmt.Errorf("step with ID '%s' not found in plan '%s'", stepID, pl.ID)
-}
+
+		taken := make(map[string]bool, len(destPlan.Steps)+len(srcPlan.Steps))
+		for _, step := range destPlan.Steps {
+			taken[step.id] = true
+		}
+
+		for _, step := range srcPlan.Steps {
+			merged := *step
+			merged.acceptance = append([]string{}, step.acceptance...)
+			merged.met = append([]bool{}, step.met...)
+			merged.references = append([]Reference{}, step.references...)
+
+			if taken[merged.id] {
+				if !opts.RenameConflicts {
+					return fmt.Errorf("cannot merge plan '%s' into '%s': step '%s': %w", src, dest, step.id, ErrDuplicateStepID)
+				}
+				for n := 2; taken[merged.id]; n++ {
+					merged.id = fmt.Sprintf("%s-%d", step.id, n)
+				}
+			}
+			taken[merged.id] = true
+
+			destPlan.Steps = append(destPlan.Steps, &merged)
+		}
+
+		if !opts.RemoveSource {
+			return tx.Save(destPlan)
+		}
+
+		// Record the destination before it changes, so that Undo takes the
+		// merged steps out of it again when it restores the source
+		destSnapshots, err := snapshotPlans(tx.tx, []string{dest}, 0)
+		if err != nil {
+			return fmt.Errorf("failed to record plan '%s' for merge: %w", dest, err)
+		}
+		if err := tx.Save(destPlan); err != nil {
+			return err
+		}
+		srcSnapshots, err := tx.remove([]string{src})
+		if err != nil {
+			return fmt.Errorf("cannot merge plan '%s' into '%s': failed to remove it: %w", src, dest, err)
+		}
+		return logOperation(tx.tx, &loggedOperation{name: "merge", plans: append(destSnapshots, srcSnapshots...)})
+	})
+}
+
+// criterionCheckbox returns the checkbox shown by Inspect in front of an
+// acceptance criterion: "[x]" if it is met and "[ ]" otherwise.
+func criterionCheckbox(met bool) string {
+	if met {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// Inspect returns the plan formatted for display, as written by WriteInspect.
+func (pl *Plan) Inspect() string {
+	return pl.InspectFiltered(allSteps)
+}
+
+// InspectFiltered is like Inspect, but only shows the steps for which pred
+// returns true. The shown steps are numbered from 1 in the order of the
+// plan, and the total time only counts them.
+func (pl *Plan) InspectFiltered(pred func(*Step) bool) string {
+	var builder strings.Builder
+	pl.WriteInspectFiltered(&builder, pred) // Writing to a strings.Builder never fails
+	return builder.String()
+}
+
+// WriteInspect writes the plan formatted for display to w, one step at a time,
+// so large plans do not have to be held in memory as a single string.
+// It returns the first error returned by w.
+func (pl *Plan) WriteInspect(w io.Writer) error {
+	return pl.WriteInspectFiltered(w, allSteps)
+}
+
+// WriteInspectFiltered is like WriteInspect, but only writes the steps for
+// which pred returns true, see InspectFiltered.
+func (pl *Plan) WriteInspectFiltered(w io.Writer, pred func(*Step) bool) error {
+	return pl.WriteInspectWith(w, InspectOptions{Filter: pred})
+}
+
+// InspectOptions customizes the output of WriteInspectWith. The zero value
+// writes the same text as WriteInspect.
+type InspectOptions struct {
+	// Filter selects the steps to show, see InspectFiltered. Nil shows every step.
+	Filter func(*Step) bool
+	// FormatStatus returns the bracketed status in a step headline, e.g.
+	// "[TODO]" for "TODO", for example to color it. Nil writes it unchanged.
+	FormatStatus func(status string) string
+	// FormatStepID returns the step ID in a step headline. Nil writes it unchanged.
+	FormatStepID func(id string) string
+}
+
+// WriteInspectWith is like WriteInspect, customized by opts.
+func (pl *Plan) WriteInspectWith(w io.Writer, opts InspectOptions) error {
+	pred := opts.Filter
+	if pred == nil {
+		pred = allSteps
+	}
+	formatStatus := opts.FormatStatus
+	if formatStatus == nil {
+		formatStatus = func(status string) string { return "[" + status + "]" }
+	}
+	formatStepID := opts.FormatStepID
+	if formatStepID == nil {
+		formatStepID = func(id string) string { return id }
+	}
+
+	out := &stickyWriter{w: w}
+
+	// Maybe add a title for the plan itself?
+	// out.printf("# Plan: %s\n\n", pl.ID)
+
+	totalEstimate, totalActual := 0, 0
+
+	shown := 0
+	for _, step := range pl.Steps {
+		if !pred(step) {
+			continue
+		}
+		shown++
+		// Headline: includes step number, status, and ID.
+		out.printf("## %d. %s %s\n", shown, formatStatus(strings.ToUpper(step.status)), formatStepID(step.id)) // Use fields
+
+		// Description paragraph (if not empty)
+		if step.description != "" {
+			out.printf("\n%s\n", step.description) // Add blank lines around description
+		}
+		out.printf("\n") // Ensure a blank line after header or description
+
+		// Blocker (only for blocked steps)
+		if strings.ToUpper(step.status) == "BLOCKED" {
+			out.printf("Blocked: %s\n\n", step.blocked)
+		}
+
+		// Assignee (only for assigned steps)
+		if step.assignee != "" {
+			out.printf("Assignee: %s\n\n", step.assignee)
+		}
+
+		// Completion time (only for done steps with a recorded time)
+		if completedAt, ok := step.CompletedAt(); ok {
+			out.printf("Completed: %s\n\n", completedAt.Local().Format("2006-01-02 15:04"))
+		}
+
+		// Time tracking (only shown once an estimate or actual time is recorded)
+		if step.estimate > 0 || step.actual > 0 {
+			out.printf("Time: %d min spent of %d min estimated\n\n", step.actual, step.estimate)
+		}
+		totalEstimate += step.estimate
+		totalActual += step.actual
+
+		// Acceptance criteria numbered list
+		if len(step.acceptance) > 0 { // Use field
+			out.printf("Acceptance Criteria:\n")
+			for j, criterion := range step.acceptance { // Use field
+				out.printf("%d. %s %s\n", j+1, criterionCheckbox(step.CriterionMet(j+1)), criterion)
+			}
+			out.printf("\n") // Add a newline after the list
+		}
+
+		// References numbered list
+		if len(step.references) > 0 { // Use field
+			out.printf("References:\n")
+			for j, reference := range step.references { // Use field
+				out.printf("%d. %s\n", j+1, reference)
+			}
+			out.printf("\n") // Add a newline after the list
+		}
+
+		if out.err != nil {
+			return out.err
+		}
+	}
+
+	if totalEstimate > 0 || totalActual > 0 {
+		out.printf("Total time: %d min spent of %d min estimated\n", totalActual, totalEstimate)
+	}
+
+	return out.err
+}
+
+// allSteps is the predicate for InspectFiltered that shows every step.
+func allSteps(*Step) bool {
+	return true
+}
+
+// IsIncomplete reports whether the step's status is not "DONE". It can be
+// passed to InspectFiltered to only show the steps that are left.
+func IsIncomplete(step *Step) bool {
+	return strings.ToUpper(step.status) != "DONE"
+}
+
+// stickyWriter formats to an io.Writer and remembers the first write error,
+// after which further writes are skipped.
+type stickyWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (sw *stickyWriter) printf(format string, args ...any) {
+	if sw.err != nil {
+		return
+	}
+	_, sw.err = fmt.Fprintf(sw.w, format, args...)
+}
+
+// dotColors maps step statuses to the fill colors used by ToDOT.
+var dotColors = map[string]string{
+	"DONE":    "palegreen",
+	"TODO":    "white",
+	"BLOCKED": "lightsalmon",
+}
+
+// ToDOT returns the plan as a Graphviz DOT digraph, e.g. for "dot -Tpng".
+// Each step is a node labeled with its ID and status and filled with a color
+// depending on the status; edges connect each step to the next one in order.
+func (pl *Plan) ToDOT() string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("digraph %s {\n", dotQuote(pl.ID)))
+	builder.WriteString("  rankdir=TB;\n")
+	builder.WriteString("  node [shape=box, style=\"rounded,filled\"];\n")
+
+	for _, step := range pl.Steps {
+		status := strings.ToUpper(step.status)
+		builder.WriteString(fmt.Sprintf("  %s [label=%s, tooltip=%s, fillcolor=%s];\n",
+			dotQuote(step.id), dotQuote(step.id+"\n["+status+"]"), dotQuote(step.description), dotQuote(dotColors[status])))
+	}
+
+	for i := 1; i < len(pl.Steps); i++ {
+		builder.WriteString(fmt.Sprintf("  %s -> %s;\n", dotQuote(pl.Steps[i-1].id), dotQuote(pl.Steps[i].id)))
+	}
+
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// dotQuote returns s as a double-quoted DOT string, escaping quotes and
+// backslashes and turning newlines into DOT line breaks.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return "\"" + s + "\""
+}
+
+// ToJSON returns the plan as indented JSON: its ID, tags, and every step with
+// its ID, description, status, acceptance criteria and references, in order.
+func (pl *Plan) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(struct {
+		ID    string   `json:"id"`
+		Tags  []string `json:"tags"`
+		Steps []*Step  `json:"steps"`
+	}{ID: pl.ID, Tags: pl.Tags(), Steps: pl.Steps}, "", "  ")
+}
+
+// ExportMarkdown returns the plan as a markdown checklist: a heading with the
+// plan's ID followed by one checkbox per step, checked for done steps, with
+// the acceptance criteria and references as nested lists.
+func (pl *Plan) ExportMarkdown() string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("# %s\n\n", pl.ID))
+
+	for _, step := range pl.Steps {
+		status := strings.ToUpper(step.status)
+		checkbox := " "
+		if status == "DONE" {
+			checkbox = "x"
+		}
+
+		line := fmt.Sprintf("- [%s] **%s**", checkbox, step.id)
+		if step.description != "" {
+			line += ": " + step.description
+		}
+		if status == "BLOCKED" {
+			line += fmt.Sprintf(" (blocked: %s)", step.blocked)
+		}
+		builder.WriteString(line + "\n")
+
+		for _, criterion := range step.acceptance {
+			builder.WriteString(fmt.Sprintf("  - %s\n", criterion))
+		}
+		for _, reference := range step.references {
+			builder.WriteString(fmt.Sprintf("  - See %s\n", reference))
+		}
+	}
+
+	return builder.String()
+}
+
+// csvColumns are the columns written by ExportCSV.
+var csvColumns = []string{"step_id", "status", "description", "acceptance_criteria", "references"}
+
+// ExportCSV writes the plan's steps to w as CSV, e.g. for spreadsheets: a
+// header row followed by one row per step with the columns step_id, status,
+// description, acceptance_criteria and references. Acceptance criteria and
+// references (in the "Title|URL" form) are joined by ";".
+func (pl *Plan) ExportCSV(w io.Writer) error {
+	return ExportPlansCSV(w, []*Plan{pl}, false)
+}
+
+// ExportPlansCSV writes the steps of several plans to w as CSV like ExportCSV.
+// With withPlanColumn set, each row starts with an additional "plan" column
+// holding the plan's ID.
+func ExportPlansCSV(w io.Writer, plans []*Plan, withPlanColumn bool) error {
+	cw := csv.NewWriter(w)
+
+	header := csvColumns
+	if withPlanColumn {
+		header = append([]string{"plan"}, csvColumns...)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, plan := range plans {
+		for _, step := range plan.Steps {
+			record := []string{
+				step.id,
+				strings.ToUpper(step.status),
+				step.description,
+				strings.Join(step.acceptance, ";"),
+				strings.Join(referenceStrings(step.references), ";"),
+			}
+			if withPlanColumn {
+				record = append([]string{plan.ID}, record...)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// NextStep returns the first step in the plan that is marked as "TODO".
+// Steps that are "DONE" or "BLOCKED" are skipped.
+// It returns nil if no step can be worked on.
+func (pl *Plan) NextStep() *Step {
+	for _, step := range pl.Steps {
+		// Case-insensitive comparison just in case
+		if strings.ToUpper(step.status) == "TODO" { // Use field
+			return step
+		}
+	}
+	return nil // All steps are done or blocked
+}
+
+// ID returns the short identifier of the step.
+func (step *Step) ID() string {
+	return step.id
+}
+
+// Status returns the current status of the step ("DONE", "TODO" or "BLOCKED").
+func (step *Step) Status() string {
+	// Ensure status is always returned in uppercase as per requirement.
+	return strings.ToUpper(step.status)
+}
+
+// Order returns the 0-based position of the step in its plan as of the last
+// Get or Save. Steps added or moved in memory keep their old position until
+// the plan is saved.
+func (step *Step) Order() int {
+	return step.stepOrder
+}
+
+// Description returns the text description of the step.
+func (step *Step) Description() string {
+	return step.description
+}
+
+// AcceptanceCriteria returns the list of acceptance criteria for the step.
+func (step *Step) AcceptanceCriteria() []string {
+	// Return a copy to prevent modification of the internal slice? No, requirement is just to return.
+	return step.acceptance
+}
+
+// References returns the list of references for the step.
+func (step *Step) References() []Reference {
+	return step.references
+}
+
+// MarshalJSON encodes the step as an object with the fields id, description,
+// status, acceptance_criteria and references, plus assignee for assigned steps.
+// References are encoded as strings in the "Title|URL" form accepted by ParseReference.
+func (step *Step) MarshalJSON() ([]byte, error) {
+	return json.Marshal(step.jsonFields())
+}
+
+// jsonFields returns the fields encoded by MarshalJSON.
+func (step *Step) jsonFields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"id":                  step.ID(),
+		"description":         step.Description(),
+		"status":              step.Status(),
+		"acceptance_criteria": step.AcceptanceCriteria(),
+		"references":          referenceStrings(step.References()),
+	}
+	if step.assignee != "" {
+		fields["assignee"] = step.assignee
+	}
+	return fields
+}
+
+// referenceStrings converts references to the "Title|URL" form accepted by
+// ParseReference, using the bare URL for references without a title.
+func referenceStrings(references []Reference) []string {
+	texts := make([]string, len(references))
+	for i, ref := range references {
+		if ref.Title == "" {
+			texts[i] = ref.URL
+		} else {
+			texts[i] = ref.Title + "|" + ref.URL
+		}
+	}
+	return texts
+}
+
+// uniqueReferences returns references without those whose URL already
+// appeared earlier in the list, preserving the order of first occurrence.
+func uniqueReferences(references []Reference) []Reference {
+	seen := make(map[string]bool, len(references))
+	unique := make([]Reference, 0, len(references))
+	for _, ref := range references {
+		if seen[ref.URL] {
+			continue
+		}
+		seen[ref.URL] = true
+		unique = append(unique, ref)
+	}
+	return unique
+}
+
+// CompletedAt returns when the step was marked as done.
+// The second result is false if the step is not done or was completed
+// before completion times were recorded.
+func (step *Step) CompletedAt() (time.Time, bool) {
+	if strings.ToUpper(step.status) != "DONE" || step.completedAt.IsZero() {
+		return time.Time{}, false
+	}
+	return step.completedAt, true
+}
+
+// BlockedReason returns why the step is blocked, or an empty string if it is not blocked.
+func (step *Step) BlockedReason() string {
+	return step.blocked
+}
+
+// EstimateMinutes returns the estimated time for the step in minutes, 0 if not estimated.
+func (step *Step) EstimateMinutes() int {
+	return step.estimate
+}
+
+// SetEstimateMinutes sets the estimated time for the step in minutes.
+func (step *Step) SetEstimateMinutes(minutes int) {
+	step.estimate = minutes
+}
+
+// ActualMinutes returns the time actually spent on the step in minutes.
+func (step *Step) ActualMinutes() int {
+	return step.actual
+}
+
+// SetActualMinutes sets the time actually spent on the step in minutes.
+func (step *Step) SetActualMinutes(minutes int) {
+	step.actual = minutes
+}
+
+// Assignee returns who owns the step, or an empty string if it is unassigned.
+func (step *Step) Assignee() string {
+	return step.assignee
+}
+
+// SetAssignee sets who owns the step. An empty name unassigns the step.
+func (step *Step) SetAssignee(name string) {
+	step.assignee = name
+}
+
+// MarkAsCompleted sets the status of the step to "DONE" in-memory and clears
+// any blocked reason. The completion time is set to now unless the step was
+// already completed.
+func (step *Step) MarkAsCompleted() {
+	if strings.ToUpper(step.status) != "DONE" || step.completedAt.IsZero() {
+		step.completedAt = time.Now().UTC()
+	}
+	step.status = "DONE"
+	step.blocked = ""
+}
+
+// MarkAsIncomplete sets the status of the step to "TODO" in-memory and clears
+// its blocked reason and completion time.
+func (step *Step) MarkAsIncomplete() {
+	step.status = "TODO"
+	step.blocked = ""
+	step.completedAt = time.Time{}
+}
+
+// AddReference appends ref to the step's references unless a reference with
+// the same URL is already present. It reports whether the reference was added.
+func (step *Step) AddReference(ref Reference) bool {
+	for _, existing := range step.references {
+		if existing.URL == ref.URL {
+			return false
+		}
+	}
+	step.references = append(step.references, ref)
+	return true
+}
+
+// AddCriterion appends an acceptance criterion to the step in-memory.
+// The new criterion is not met.
+func (step *Step) AddCriterion(criterion string) {
+	step.acceptance = append(step.acceptance, criterion)
+}
+
+// CriterionMet reports whether the acceptance criterion at the given 1-based
+// index is checked as met. It returns false for indexes out of range.
+func (step *Step) CriterionMet(index int) bool {
+	return index >= 1 && index <= len(step.met) && index <= len(step.acceptance) && step.met[index-1]
+}
+
+// SetCriterionMet checks or unchecks the acceptance criterion at the given
+// 1-based index, matching the numbers shown by Inspect, in-memory.
+// It returns an error if the index is out of range.
+func (step *Step) SetCriterionMet(index int, met bool) error {
+	if index < 1 || index > len(step.acceptance) {
+		return fmt.Errorf("acceptance criterion %d not found in step '%s' (it has %d)", index, step.id, len(step.acceptance))
+	}
+	// Build a new slice so a slice shared with a copy of the step is not modified
+	updated := make([]bool, len(step.acceptance))
+	copy(updated, step.met)
+	updated[index-1] = met
+	step.met = updated
+	return nil
+}
+
+// AllCriteriaMet reports whether every acceptance criterion of the step is
+// checked as met. A step without criteria has all its criteria met.
+func (step *Step) AllCriteriaMet() bool {
+	for i := range step.acceptance {
+		if !step.CriterionMet(i + 1) {
+			return false
+		}
+	}
+	return true
+}
+
+// RemoveCriterion removes the acceptance criterion at the given 1-based index,
+// matching the numbers shown by Inspect, in-memory.
+// It returns an error if the index is out of range.
+func (step *Step) RemoveCriterion(index int) error {
+	if index < 1 || index > len(step.acceptance) {
+		return fmt.Errorf("acceptance criterion %d not found in step '%s' (it has %d)", index, step.id, len(step.acceptance))
+	}
+	// Build a new slice so a slice shared with the caller of AddStep is not modified
+	remaining := make([]string, 0, len(step.acceptance)-1)
+	remaining = append(remaining, step.acceptance[:index-1]...)
+	remaining = append(remaining, step.acceptance[index:]...)
+	step.acceptance = remaining
+	if index <= len(step.met) {
+		remainingMet := make([]bool, 0, len(step.met)-1)
+		remainingMet = append(remainingMet, step.met[:index-1]...)
+		remainingMet = append(remainingMet, step.met[index:]...)
+		step.met = remainingMet
+	}
+	return nil
+}
+
+// LogTime adds minutes to the time actually spent on the step with the given stepID in-memory.
+// It returns an error if the step is not found.
+func (pl *Plan) LogTime(stepID string, minutes int) error {
+	step, err := pl.FindStep(stepID)
+	if err != nil {
+		return err
+	}
+	step.actual += minutes
+	return nil
+}
+
+// FindStep returns the step with the given stepID.
+// It returns an error if the step is not found.
+func (pl *Plan) FindStep(stepID string) (*Step, error) {
+	for _, step := range pl.Steps {
+		if step.id == stepID {
+			return step, nil
+		}
+	}
+	return nil, fmt.Errorf("step with ID '%s' not found in plan '%s'", stepID, pl.ID)
+}
+
+// MarkAsCompleted sets the status of the step with the given stepID to "DONE" in-memory.
+// It returns an error if the step is not found.
+func (pl *Plan) MarkAsCompleted(stepID string) error {
+	step, err := pl.FindStep(stepID)
+	if err != nil {
+		return err
+	}
+	step.MarkAsCompleted()
+	return nil
+}
+
+// MarkAsCompletedStrict is like MarkAsCompleted, but refuses to complete a
+// step whose acceptance criteria are not all checked as met, returning an
+// error wrapping ErrCriteriaNotMet that lists the unmet criteria by number.
+// The step is left unchanged in that case.
+func (pl *Plan) MarkAsCompletedStrict(stepID string) error {
+	step, err := pl.FindStep(stepID)
+	if err != nil {
+		return err
+	}
+	var unmet []string
+	for i := range step.acceptance {
+		if !step.CriterionMet(i + 1) {
+			unmet = append(unmet, strconv.Itoa(i+1))
+		}
+	}
+	if len(unmet) == 1 {
+		return fmt.Errorf("cannot complete step '%s': criterion %s: %w", stepID, unmet[0], ErrCriteriaNotMet)
+	}
+	if len(unmet) > 1 {
+		return fmt.Errorf("cannot complete step '%s': criteria %s: %w", stepID, strings.Join(unmet, ", "), ErrCriteriaNotMet)
+	}
+	return pl.MarkAsCompleted(stepID)
+}
+
+// MarkAsIncomplete sets the status of the step with the given stepID to "TODO" in-memory.
+// It returns an error if the step is not found.
+func (pl *Plan) MarkAsIncomplete(stepID string) error {
+	step, err := pl.FindStep(stepID)
+	if err != nil {
+		return err
+	}
+	step.MarkAsIncomplete()
+	return nil
+}
+
+// MarkAsBlocked sets the status of the step with the given stepID to "BLOCKED" in-memory
+// and records the reason. Blocked steps are skipped by NextStep.
+// It returns an error if the step is not found or reason is empty.
+func (pl *Plan) MarkAsBlocked(stepID, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("a reason is required to block step '%s'", stepID)
+	}
+	step, err := pl.FindStep(stepID)
+	if err != nil {
+		return err
+	}
+	step.status = "BLOCKED"
+	step.blocked = reason
+	step.completedAt = time.Time{}
+	return nil
+}
+
+// Unblock sets the status of the blocked step with the given stepID back to "TODO" in-memory
+// and clears the reason.
+// It returns an error if the step is not found or is not blocked.
+func (pl *Plan) Unblock(stepID string) error {
+	step, err := pl.FindStep(stepID)
+	if err != nil {
+		return err
+	}
+	if strings.ToUpper(step.status) != "BLOCKED" {
+		return fmt.Errorf("step with ID '%s' in plan '%s' is not blocked", stepID, pl.ID)
+	}
+	step.status = "TODO"
+	step.blocked = ""
+	step.completedAt = time.Time{}
+	return nil
+}
+
+// ResetAll sets every step of the plan back to "TODO" in-memory, clearing
+// completion times and blocked reasons. Logged time is kept.
+// It returns the number of steps whose status changed.
+func (pl *Plan) ResetAll() int {
+	reset := 0
+	for _, step := range pl.Steps {
+		if strings.ToUpper(step.status) != "TODO" {
+			reset++
+		}
+		step.status = "TODO"
+		step.blocked = ""
+		step.completedAt = time.Time{}
+	}
+	return reset
+}
 
 // AddStep appends a new step to the plan.
 // The new step is initialized with status "TODO".
-func (pl *Plan) AddStep(id, description string, acceptanceCriteria []string, references []string) {
+func (pl *Plan) AddStep(id, description string, acceptanceCriteria []string, references []Reference) {
 	newStep := &Step{
 		id:          id,
 		description: description,
@@ -315,6 +1671,187 @@ func (pl *Plan) AddStep(id, description string, acceptanceCriteria []string, ref
 	pl.Steps = append(pl.Steps, newStep)
 }
 
+// MaxStepIDLength is the maximum number of characters in a step ID.
+const MaxStepIDLength = 64
+
+// ValidateStepID checks that id can be used as a step ID. IDs must not be
+// empty or consist only of whitespace, must not start or end with whitespace
+// or contain control characters, must not start with '-' (which the command
+// line would take for a flag), and must be at most MaxStepIDLength characters long.
+func ValidateStepID(id string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("step ID must not be empty")
+	}
+	if strings.TrimSpace(id) != id {
+		return fmt.Errorf("step ID '%s' must not start or end with whitespace", id)
+	}
+	if strings.HasPrefix(id, "-") {
+		return fmt.Errorf("step ID '%s' must not start with '-'", id)
+	}
+	if strings.IndexFunc(id, unicode.IsControl) >= 0 {
+		return fmt.Errorf("step ID %q must not contain control characters", id)
+	}
+	if utf8.RuneCountInString(id) > MaxStepIDLength {
+		return fmt.Errorf("step ID '%s' is longer than %d characters", id, MaxStepIDLength)
+	}
+	return nil
+}
+
+// AddStepChecked is like AddStep, but first validates the ID with
+// ValidateStepID and checks that no step in the plan already uses it.
+// On error the plan is left unchanged.
+func (pl *Plan) AddStepChecked(id, description string, acceptanceCriteria []string, references []Reference) error {
+	if err := ValidateStepID(id); err != nil {
+		return err
+	}
+	for _, step := range pl.Steps {
+		if step.id == id {
+			return fmt.Errorf("step with ID '%s' already exists in plan '%s'", id, pl.ID)
+		}
+	}
+	pl.AddStep(id, description, acceptanceCriteria, references)
+	return nil
+}
+
+// validStatuses lists the statuses a step can have.
+var validStatuses = map[string]bool{"TODO": true, "DONE": true, "BLOCKED": true}
+
+// Validate checks that the step can be stored: its ID must not be empty,
+// its status must be "TODO", "DONE" or "BLOCKED", and every reference must
+// have a URL. The stricter rules of ValidateStepID are only enforced when
+// steps are added, so that plans with older step IDs can still be saved.
+func (step *Step) Validate() error {
+	if strings.TrimSpace(step.id) == "" {
+		return fmt.Errorf("step ID must not be empty")
+	}
+	if !validStatuses[step.status] {
+		return fmt.Errorf("step '%s': invalid status '%s' (must be TODO, DONE or BLOCKED)", step.id, step.status)
+	}
+	for i, ref := range step.references {
+		if strings.TrimSpace(ref.URL) == "" {
+			return fmt.Errorf("step '%s': reference %d has an empty URL", step.id, i+1)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the plan can be stored: its ID must not be empty,
+// every step must pass Step.Validate, and no two steps may share an ID,
+// which is reported with an error wrapping ErrDuplicateStepID.
+// It returns the first problem found.
+func (pl *Plan) Validate() error {
+	if strings.TrimSpace(pl.ID) == "" {
+		return fmt.Errorf("plan ID must not be empty")
+	}
+
+	seenStepIDs := make(map[string]bool, len(pl.Steps))
+	for _, step := range pl.Steps {
+		if err := step.Validate(); err != nil {
+			return err
+		}
+		if seenStepIDs[step.id] {
+			return fmt.Errorf("step '%s': %w", step.id, ErrDuplicateStepID)
+		}
+		seenStepIDs[step.id] = true
+	}
+	return nil
+}
+
+// StepSpec defines a step to be added with AddSteps.
+// References use the "Title|URL" or bare URL form accepted by ParseReference.
+type StepSpec struct {
+	ID                 string   `json:"id" yaml:"id"`
+	Description        string   `json:"description" yaml:"description"`
+	AcceptanceCriteria []string `json:"acceptance_criteria" yaml:"acceptance_criteria"`
+	References         []string `json:"references" yaml:"references"`
+}
+
+// AddSteps appends new steps to the plan in the given order.
+// All specs are validated before any step is added, so on error the plan is
+// left unchanged. It returns an error if a spec has no description, if its ID
+// is rejected by ValidateStepID, or if its ID is already used in the plan or by
+// an earlier spec.
+func (pl *Plan) AddSteps(specs []StepSpec) error {
+	seen := make(map[string]bool, len(pl.Steps)+len(specs))
+	for _, step := range pl.Steps {
+		seen[step.id] = true
+	}
+	for i, spec := range specs {
+		if spec.ID == "" {
+			return fmt.Errorf("step %d has no ID", i+1)
+		}
+		if err := ValidateStepID(spec.ID); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if spec.Description == "" {
+			return fmt.Errorf("step '%s' has no description", spec.ID)
+		}
+		if seen[spec.ID] {
+			return fmt.Errorf("step with ID '%s' already exists in plan '%s'", spec.ID, pl.ID)
+		}
+		seen[spec.ID] = true
+	}
+
+	for _, spec := range specs {
+		var references []Reference
+		for _, text := range spec.References {
+			references = append(references, ParseReference(text))
+		}
+		pl.AddStep(spec.ID, spec.Description, spec.AcceptanceCriteria, references)
+	}
+	return nil
+}
+
+// Tags returns the tags of the plan.
+func (pl *Plan) Tags() []string {
+	return pl.tags
+}
+
+// AddTag adds a tag to the plan in-memory.
+// Adding a tag that is already present has no effect.
+func (pl *Plan) AddTag(tag string) {
+	for _, existing := range pl.tags {
+		if existing == tag {
+			return
+		}
+	}
+	pl.tags = append(pl.tags, tag)
+}
+
+// RemoveTag removes a tag from the plan in-memory.
+// It returns true if the tag was present.
+func (pl *Plan) RemoveTag(tag string) bool {
+	for i, existing := range pl.tags {
+		if existing == tag {
+			pl.tags = append(pl.tags[:i], pl.tags[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateStep modifies the step with the given id in-memory.
+// A nil description, acceptanceCriteria or references leaves the corresponding
+// field unchanged. The step's status and position in the plan are preserved.
+// It returns an error if the step is not found.
+func (pl *Plan) UpdateStep(id string, description *string, acceptanceCriteria []string, references []Reference) error {
+	step, err := pl.FindStep(id)
+	if err != nil {
+		return err
+	}
+	if description != nil {
+		step.description = *description
+	}
+	if acceptanceCriteria != nil {
+		step.acceptance = acceptanceCriteria
+		step.met = nil // New criteria are not met yet
+	}
+	if references != nil {
+		step.references = references
+	}
+	return nil
+}
+
 // RemoveSteps removes steps from the plan based on the provided slice of step IDs.
 // It returns the number of steps actually removed.
 // It is not an error if a provided step ID is not found in the plan.
@@ -346,6 +1883,89 @@ func (pl *Plan) RemoveSteps(stepIDs []string) int {
 	return removedCount
 }
 
+// RemoveCompleted removes all steps with status "DONE" from the plan in-memory,
+// keeping the order of the remaining steps.
+// It returns the number of steps removed.
+func (pl *Plan) RemoveCompleted() int {
+	var remaining []*Step
+	for _, step := range pl.Steps {
+		if strings.ToUpper(step.status) != "DONE" {
+			remaining = append(remaining, step)
+		}
+	}
+
+	removed := len(pl.Steps) - len(remaining)
+	pl.Steps = remaining
+	return removed
+}
+
+// Position describes where MoveStep places a step.
+// Exactly one of its fields must be set.
+type Position struct {
+	Before   string // Place the step directly before the step with this ID
+	After    string // Place the step directly after the step with this ID
+	ToTop    bool   // Make the step the first step of the plan
+	ToBottom bool   // Make the step the last step of the plan
+}
+
+// MoveStep moves a single step to the given position, keeping the relative
+// order of all other steps.
+// It returns an error if the step or the anchor step does not exist, or if
+// position does not specify exactly one target.
+func (pl *Plan) MoveStep(id string, position Position) error {
+	targets := 0
+	for _, set := range []bool{position.Before != "", position.After != "", position.ToTop, position.ToBottom} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return fmt.Errorf("exactly one of before, after, to top or to bottom must be given")
+	}
+
+	if _, err := pl.FindStep(id); err != nil {
+		return err
+	}
+
+	anchor := position.Before
+	if position.After != "" {
+		anchor = position.After
+	}
+	if anchor == id {
+		return fmt.Errorf("cannot move step '%s' relative to itself", id)
+	}
+
+	var newOrder []string
+	if position.ToTop {
+		newOrder = append(newOrder, id)
+	}
+	found := anchor == ""
+	for _, step := range pl.Steps {
+		if step.id == id {
+			continue
+		}
+		if step.id == anchor {
+			found = true
+			if position.Before != "" {
+				newOrder = append(newOrder, id, step.id)
+			} else {
+				newOrder = append(newOrder, step.id, id)
+			}
+			continue
+		}
+		newOrder = append(newOrder, step.id)
+	}
+	if !found {
+		return fmt.Errorf("step with ID '%s' not found in plan '%s'", anchor, pl.ID)
+	}
+	if position.ToBottom {
+		newOrder = append(newOrder, id)
+	}
+
+	pl.Reorder(newOrder)
+	return nil
+}
+
 // Reorder rearranges the steps in the plan.
 // Steps whose IDs are in newStepOrder are placed first, in the specified order.
 // Any remaining steps from the original plan are appended afterwards,
@@ -357,58 +1977,324 @@ func (pl *Plan) Reorder(newStepOrder []string) {
 		return // Nothing to reorder
 	}
 
-	originalStepsMap := make(map[string]*Step, len(pl.Steps))
-	for _, step := range pl.Steps {
-		originalStepsMap[step.id] = step
+	originalStepsMap := make(map[string]*Step, len(pl.Steps))
+	for _, step := range pl.Steps {
+		originalStepsMap[step.id] = step
+	}
+
+	var reorderedSteps []*Step
+	// Keep track of steps that have been explicitly placed by newStepOrder
+	// to correctly append remaining steps and handle potential duplicates in newStepOrder.
+	placedStepIDs := make(map[string]struct{})
+
+	// First, place steps according to newStepOrder
+	for _, stepID := range newStepOrder {
+		step, exists := originalStepsMap[stepID]
+		if !exists {
+			continue // Step ID from newStepOrder not found in plan, ignore.
+		}
+		if _, alreadyPlaced := placedStepIDs[stepID]; alreadyPlaced {
+			continue // Step ID was already placed (e.g., duplicate in newStepOrder), ignore.
+		}
+		reorderedSteps = append(reorderedSteps, step)
+		placedStepIDs[stepID] = struct{}{}
+	}
+
+	// Then, append any remaining steps from the original order
+	// that were not part of newStepOrder (or were duplicates and thus not re-added).
+	for _, originalStep := range pl.Steps {
+		if _, wasPlaced := placedStepIDs[originalStep.id]; !wasPlaced {
+			reorderedSteps = append(reorderedSteps, originalStep)
+			// Mark as placed here too, though less critical as we iterate originalSteps once.
+			placedStepIDs[originalStep.id] = struct{}{}
+		}
+	}
+
+	pl.Steps = reorderedSteps
+}
+
+// Renumber gives the steps sequential IDs in their current order, starting
+// at prefix+"1", e.g. "step-1", "step-2" for the prefix "step-". Everything
+// else about the steps, including their status, acceptance criteria and
+// references, is kept. It returns the old ID of every step whose ID changed,
+// mapped to its new ID, so that references to the steps elsewhere can be
+// updated. The new IDs are not checked with ValidateStepID.
+func (pl *Plan) Renumber(prefix string) map[string]string {
+	renamed := make(map[string]string)
+	stored := make(map[string]string)
+	for i, step := range pl.Steps {
+		id := prefix + strconv.Itoa(i+1)
+		storedID := step.id
+		if previous, ok := pl.renamed[step.id]; ok {
+			storedID = previous
+		}
+		if step.id != id {
+			renamed[step.id] = id
+			step.id = id
+		}
+		if storedID != id {
+			stored[id] = storedID
+		}
+	}
+	pl.renamed = stored
+	return renamed
+}
+
+// RemainingCount returns the number of steps that are not "DONE", including blocked steps.
+func (pl *Plan) RemainingCount() int {
+	done, total := pl.Progress()
+	return total - done
+}
+
+// Progress returns the number of steps marked as "DONE" and the total number of steps.
+func (pl *Plan) Progress() (done int, total int) {
+	for _, step := range pl.Steps {
+		if strings.ToUpper(step.status) == "DONE" {
+			done++
+		}
+	}
+	return done, len(pl.Steps)
+}
+
+// WeightedProgress returns the fraction of the plan that is done, between 0
+// and 1, weighting each step by its estimate: the estimated minutes of the
+// "DONE" steps divided by the estimated minutes of all steps, so that one large
+// step counts for more than several small ones. Steps without an estimate
+// have no weight. If no step has an estimate, it falls back to the fraction of
+// steps that are done, like Progress. A plan without steps has no progress.
+func (pl *Plan) WeightedProgress() float64 {
+	doneMinutes, totalMinutes := 0, 0
+	for _, step := range pl.Steps {
+		totalMinutes += step.estimate
+		if strings.ToUpper(step.status) == "DONE" {
+			doneMinutes += step.estimate
+		}
+	}
+	if totalMinutes > 0 {
+		return float64(doneMinutes) / float64(totalMinutes)
+	}
+
+	done, total := pl.Progress()
+	if total == 0 {
+		return 0
+	}
+	return float64(done) / float64(total)
+}
+
+// IsCompleted checks if all steps in the plan are marked as "DONE".
+// Blocked steps count as not done.
+func (pl *Plan) IsCompleted() bool {
+	done, total := pl.Progress()
+	return done == total
+}
+
+// List retrieves summary information for all plans that are not archived.
+func (p *Planner) List() ([]PlanInfo, error) {
+	return p.ListCtx(context.Background())
+}
+
+// ListCtx is like List, but stops once ctx is cancelled and returns an error
+// wrapping ctx's error.
+func (p *Planner) ListCtx(ctx context.Context) ([]PlanInfo, error) {
+	return p.listPlansPage(ctx, "WHERE p.archived = 0", -1, 0)
+}
+
+// ListPaged is like List but returns at most limit plans, skipping the first
+// offset plans. Plans are ordered by name, so consecutive pages neither
+// overlap nor skip plans as long as no plans are added or removed in between.
+func (p *Planner) ListPaged(limit, offset int) ([]PlanInfo, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("invalid limit %d (must be positive)", limit)
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset %d (must not be negative)", offset)
+	}
+	return p.listPlansPage(context.Background(), "WHERE p.archived = 0", limit, offset)
+}
+
+// ListAll retrieves summary information for all plans, including archived ones.
+func (p *Planner) ListAll() ([]PlanInfo, error) {
+	return p.listPlans("")
+}
+
+// ListByTag retrieves summary information for all plans tagged with tag
+// that are not archived.
+func (p *Planner) ListByTag(tag string) ([]PlanInfo, error) {
+	return p.listPlans("WHERE p.archived = 0 AND p.id IN (SELECT plan_id FROM plan_tags WHERE tag = ?)", tag)
+}
+
+// ListAllByTag is like ListByTag but includes archived plans.
+func (p *Planner) ListAllByTag(tag string) ([]PlanInfo, error) {
+	return p.listPlans("WHERE p.id IN (SELECT plan_id FROM plan_tags WHERE tag = ?)", tag)
+}
+
+// ListByStatus retrieves summary information for all plans that are not
+// archived and whose computed Status is status ("DONE" or "TODO", case-insensitive).
+func (p *Planner) ListByStatus(status string) ([]PlanInfo, error) {
+	return p.ListByStatusCtx(context.Background(), status)
+}
+
+// ListByStatusCtx is like ListByStatus, but stops once ctx is cancelled like
+// ListCtx.
+func (p *Planner) ListByStatusCtx(ctx context.Context, status string) ([]PlanInfo, error) {
+	status = strings.ToUpper(status)
+	if status != "DONE" && status != "TODO" {
+		return nil, fmt.Errorf("invalid plan status '%s' (must be DONE or TODO)", status)
+	}
+
+	plans, err := p.ListCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return FilterByStatus(plans, status), nil
+}
+
+// FilterByStatus returns the plans whose Status is status, compared case-insensitively.
+func FilterByStatus(plans []PlanInfo, status string) []PlanInfo {
+	var filtered []PlanInfo
+	for _, info := range plans {
+		if strings.EqualFold(info.Status, status) {
+			filtered = append(filtered, info)
+		}
+	}
+	return filtered
+}
+
+// StepRef identifies a step and the plan it belongs to, with the step's
+// description and status. This is returned by the ListSteps method.
+type StepRef struct {
+	Plan        string `json:"plan"`
+	StepID      string `json:"step_id"`
+	Description string `json:"description"`
+	Status      string `json:"status"` // "DONE", "TODO" or "BLOCKED"
+}
+
+// ListSteps returns the steps with the given status ("TODO", "DONE" or
+// "BLOCKED", case-insensitive) in all plans that are not archived, ordered by
+// plan name and then by position within the plan. An empty status returns
+// every step. Unlike ListAssigned, no plans are loaded: the steps are read
+// with a single query, without their acceptance criteria and references.
+// It returns an error wrapping ErrTooManySteps if a plan has more matching
+// steps than the planner's limit, see Options.MaxSteps.
+func (p *Planner) ListSteps(status string) ([]StepRef, error) {
+	status = strings.ToUpper(status)
+	if status != "" && status != "TODO" && status != "DONE" && status != "BLOCKED" {
+		return nil, fmt.Errorf("invalid status '%s' (must be 'TODO', 'DONE' or 'BLOCKED')", status)
+	}
+
+	// The status is only compared when given, so that idx_steps_status can be used
+	query := `SELECT s.plan_id, s.id, COALESCE(s.description, ''), s.status
+		FROM steps s JOIN plans p ON p.id = s.plan_id
+		WHERE p.archived = 0`
+	var args []interface{}
+	if status != "" {
+		query += " AND s.status = ?"
+		args = append(args, status)
 	}
+	query += " ORDER BY s.plan_id, s.step_order"
 
-	var reorderedSteps []*Step
-	// Keep track of steps that have been explicitly placed by newStepOrder
-	// to correctly append remaining steps and handle potential duplicates in newStepOrder.
-	placedStepIDs := make(map[string]struct{})
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query steps: %w", err)
+	}
+	defer rows.Close()
 
-	// First, place steps according to newStepOrder
-	for _, stepID := range newStepOrder {
-		step, exists := originalStepsMap[stepID]
-		if !exists {
-			continue // Step ID from newStepOrder not found in plan, ignore.
+	steps := []StepRef{}
+	planSteps := 0 // Steps of the plan scanned last
+	for rows.Next() {
+		var ref StepRef
+		if err := rows.Scan(&ref.Plan, &ref.StepID, &ref.Description, &ref.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan step: %w", err)
 		}
-		if _, alreadyPlaced := placedStepIDs[stepID]; alreadyPlaced {
-			continue // Step ID was already placed (e.g., duplicate in newStepOrder), ignore.
+		if len(steps) == 0 || steps[len(steps)-1].Plan != ref.Plan {
+			planSteps = 0
 		}
-		reorderedSteps = append(reorderedSteps, step)
-		placedStepIDs[stepID] = struct{}{}
+		if p.maxSteps > 0 && planSteps == p.maxSteps {
+			return nil, fmt.Errorf("plan '%s' has more than %d steps: %w", ref.Plan, p.maxSteps, ErrTooManySteps)
+		}
+		planSteps++
+		steps = append(steps, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating steps: %w", err)
 	}
+	return steps, nil
+}
 
-	// Then, append any remaining steps from the original order
-	// that were not part of newStepOrder (or were duplicates and thus not re-added).
-	for _, originalStep := range pl.Steps {
-		if _, wasPlaced := placedStepIDs[originalStep.id]; !wasPlaced {
-			reorderedSteps = append(reorderedSteps, originalStep)
-			// Mark as placed here too, though less critical as we iterate originalSteps once.
-			placedStepIDs[originalStep.id] = struct{}{}
+// AssignedStep is a step together with the name of the plan it belongs to.
+// This is returned by the ListAssigned method.
+type AssignedStep struct {
+	Plan string
+	Step *Step
+}
+
+// ListAssigned returns the steps assigned to assignee in all plans that are not
+// archived, ordered by plan name and then by position within the plan.
+// The plans are loaded with GetMany, so the planner's step limit applies.
+func (p *Planner) ListAssigned(assignee string) ([]AssignedStep, error) {
+	rows, err := p.db.Query(`SELECT DISTINCT s.plan_id FROM steps s JOIN plans p ON p.id = s.plan_id
+		WHERE p.archived = 0 AND s.assignee = ? ORDER BY s.plan_id`, assignee)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query steps assigned to '%s': %w", assignee, err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan plan name: %w", err)
 		}
+		names = append(names, name)
 	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating plans: %w", err)
+	}
+	rows.Close()
 
-	pl.Steps = reorderedSteps
+	plans, err := p.GetMany(names)
+	if err != nil {
+		return nil, err
+	}
+
+	var assigned []AssignedStep
+	for _, name := range names {
+		for _, step := range plans[name].Steps {
+			if step.assignee == assignee {
+				assigned = append(assigned, AssignedStep{Plan: name, Step: step})
+			}
+		}
+	}
+	return assigned, nil
 }
 
-// IsCompleted checks if all steps in the plan are marked as "DONE".
-func (pl *Plan) IsCompleted() bool {
-	return pl.NextStep() == nil // If NextStep is nil, all steps are DONE
+// listPlans queries summary information for the plans matching the given
+// WHERE clause (which may be empty) and converts the rows to PlanInfo values.
+func (p *Planner) listPlans(where string, args ...interface{}) ([]PlanInfo, error) {
+	return p.listPlansPage(context.Background(), where, -1, 0, args...)
 }
 
-// List retrieves summary information for all plans from the database.
-func (p *Planner) List() ([]PlanInfo, error) {
-	rows, err := p.db.Query(`
+// listPlansPage is like listPlans but returns at most limit plans ordered by
+// name, skipping the first offset plans. A negative limit returns all plans.
+// The query stops once ctx is cancelled.
+func (p *Planner) listPlansPage(ctx context.Context, where string, limit, offset int, args ...interface{}) ([]PlanInfo, error) {
+	query := `
         SELECT 
             p.id, 
+            p.created_at,
+            p.updated_at,
+            p.archived,
+            COALESCE(p.recurrence, ''),
             COUNT(s.id),
             SUM(CASE WHEN s.status = 'DONE' THEN 1 ELSE 0 END)
         FROM plans p
         LEFT JOIN steps s ON p.id = s.plan_id
+        ` + where + `
         GROUP BY p.id
-    `)
+        ORDER BY p.id
+        LIMIT ? OFFSET ?
+    `
+	rows, err := p.db.QueryContext(ctx, query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query plan summaries: %w", err)
 	}
@@ -420,12 +2306,15 @@ func (p *Planner) List() ([]PlanInfo, error) {
 		var totalTasks sql.NullInt64     // Use NullInt64 for COUNT which can be 0 -> NULL
 		var completedTasks sql.NullInt64 // Use NullInt64 for SUM which can be NULL if no rows
 
-		if err := rows.Scan(&info.Name, &totalTasks, &completedTasks); err != nil {
+		if err := rows.Scan(&info.Name, &info.CreatedAt, &info.UpdatedAt, &info.Archived, &info.Recurrence, &totalTasks, &completedTasks); err != nil {
 			return nil, fmt.Errorf("failed to scan plan summary: %w", err)
 		}
 
 		info.TotalTasks = int(totalTasks.Int64)         // Assign, defaults to 0 if NULL
 		info.CompletedTasks = int(completedTasks.Int64) // Assign, defaults to 0 if NULL
+		if info.TotalTasks > 0 {
+			info.CompletionPercent = float64(info.CompletedTasks) * 100 / float64(info.TotalTasks)
+		}
 
 		if info.TotalTasks > 0 && info.CompletedTasks == info.TotalTasks {
 			info.Status = "DONE"
@@ -442,18 +2331,246 @@ func (p *Planner) List() ([]PlanInfo, error) {
 	return plansInfo, nil
 }
 
+// Archive marks the plan as archived, hiding it from List and ListByTag.
+// The plan and its steps are kept and can still be retrieved with Get.
+func (p *Planner) Archive(name string) error {
+	return p.setArchived(name, true)
+}
+
+// Unarchive makes an archived plan show up in List and ListByTag again.
+func (p *Planner) Unarchive(name string) error {
+	return p.setArchived(name, false)
+}
+
+// setArchived updates the archived flag of the named plan.
+func (p *Planner) setArchived(name string, archived bool) error {
+	result, err := p.db.Exec("UPDATE plans SET archived = ? WHERE id = ?", archived, name)
+	if err != nil {
+		return fmt.Errorf("failed to update archived flag of plan '%s': %w", name, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check archived flag update of plan '%s': %w", name, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("plan with name '%s' not found", name)
+	}
+	return nil
+}
+
+// Vacuum rebuilds the database file to reclaim the space left behind by
+// removed plans and steps, then checkpoints the write-ahead log so the file
+// actually shrinks and lets SQLite update its query planner statistics.
+// VACUUM cannot run inside a transaction, so it is executed on its own;
+// it is retried with backoff while the database is busy.
+func (p *Planner) Vacuum() error {
+	return withRetry(maxTransactionAttempts, func() error {
+		if _, err := p.db.Exec("VACUUM"); err != nil {
+			return fmt.Errorf("failed to vacuum database: %w", err)
+		}
+		if _, err := p.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+			return fmt.Errorf("failed to checkpoint database: %w", err)
+		}
+		if _, err := p.db.Exec("PRAGMA optimize"); err != nil {
+			return fmt.Errorf("failed to optimize database: %w", err)
+		}
+		return nil
+	})
+}
+
+// Touch sets the plan's updated_at time to now without changing anything else,
+// e.g. to record that the plan was looked at. Only the plans row is updated,
+// so the triggers on steps do not fire and the plan's version is unchanged.
+// It returns an error wrapping ErrPlanNotFound if the plan does not exist.
+func (p *Planner) Touch(name string) error {
+	result, err := p.db.Exec("UPDATE plans SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to touch plan '%s': %w", name, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check touch of plan '%s': %w", name, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("cannot touch plan '%s': %w", name, ErrPlanNotFound)
+	}
+	return nil
+}
+
+// CountSteps returns the number of steps in the plan and how many of them are
+// "DONE", like Plan.Progress, using a single aggregate query instead of
+// loading the plan.
+// It returns an error wrapping ErrPlanNotFound if the plan does not exist.
+func (p *Planner) CountSteps(planName string) (total int, done int, err error) {
+	err = p.db.QueryRow(`
+        SELECT COUNT(s.id), COALESCE(SUM(CASE WHEN s.status = 'DONE' THEN 1 ELSE 0 END), 0)
+        FROM plans p LEFT JOIN steps s ON s.plan_id = p.id
+        WHERE p.id = ?
+        GROUP BY p.id`, planName).Scan(&total, &done)
+	if err == sql.ErrNoRows {
+		return 0, 0, fmt.Errorf("cannot count steps of plan '%s': %w", planName, ErrPlanNotFound)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count steps of plan '%s': %w", planName, err)
+	}
+	return total, done, nil
+}
+
+// Stats aggregates step and plan counts across all plans in the database.
+// The aggregation is done in SQL, without loading individual plans.
+func (p *Planner) Stats() (Stats, error) {
+	var stats Stats
+
+	var done, todo, blocked sql.NullInt64 // SUM is NULL when there are no steps
+	err := p.db.QueryRow(`
+        SELECT
+            COUNT(*),
+            SUM(CASE WHEN status = 'DONE' THEN 1 ELSE 0 END),
+            SUM(CASE WHEN status = 'TODO' THEN 1 ELSE 0 END),
+            SUM(CASE WHEN status = 'BLOCKED' THEN 1 ELSE 0 END)
+        FROM steps
+    `).Scan(&stats.TotalSteps, &done, &todo, &blocked)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to query step statistics: %w", err)
+	}
+	stats.DoneSteps = int(done.Int64)
+	stats.TodoSteps = int(todo.Int64)
+	stats.BlockedSteps = int(blocked.Int64)
+
+	var completed sql.NullInt64 // SUM is NULL when there are no plans
+	err = p.db.QueryRow(`
+        SELECT
+            COUNT(*),
+            SUM(CASE WHEN total > 0 AND done = total THEN 1 ELSE 0 END)
+        FROM (
+            SELECT
+                COUNT(s.id) AS total,
+                SUM(CASE WHEN s.status = 'DONE' THEN 1 ELSE 0 END) AS done
+            FROM plans p
+            LEFT JOIN steps s ON p.id = s.plan_id
+            GROUP BY p.id
+        )
+    `).Scan(&stats.TotalPlans, &completed)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to query plan statistics: %w", err)
+	}
+	stats.CompletedPlans = int(completed.Int64)
+
+	if stats.TotalPlans > 0 {
+		stats.AverageStepsPerPlan = float64(stats.TotalSteps) / float64(stats.TotalPlans)
+	}
+
+	return stats, nil
+}
+
 // Save persists changes to a plan and its steps in the database using a transaction.
 // If plan.isNew is true, it inserts the plan into the 'plans' table first.
 // After successful save of a new plan, plan.isNew is set to false.
+// Plans that fail Plan.Validate are rejected before the transaction begins.
+// The transaction is retried with backoff while the database is busy.
 func (p *Planner) Save(plan *Plan) error {
-	tx, err := p.db.Begin()
+	return p.SaveCtx(context.Background(), plan)
+}
+
+// SaveCtx is like Save, but gives up once ctx is cancelled: the transaction
+// is rolled back, so nothing is written, and an error wrapping ctx's error
+// is returned.
+func (p *Planner) SaveCtx(ctx context.Context, plan *Plan) error {
+	return p.saveOperation(ctx, plan, "")
+}
+
+// saveOperation implements Save and SaveLogged. With an empty operation,
+// nothing is written to the operations log.
+func (p *Planner) saveOperation(ctx context.Context, plan *Plan, operation string) error {
+	if err := checkSavable(plan); err != nil {
+		return err
+	}
+
+	// The snapshot is taken before the transaction begins, since in-memory
+	// databases only have a single connection. If the plan changes in between,
+	// the version check below fails and nothing is logged.
+	var logged *loggedOperation
+	if operation != "" && !plan.isNew {
+		snapshots, err := snapshotPlans(withContext(ctx, p.db), []string{plan.ID}, 0)
+		if err != nil {
+			return err
+		}
+		logged = &loggedOperation{name: operation, plans: snapshots}
+	}
+
+	return withRetry(maxTransactionAttempts, func() error {
+		return p.save(ctx, plan, logged)
+	})
+}
+
+// checkSavable rejects plans that cannot be saved: summaries loaded with
+// GetSummary and invalid plans, such as plans with duplicate step IDs (which
+// are part of the primary key of steps), before any statement fails halfway
+// through the transaction.
+func checkSavable(plan *Plan) error {
+	if plan.summary {
+		return fmt.Errorf("cannot save plan '%s': it was loaded with GetSummary and lacks acceptance criteria and references", plan.ID)
+	}
+	if err := plan.Validate(); err != nil {
+		return fmt.Errorf("cannot save plan '%s': %w", plan.ID, err)
+	}
+	return nil
+}
+
+// save runs a single attempt of Save, writing logged to the operations log
+// unless it is nil.
+func (p *Planner) save(ctx context.Context, plan *Plan, logged *loggedOperation) error {
+	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback() // Rollback if not committed
 
+	hook, err := watchCompletion(tx, plan.ID, func() error {
+		return savePlan(tx, plan, logged)
+	})
+	if err != nil {
+		return contextError(ctx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return contextError(ctx, fmt.Errorf("failed to commit transaction for plan '%s': %w", plan.ID, err))
+	}
+
+	plan.markSaved()
+	hook.run()
+	return nil
+}
+
+// contextError adds ctx's error to err if ctx was cancelled. Statements of a
+// transaction begun with ctx fail with sql.ErrTxDone once ctx is cancelled,
+// which does not tell callers why.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		return fmt.Errorf("%w: %w", err, ctxErr)
+	}
+	return err
+}
+
+// markSaved updates the in-memory state of the plan after it was saved.
+func (plan *Plan) markSaved() {
+	// If we successfully committed a new plan, update its in-memory status.
+	if plan.isNew {
+		plan.isNew = false
+		plan.version = 1
+	} else {
+		plan.version++
+	}
+	plan.renamed = nil
+}
+
+// savePlan writes the plan to the database as part of tx, and op to the
+// operations log unless it is nil. The in-memory state of the plan is not
+// changed; call markSaved once tx is committed.
+func savePlan(tx *sql.Tx, plan *Plan, logged *loggedOperation) error {
 	if plan.isNew {
-		_, err := tx.Exec("INSERT INTO plans (id) VALUES (?)", plan.ID)
+		_, err := tx.Exec("INSERT INTO plans (id, version, on_complete, recurrence) VALUES (?, 1, ?, ?)", plan.ID, nullableString(plan.onComplete), nullableString(plan.recurrence))
 		if err != nil {
 			// Check if the error is due to a unique constraint violation (plan already exists)
 			if strings.Contains(err.Error(), "UNIQUE constraint failed") {
@@ -466,37 +2583,70 @@ func (p *Planner) Save(plan *Plan) error {
 	} else {
 		// If it's not a new plan, we might still want to verify it exists to provide a clearer error
 		// than what might come from step synchronization.
-		var checkID string
-		err := tx.QueryRow("SELECT id FROM plans WHERE id = ?", plan.ID).Scan(&checkID)
+		exists, err := planExists(tx, plan.ID)
 		if err != nil {
-			if err == sql.ErrNoRows {
-				return fmt.Errorf("plan with name '%s' not found in database, cannot update", plan.ID)
-			}
-			return fmt.Errorf("failed to verify existence of plan '%s': %w", plan.ID, err)
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("plan with name '%s' not found in database, cannot update", plan.ID)
+		}
+
+		// Only bump the version if nobody else saved the plan since it was loaded.
+		result, err := tx.Exec("UPDATE plans SET version = version + 1, on_complete = ?, recurrence = ? WHERE id = ? AND version = ?", nullableString(plan.onComplete), nullableString(plan.recurrence), plan.ID, plan.version)
+		if err != nil {
+			return fmt.Errorf("failed to update version of plan '%s': %w", plan.ID, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to check version of plan '%s': %w", plan.ID, err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("cannot save plan '%s': %w", plan.ID, ErrConcurrentModification)
+		}
+	}
+
+	// --- Synchronize tags --- //
+
+	_, err := tx.Exec("DELETE FROM plan_tags WHERE plan_id = ?", plan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete old tags for plan '%s': %w", plan.ID, err)
+	}
+	for _, tag := range plan.tags {
+		_, err = tx.Exec("INSERT INTO plan_tags (plan_id, tag) VALUES (?, ?)", plan.ID, tag)
+		if err != nil {
+			return fmt.Errorf("failed to insert tag '%s' for plan '%s': %w", tag, plan.ID, err)
 		}
 	}
 
 	// --- Synchronize steps --- //
 
-	// Get existing step IDs from the DB for this plan
-	rows, err := tx.Query("SELECT id FROM steps WHERE plan_id = ?", plan.ID)
+	// Get existing step IDs from the DB for this plan, together with their
+	// statuses to record status changes in the history
+	rows, err := tx.Query("SELECT id, status FROM steps WHERE plan_id = ?", plan.ID)
 	if err != nil {
 		return fmt.Errorf("failed to query existing steps for plan '%s': %w", plan.ID, err)
 	}
 	dbStepIDs := make(map[string]bool)
+	dbStatuses := make(map[string]string)
 	for rows.Next() {
-		var stepID string
-		if err := rows.Scan(&stepID); err != nil {
+		var stepID, status string
+		if err := rows.Scan(&stepID, &status); err != nil {
 			rows.Close()
 			return fmt.Errorf("failed to scan existing step ID: %w", err)
 		}
 		dbStepIDs[stepID] = true
+		dbStatuses[stepID] = status
 	}
 	rows.Close()
 	if err = rows.Err(); err != nil {
 		return fmt.Errorf("error iterating existing step IDs: %w", err)
 	}
 
+	// The history of renamed steps moves with them
+	if err := renameStepHistory(tx, plan.ID, plan.renamed); err != nil {
+		return err
+	}
+
 	planStepIDs := make(map[string]bool)
 	for _, step := range plan.Steps {
 		planStepIDs[step.id] = true
@@ -522,30 +2672,37 @@ func (p *Planner) Save(plan *Plan) error {
 	for i, step := range plan.Steps {
 		step.stepOrder = i
 		if dbStepIDs[step.id] {
-			_, err = tx.Exec("UPDATE steps SET description = ?, status = ?, step_order = ? WHERE plan_id = ? AND id = ?",
-				step.description, step.status, step.stepOrder, plan.ID, step.id)
+			_, err = tx.Exec("UPDATE steps SET description = ?, status = ?, step_order = ?, estimate_minutes = ?, actual_minutes = ?, blocked_reason = ?, completed_at = ?, assignee = ? WHERE plan_id = ? AND id = ?",
+				step.description, step.status, i*stepOrderGap, step.estimate, step.actual, nullableString(step.blocked), nullableTime(step.completedAt), nullableString(step.assignee), plan.ID, step.id)
 			if err != nil {
 				return fmt.Errorf("failed to update step '%s' in plan '%s': %w", step.id, plan.ID, err)
 			}
 		} else {
-			_, err = tx.Exec("INSERT INTO steps (id, plan_id, description, status, step_order) VALUES (?, ?, ?, ?, ?)",
-				step.id, plan.ID, step.description, step.status, step.stepOrder)
+			_, err = tx.Exec("INSERT INTO steps (id, plan_id, description, status, step_order, estimate_minutes, actual_minutes, blocked_reason, completed_at, assignee) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				step.id, plan.ID, step.description, step.status, i*stepOrderGap, step.estimate, step.actual, nullableString(step.blocked), nullableTime(step.completedAt), nullableString(step.assignee))
 			if err != nil {
 				return fmt.Errorf("failed to insert step '%s' into plan '%s': %w", step.id, plan.ID, err)
 			}
 		}
 
+		// Compare with the stored status of the step, under its old ID if it was renamed
+		storedID := step.id
+		if previous, ok := plan.renamed[step.id]; ok {
+			storedID = previous
+		}
+		if oldStatus, ok := dbStatuses[storedID]; ok {
+			if err := recordStatusChange(tx, plan.ID, step.id, oldStatus, step.status); err != nil {
+				return err
+			}
+		}
+
 		_, err = tx.Exec("DELETE FROM step_acceptance_criteria WHERE plan_id = ? AND step_id = ?", plan.ID, step.id)
 		if err != nil {
 			return fmt.Errorf("failed to delete old acceptance criteria for step '%s' in plan '%s': %w", step.id, plan.ID, err)
 		}
 
-		for j, acText := range step.acceptance {
-			_, err = tx.Exec("INSERT INTO step_acceptance_criteria (plan_id, step_id, criterion_order, criterion) VALUES (?, ?, ?, ?)",
-				plan.ID, step.id, j, acText)
-			if err != nil {
-				return fmt.Errorf("failed to insert acceptance criterion for step '%s' in plan '%s': %w", step.id, plan.ID, err)
-			}
+		if err := insertAcceptanceCriteria(tx, plan.ID, step.id, step.acceptance, step.met); err != nil {
+			return err
 		}
 
 		_, err = tx.Exec("DELETE FROM step_references WHERE plan_id = ? AND step_id = ?", plan.ID, step.id)
@@ -553,33 +2710,278 @@ func (p *Planner) Save(plan *Plan) error {
 			return fmt.Errorf("failed to delete old references for step '%s' in plan '%s': %w", step.id, plan.ID, err)
 		}
 
-		for j, refText := range step.references {
-			_, err = tx.Exec("INSERT INTO step_references (plan_id, step_id, reference_order, reference_url) VALUES (?, ?, ?, ?)",
-				plan.ID, step.id, j, refText)
+		// The same URL is only stored once per step, keeping the first occurrence
+		step.references = uniqueReferences(step.references)
+		for j, ref := range step.references {
+			_, err = tx.Exec("INSERT INTO step_references (plan_id, step_id, reference_order, reference_url, title) VALUES (?, ?, ?, ?, ?)",
+				plan.ID, step.id, j, ref.URL, nullableString(ref.Title))
 			if err != nil {
 				return fmt.Errorf("failed to insert reference for step '%s' in plan '%s': %w", step.id, plan.ID, err)
 			}
 		}
 	}
 
-	err = tx.Commit()
+	if logged != nil {
+		if err := logOperation(tx, logged); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetStepStatus changes the status of a single step directly in the database
+// to "TODO" or "DONE", without loading and saving the whole plan.
+// Like MarkAsCompleted and MarkAsIncomplete, it clears a blocked reason and
+// records or clears the completion time. The plan's version is incremented,
+// so copies of the plan loaded earlier can no longer be saved.
+// A change of status is recorded in the history, see History.
+// It returns an error if the plan or the step does not exist.
+func (p *Planner) SetStepStatus(planName, stepID, status string) error {
+	return p.WithTx(func(tx *Tx) error {
+		return tx.SetStepStatus(planName, stepID, status)
+	})
+}
+
+// setStepStatus implements SetStepStatus as part of tx.
+func setStepStatus(tx *sql.Tx, planName, stepID, status string) error {
+	result, err := tx.Exec("UPDATE plans SET version = version + 1 WHERE id = ?", planName)
 	if err != nil {
-		return fmt.Errorf("failed to commit transaction for plan '%s': %w", plan.ID, err)
+		return fmt.Errorf("failed to update version of plan '%s': %w", planName, err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check version update of plan '%s': %w", planName, err)
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("plan with name '%s' not found", planName)
 	}
 
-	// If we successfully committed a new plan, update its in-memory status.
-	if plan.isNew {
-		plan.isNew = false
+	var oldStatus string
+	err = tx.QueryRow("SELECT status FROM steps WHERE plan_id = ? AND id = ?", planName, stepID).Scan(&oldStatus)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("step with ID '%s' not found in plan '%s'", stepID, planName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query status of step '%s' in plan '%s': %w", stepID, planName, err)
+	}
+
+	// Keep the completion time of steps that already are done
+	result, err = tx.Exec(`
+        UPDATE steps
+        SET status = ?,
+            blocked_reason = NULL,
+            completed_at = CASE WHEN ? = 'DONE' THEN COALESCE(CASE WHEN status = 'DONE' THEN completed_at END, ?) END
+        WHERE plan_id = ? AND id = ?`,
+		status, status, time.Now().UTC(), planName, stepID)
+	if err != nil {
+		return fmt.Errorf("failed to update status of step '%s' in plan '%s': %w", stepID, planName, err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check status update of step '%s' in plan '%s': %w", stepID, planName, err)
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("step with ID '%s' not found in plan '%s'", stepID, planName)
+	}
+	return recordStatusChange(tx, planName, stepID, oldStatus, status)
+}
+
+// CompleteAndNext marks the step stepID of the plan planName as "DONE" like
+// SetStepStatus and returns the plan's next step as NextStep would, both in
+// a single transaction, so no other process can change the plan in between.
+// It returns a nil step if no step is left to work on, and an error if the
+// plan or the step does not exist.
+func (p *Planner) CompleteAndNext(planName, stepID string) (*Step, error) {
+	return p.CompleteAndNextCtx(context.Background(), planName, stepID)
+}
+
+// CompleteAndNextCtx is like CompleteAndNext, but gives up once ctx is
+// cancelled like SaveCtx: the transaction is rolled back, so the step is not
+// completed, and an error wrapping ctx's error is returned.
+func (p *Planner) CompleteAndNextCtx(ctx context.Context, planName, stepID string) (*Step, error) {
+	var next *Step
+	err := withRetry(maxTransactionAttempts, func() error {
+		tx, err := p.db.BeginTx(ctx, nil)
+		if err != nil {
+			return contextError(ctx, fmt.Errorf("failed to begin transaction: %w", err))
+		}
+		defer tx.Rollback() // Rollback if not committed
+
+		hook, err := watchCompletion(tx, planName, func() error {
+			return setStepStatus(tx, planName, stepID, "DONE")
+		})
+		if err != nil {
+			return contextError(ctx, err)
+		}
+
+		next, err = nextStep(tx, planName)
+		if err != nil {
+			return contextError(ctx, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return contextError(ctx, fmt.Errorf("failed to commit transaction for plan '%s': %w", planName, err))
+		}
+		hook.run()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// nextStep loads the first "TODO" step of the plan planName, including its
+// acceptance criteria and references, as part of tx.
+// It returns nil if the plan has no such step.
+func nextStep(tx *sql.Tx, planName string) (*Step, error) {
+	step := &Step{acceptance: []string{}, references: []Reference{}}
+	var estimate, actual sql.NullInt64
+	var blockedReason, assignee sql.NullString
+	var completedAt sql.NullTime
+	var order int
+	err := tx.QueryRow("SELECT id, description, status, step_order, estimate_minutes, actual_minutes, blocked_reason, completed_at, assignee FROM steps WHERE plan_id = ? AND status = 'TODO' ORDER BY step_order ASC LIMIT 1", planName).
+		Scan(&step.id, &step.description, &step.status, &order, &estimate, &actual, &blockedReason, &completedAt, &assignee)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query next step of plan '%s': %w", planName, err)
+	}
+
+	// Stored orders may have gaps, see stepOrderGap, so count the steps before it
+	err = tx.QueryRow("SELECT COUNT(*) FROM steps WHERE plan_id = ? AND step_order < ?", planName, order).Scan(&step.stepOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query position of step '%s' in plan '%s': %w", step.id, planName, err)
+	}
+	step.estimate = int(estimate.Int64)
+	step.actual = int(actual.Int64)
+	step.blocked = blockedReason.String
+	step.completedAt = completedAt.Time
+	step.assignee = assignee.String
+
+	acRows, err := tx.Query("SELECT criterion, met FROM step_acceptance_criteria WHERE step_id = ? AND plan_id = ? ORDER BY criterion_order ASC", step.id, planName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query acceptance criteria for step '%s' in plan '%s': %w", step.id, planName, err)
+	}
+	defer acRows.Close()
+	for acRows.Next() {
+		var criterion string
+		var met bool
+		if err := acRows.Scan(&criterion, &met); err != nil {
+			return nil, fmt.Errorf("failed to scan acceptance criterion for step '%s' in plan '%s': %w", step.id, planName, err)
+		}
+		step.acceptance = append(step.acceptance, criterion)
+		step.met = append(step.met, met)
+	}
+	if err := acRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating acceptance criteria for step '%s' in plan '%s': %w", step.id, planName, err)
+	}
+
+	refRows, err := tx.Query("SELECT reference_url, title FROM step_references WHERE step_id = ? AND plan_id = ? ORDER BY reference_order ASC", step.id, planName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query references for step '%s' in plan '%s': %w", step.id, planName, err)
+	}
+	defer refRows.Close()
+	for refRows.Next() {
+		var ref Reference
+		var title sql.NullString
+		if err := refRows.Scan(&ref.URL, &title); err != nil {
+			return nil, fmt.Errorf("failed to scan reference for step '%s' in plan '%s': %w", step.id, planName, err)
+		}
+		ref.Title = title.String
+		step.references = append(step.references, ref)
+	}
+	if err := refRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating references for step '%s' in plan '%s': %w", step.id, planName, err)
+	}
+
+	return step, nil
+}
+
+// nullableString converts an empty string to NULL for storage.
+func nullableString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// nullableTime converts a time to a sql.NullTime, mapping the zero time to NULL.
+func nullableTime(value time.Time) sql.NullTime {
+	return sql.NullTime{Time: value, Valid: !value.IsZero()}
+}
+
+// maxCriteriaPerInsert limits the number of rows in a single multi-row INSERT
+// so that the statement stays well below SQLite's bound parameter limit.
+const maxCriteriaPerInsert = 500
+
+// insertAcceptanceCriteria inserts the acceptance criteria of a step using
+// multi-row INSERT statements instead of one statement per criterion.
+// criterion_order is the index of each criterion in criteria, and met tells
+// by index which criteria are met; missing entries are not met.
+func insertAcceptanceCriteria(tx *sql.Tx, planID, stepID string, criteria []string, met []bool) error {
+	for start := 0; start < len(criteria); start += maxCriteriaPerInsert {
+		end := start + maxCriteriaPerInsert
+		if end > len(criteria) {
+			end = len(criteria)
+		}
+
+		var query strings.Builder
+		query.WriteString("INSERT INTO step_acceptance_criteria (plan_id, step_id, criterion_order, criterion, met) VALUES ")
+		args := make([]interface{}, 0, (end-start)*5)
+		for j := start; j < end; j++ {
+			if j > start {
+				query.WriteString(", ")
+			}
+			query.WriteString("(?, ?, ?, ?, ?)")
+			args = append(args, planID, stepID, j, criteria[j], j < len(met) && met[j])
+		}
 
+		if _, err := tx.Exec(query.String(), args...); err != nil {
+			return fmt.Errorf("failed to insert acceptance criteria for step '%s' in plan '%s': %w", stepID, planID, err)
+		}
+	}
 	return nil
 }
 
 // Remove deletes plans from the database by their names (IDs).
-// It relies on "ON DELETE CASCADE" foreign key constraints to remove associated steps and criteria.
+// It relies on "ON DELETE CASCADE" foreign key constraints to remove associated steps and criteria,
+// and removes the status history of the plans, so that a new plan with the same name starts without one.
 // It returns a map where keys are plan names and values are errors encountered during deletion (nil on success).
+// The removed plans are recorded in the operations log and can be restored with Undo.
+// The transaction is retried with backoff while the database is busy.
 func (p *Planner) Remove(planNames []string) map[string]error {
+	var results map[string]error
+	withRetry(maxTransactionAttempts, func() error {
+		results = p.remove(planNames, "remove")
+		for _, err := range results {
+			if isBusyError(err) {
+				return err
+			}
+		}
+		return nil
+	})
+	return results
+}
+
+// RemoveErr removes the plans like Remove, but returns a single error joining
+// the errors of all plans that could not be removed, in the order of
+// planNames, or nil if every plan was removed. Since the plans are removed in
+// one transaction, none of them is removed if any removal fails.
+func (p *Planner) RemoveErr(planNames []string) error {
+	results := p.Remove(planNames)
+
+	// Errors not tied to a plan, e.g. a failed commit, come first
+	errs := []error{results["_"]}
+	seen := make(map[string]bool)
+	for _, name := range planNames {
+		if !seen[name] {
+			seen[name] = true
+			errs = append(errs, results[name])
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// remove runs a single attempt of Remove, logging the removal under the name operation.
+func (p *Planner) remove(planNames []string, operation string) map[string]error {
 	results := make(map[string]error)
+
 	tx, err := p.db.Begin() // Start a transaction for potentially multiple deletes
 	if err != nil {
 		// If we can't even begin a transaction, report a general error.
@@ -590,6 +2992,18 @@ func (p *Planner) Remove(planNames []string) map[string]error {
 	}
 	defer tx.Rollback() // Ensure rollback on error
 
+	// Record the plans in the same transaction that deletes them, so that
+	// Undo restores exactly what was removed, including changes committed
+	// just before the removal
+	snapshots, err := snapshotPlans(tx, planNames, 0)
+	if err == nil {
+		err = addHistory(tx, snapshots)
+	}
+	if err != nil {
+		results["_"] = fmt.Errorf("failed to record plans for remove: %w", err)
+		return results
+	}
+
 	stmt, err := tx.Prepare("DELETE FROM plans WHERE id = ?")
 	if err != nil {
 		results["_"] = fmt.Errorf("failed to prepare delete statement: %w", err)
@@ -607,6 +3021,8 @@ func (p *Planner) Remove(planNames []string) map[string]error {
 		if rowsAffected == 0 {
 			// Optionally report this as an error or warning
 			results[name] = fmt.Errorf("plan '%s' not found for deletion", name)
+		} else if err := deleteHistory(tx, name); err != nil {
+			results[name] = err
 		} else {
 			results[name] = nil // Mark as success
 		}
@@ -622,6 +3038,10 @@ func (p *Planner) Remove(planNames []string) map[string]error {
 	}
 
 	if !hasErrors {
+		if err := logOperation(tx, &loggedOperation{name: operation, plans: snapshots}); err != nil {
+			results["_"] = err
+			return results
+		}
 		if err := tx.Commit(); err != nil {
 			results["_"] = fmt.Errorf("failed to commit transaction for remove: %w", err)
 			// If commit fails, the actual outcome is uncertain. Mark all non-errored as failed?
@@ -638,9 +3058,46 @@ func (p *Planner) Remove(planNames []string) map[string]error {
 	return results
 }
 
+// RemoveAll deletes every plan, including archived ones, together with their
+// steps, acceptance criteria, references, tags and status history in a single transaction.
+// Unlike Remove, the removal is not recorded in the operations log, so the
+// deleted data does not stay behind in the database.
+// It returns the number of plans removed.
+func (p *Planner) RemoveAll() (int, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction for remove all: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	result, err := tx.Exec("DELETE FROM plans")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete plans: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted plans: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM step_status_history"); err != nil {
+		return 0, fmt.Errorf("failed to delete status history: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction for remove all: %w", err)
+	}
+	return int(removed), nil
+}
+
 // Compact removes all completed plans from the database.
 // A plan is completed if it has no steps or all its steps are marked as 'DONE'.
+// The removed plans are recorded in the operations log and can be restored with Undo.
+// The compaction is retried with backoff while the database is busy.
 func (p *Planner) Compact() error {
+	return withRetry(maxTransactionAttempts, p.compact)
+}
+
+// compact runs a single attempt of Compact.
+func (p *Planner) compact() error {
 	query := `
         SELECT p.id
         FROM plans p
@@ -671,10 +3128,11 @@ func (p *Planner) Compact() error {
 		return nil // Nothing to compact
 	}
 
-	// Use the existing Remove method which handles transactions and cascading deletes
-	// The Remove method returns a map of errors, but Compact just returns a single error.
+	// Use a single attempt of Remove, which handles transactions and cascading deletes;
+	// Compact itself is retried as a whole.
+	// Remove returns a map of errors, but Compact just returns a single error.
 	// We'll check the map for any errors.
-	removeResults := p.Remove(completedPlanIDs)
+	removeResults := p.remove(completedPlanIDs, "compact")
 
 	var firstError error
 	var errorCount int
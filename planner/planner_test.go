@@ -1,12 +1,24 @@
 package planner
 
 import (
+	"bytes"
+	"context"
 	"database/sql" // Import database/sql
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"reflect" // Will be used later for deep comparisons
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	sqlite3 "github.com/mattn/go-sqlite3"
 )
 
 // Helper function to set up a temporary database for testing
@@ -182,8 +194,8 @@ func TestPlanner_SaveAndGet(t *testing.T) {
 	}
 
 	// 2. Add steps to the in-memory plan
-	plan.AddStep("step1", "First step description", []string{"AC1.1", "AC1.2"}, []string{"https://example.com/doc1", "https://example.com/doc2"})
-	plan.AddStep("step2", "Second step", []string{"AC2.1"}, []string{"https://example.com/ref"})
+	plan.AddStep("step1", "First step description", []string{"AC1.1", "AC1.2"}, []Reference{{URL: "https://example.com/doc1"}, {URL: "https://example.com/doc2"}})
+	plan.AddStep("step2", "Second step", []string{"AC2.1"}, []Reference{{URL: "https://example.com/ref"}})
 	plan.AddStep("step3", "Third step", []string{"AC3.1"}, nil) // No references
 
 	// 3. Save the plan
@@ -223,7 +235,7 @@ func TestPlanner_SaveAndGet(t *testing.T) {
 	if !reflect.DeepEqual(step1.AcceptanceCriteria(), []string{"AC1.1", "AC1.2"}) {
 		t.Errorf("Step 1 Acceptance Criteria mismatch: got %v", step1.AcceptanceCriteria())
 	}
-	if !reflect.DeepEqual(step1.References(), []string{"https://example.com/doc1", "https://example.com/doc2"}) {
+	if !reflect.DeepEqual(step1.References(), []Reference{{URL: "https://example.com/doc1"}, {URL: "https://example.com/doc2"}}) {
 		t.Errorf("Step 1 References mismatch: got %v", step1.References())
 	}
 
@@ -241,7 +253,7 @@ func TestPlanner_SaveAndGet(t *testing.T) {
 	if !reflect.DeepEqual(step2.AcceptanceCriteria(), []string{"AC2.1"}) {
 		t.Errorf("Step 2 Acceptance Criteria mismatch: got %v", step2.AcceptanceCriteria())
 	}
-	if !reflect.DeepEqual(step2.References(), []string{"https://example.com/ref"}) {
+	if !reflect.DeepEqual(step2.References(), []Reference{{URL: "https://example.com/ref"}}) {
 		t.Errorf("Step 2 References mismatch: got %v", step2.References())
 	}
 
@@ -259,7 +271,7 @@ func TestPlanner_SaveAndGet(t *testing.T) {
 	if !reflect.DeepEqual(step3.AcceptanceCriteria(), []string{"AC3.1"}) {
 		t.Errorf("Step 3 Acceptance Criteria mismatch: got %v", step3.AcceptanceCriteria())
 	}
-	if !reflect.DeepEqual(step3.References(), []string{}) {
+	if !reflect.DeepEqual(step3.References(), []Reference{}) {
 		t.Errorf("Step 3 References mismatch (should be empty): got %v", step3.References())
 	}
 
@@ -270,7 +282,7 @@ func TestPlanner_SaveAndGet(t *testing.T) {
 	if err != nil {
 		t.Fatalf("MarkAsCompleted failed: %v", err)
 	}
-	retrievedPlan.AddStep("step4", "Fourth step", nil, []string{"https://example.com/newref"})
+	retrievedPlan.AddStep("step4", "Fourth step", nil, []Reference{{URL: "https://example.com/newref"}})
 
 	// Reorder (step4, step2, step3) - Note: step1 was removed
 	retrievedPlan.Reorder([]string{"step4", "step2", "step3"})
@@ -299,7 +311,7 @@ func TestPlanner_SaveAndGet(t *testing.T) {
 	if finalPlan.Steps[0].Status() != "TODO" {
 		t.Errorf("Final Step 1 Status mismatch (expected TODO)")
 	}
-	if !reflect.DeepEqual(finalPlan.Steps[0].References(), []string{"https://example.com/newref"}) {
+	if !reflect.DeepEqual(finalPlan.Steps[0].References(), []Reference{{URL: "https://example.com/newref"}}) {
 		t.Errorf("Final Step 1 References mismatch: got %v", finalPlan.Steps[0].References())
 	}
 	if finalPlan.Steps[1].ID() != "step2" {
@@ -308,7 +320,7 @@ func TestPlanner_SaveAndGet(t *testing.T) {
 	if finalPlan.Steps[1].Status() != "DONE" {
 		t.Errorf("Final Step 2 Status mismatch (expected DONE)")
 	}
-	if !reflect.DeepEqual(finalPlan.Steps[1].References(), []string{"https://example.com/ref"}) {
+	if !reflect.DeepEqual(finalPlan.Steps[1].References(), []Reference{{URL: "https://example.com/ref"}}) {
 		t.Errorf("Final Step 2 References mismatch: got %v", finalPlan.Steps[1].References())
 	}
 	if finalPlan.Steps[2].ID() != "step3" {
@@ -317,7 +329,7 @@ func TestPlanner_SaveAndGet(t *testing.T) {
 	if finalPlan.Steps[2].Status() != "TODO" {
 		t.Errorf("Final Step 3 Status mismatch (expected TODO)")
 	}
-	if !reflect.DeepEqual(finalPlan.Steps[2].References(), []string{}) {
+	if !reflect.DeepEqual(finalPlan.Steps[2].References(), []Reference{}) {
 		t.Errorf("Final Step 3 References mismatch (should be empty): got %v", finalPlan.Steps[2].References())
 	}
 	if finalPlan.isNew { // Should be false as it was retrieved from DB
@@ -473,19 +485,19 @@ func TestStep_References(t *testing.T) {
 	plan := &Plan{ID: "test-references-plan", Steps: []*Step{}}
 
 	// Test step with multiple references
-	plan.AddStep("step1", "Step 1 desc", nil, []string{"https://example.com/doc1", "https://github.com/repo", "https://docs.example.com"})
+	plan.AddStep("step1", "Step 1 desc", nil, []Reference{{URL: "https://example.com/doc1"}, {URL: "https://github.com/repo"}, {URL: "https://docs.example.com"}})
 	step1 := plan.Steps[0]
 	refs := step1.References()
-	expected := []string{"https://example.com/doc1", "https://github.com/repo", "https://docs.example.com"}
+	expected := []Reference{{URL: "https://example.com/doc1"}, {URL: "https://github.com/repo"}, {URL: "https://docs.example.com"}}
 	if !reflect.DeepEqual(refs, expected) {
 		t.Errorf("References() returned %v, want %v", refs, expected)
 	}
 
 	// Test step with single reference
-	plan.AddStep("step2", "Step 2 desc", nil, []string{"https://single.com"})
+	plan.AddStep("step2", "Step 2 desc", nil, []Reference{{URL: "https://single.com"}})
 	step2 := plan.Steps[1]
 	refs2 := step2.References()
-	expected2 := []string{"https://single.com"}
+	expected2 := []Reference{{URL: "https://single.com"}}
 	if !reflect.DeepEqual(refs2, expected2) {
 		t.Errorf("References() returned %v, want %v", refs2, expected2)
 	}
@@ -499,10 +511,10 @@ func TestStep_References(t *testing.T) {
 	}
 
 	// Test step with empty references slice
-	plan.AddStep("step4", "Step 4 desc", nil, []string{})
+	plan.AddStep("step4", "Step 4 desc", nil, []Reference{})
 	step4 := plan.Steps[3]
 	refs4 := step4.References()
-	if !reflect.DeepEqual(refs4, []string{}) {
+	if !reflect.DeepEqual(refs4, []Reference{}) {
 		t.Errorf("References() for step with empty references returned %v, want []", refs4)
 	}
 }
@@ -512,7 +524,7 @@ func TestPlan_AddStepWithReferences(t *testing.T) {
 	plan := &Plan{ID: "test-addstep-references", Steps: []*Step{}}
 
 	// Test adding step with multiple references
-	refs1 := []string{"https://example.com/guide", "https://api.docs.com", "https://tutorial.com"}
+	refs1 := []Reference{{URL: "https://example.com/guide"}, {URL: "https://api.docs.com"}, {URL: "https://tutorial.com"}}
 	plan.AddStep("step1", "First step", []string{"AC1"}, refs1)
 
 	if len(plan.Steps) != 1 {
@@ -538,9 +550,9 @@ func TestPlan_AddStepWithReferences(t *testing.T) {
 	}
 
 	// Test adding step with empty references
-	plan.AddStep("step3", "Third step", nil, []string{})
+	plan.AddStep("step3", "Third step", nil, []Reference{})
 	step3 := plan.Steps[2]
-	if !reflect.DeepEqual(step3.References(), []string{}) {
+	if !reflect.DeepEqual(step3.References(), []Reference{}) {
 		t.Errorf("Step with empty references should return empty slice, got %v", step3.References())
 	}
 }
@@ -559,18 +571,18 @@ func TestPlanner_ReferencesPersistence(t *testing.T) {
 	}
 
 	// Step with multiple references
-	refs1 := []string{"https://example.com/doc1", "https://github.com/user/repo", "https://wiki.example.com/page"}
+	refs1 := []Reference{{URL: "https://example.com/doc1"}, {URL: "https://github.com/user/repo"}, {URL: "https://wiki.example.com/page"}}
 	plan.AddStep("step1", "Step with multiple refs", []string{"AC1"}, refs1)
 
 	// Step with single reference
-	refs2 := []string{"https://single-ref.com"}
+	refs2 := []Reference{{URL: "https://single-ref.com"}}
 	plan.AddStep("step2", "Step with single ref", []string{"AC2"}, refs2)
 
 	// Step with no references
 	plan.AddStep("step3", "Step with no refs", []string{"AC3"}, nil)
 
 	// Step with empty references
-	plan.AddStep("step4", "Step with empty refs", []string{"AC4"}, []string{})
+	plan.AddStep("step4", "Step with empty refs", []string{"AC4"}, []Reference{})
 
 	// Save the plan
 	err = planner.Save(plan)
@@ -603,13 +615,13 @@ func TestPlanner_ReferencesPersistence(t *testing.T) {
 
 	// Check step3 references (should be empty)
 	step3 := retrievedPlan.Steps[2]
-	if !reflect.DeepEqual(step3.References(), []string{}) {
+	if !reflect.DeepEqual(step3.References(), []Reference{}) {
 		t.Errorf("Step3 references should be empty: got %v", step3.References())
 	}
 
 	// Check step4 references (should be empty)
 	step4 := retrievedPlan.Steps[3]
-	if !reflect.DeepEqual(step4.References(), []string{}) {
+	if !reflect.DeepEqual(step4.References(), []Reference{}) {
 		t.Errorf("Step4 references should be empty: got %v", step4.References())
 	}
 }
@@ -628,12 +640,12 @@ func TestPlanner_ReferencesOrder(t *testing.T) {
 	}
 
 	// References in specific order
-	orderedRefs := []string{
-		"https://first.com",
-		"https://second.com",
-		"https://third.com",
-		"https://fourth.com",
-		"https://fifth.com",
+	orderedRefs := []Reference{
+		{URL: "https://first.com"},
+		{URL: "https://second.com"},
+		{URL: "https://third.com"},
+		{URL: "https://fourth.com"},
+		{URL: "https://fifth.com"},
 	}
 	plan.AddStep("step1", "Step with ordered refs", nil, orderedRefs)
 
@@ -680,9 +692,9 @@ func TestPlanner_ReferencesWithPlanOperations(t *testing.T) {
 	}
 
 	// Add steps with references
-	plan.AddStep("step1", "First step", nil, []string{"https://step1.com"})
-	plan.AddStep("step2", "Second step", nil, []string{"https://step2-a.com", "https://step2-b.com"})
-	plan.AddStep("step3", "Third step", nil, []string{"https://step3.com"})
+	plan.AddStep("step1", "First step", nil, []Reference{{URL: "https://step1.com"}})
+	plan.AddStep("step2", "Second step", nil, []Reference{{URL: "https://step2-a.com"}, {URL: "https://step2-b.com"}})
+	plan.AddStep("step3", "Third step", nil, []Reference{{URL: "https://step3.com"}})
 
 	// Save initial state
 	err = planner.Save(plan)
@@ -700,7 +712,7 @@ func TestPlanner_ReferencesWithPlanOperations(t *testing.T) {
 	retrievedPlan.RemoveSteps([]string{"step2"})
 
 	// Add a new step with references
-	retrievedPlan.AddStep("step4", "Fourth step", nil, []string{"https://step4.com", "https://step4-alt.com"})
+	retrievedPlan.AddStep("step4", "Fourth step", nil, []Reference{{URL: "https://step4.com"}, {URL: "https://step4-alt.com"}})
 
 	// Reorder steps
 	retrievedPlan.Reorder([]string{"step4", "step1", "step3"})
@@ -725,11 +737,11 @@ func TestPlanner_ReferencesWithPlanOperations(t *testing.T) {
 	// Check order and references
 	expectedOrder := []struct {
 		id   string
-		refs []string
+		refs []Reference
 	}{
-		{"step4", []string{"https://step4.com", "https://step4-alt.com"}},
-		{"step1", []string{"https://step1.com"}},
-		{"step3", []string{"https://step3.com"}},
+		{"step4", []Reference{{URL: "https://step4.com"}, {URL: "https://step4-alt.com"}}},
+		{"step1", []Reference{{URL: "https://step1.com"}}},
+		{"step3", []Reference{{URL: "https://step3.com"}}},
 	}
 
 	for i, expected := range expectedOrder {
@@ -744,3 +756,4122 @@ func TestPlanner_ReferencesWithPlanOperations(t *testing.T) {
 }
 
 // --- Add tests for List, Remove, Compact, MarkAsComplete/Incomplete etc. ---
+
+// TestNewShared verifies that shared planners reuse one connection per path
+// and that the connection stays usable until the last planner is closed.
+func TestNewShared(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "shared.db")
+
+	first, err := NewShared(dbPath)
+	if err != nil {
+		t.Fatalf("NewShared failed: %v", err)
+	}
+	second, err := NewShared(dbPath)
+	if err != nil {
+		t.Fatalf("Second NewShared failed: %v", err)
+	}
+	if first.db != second.db {
+		t.Fatal("Expected shared planners for the same path to use the same connection")
+	}
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close of first shared planner failed: %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("Second Close of first shared planner should be a no-op, got: %v", err)
+	}
+
+	// The second planner must still be able to use the connection.
+	plan, err := second.Create("shared-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := second.Save(plan); err != nil {
+		t.Fatalf("Save through remaining shared planner failed: %v", err)
+	}
+
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close of second shared planner failed: %v", err)
+	}
+
+	// After all planners are closed a fresh connection is opened.
+	third, err := NewShared(dbPath)
+	if err != nil {
+		t.Fatalf("NewShared after release failed: %v", err)
+	}
+	defer third.Close()
+	if _, err := third.Get("shared-plan"); err != nil {
+		t.Fatalf("Get through reopened shared planner failed: %v", err)
+	}
+}
+
+// TestPlanner_ConcurrentPlannersSameFile verifies that two planners opened on the
+// same database file can interleave writes without "database is locked" errors.
+func TestPlanner_ConcurrentPlannersSameFile(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "concurrent.db")
+
+	first, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create first planner: %v", err)
+	}
+	defer first.Close()
+
+	second, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create second planner: %v", err)
+	}
+	defer second.Close()
+
+	var journalMode string
+	if err := first.db.QueryRow("PRAGMA journal_mode;").Scan(&journalMode); err != nil {
+		t.Fatalf("Failed to query journal mode: %v", err)
+	}
+	if journalMode != "wal" {
+		t.Errorf("journal_mode = %s, want wal", journalMode)
+	}
+
+	planA, err := first.Create("plan-a")
+	if err != nil {
+		t.Fatalf("Create plan-a failed: %v", err)
+	}
+	planB, err := second.Create("plan-b")
+	if err != nil {
+		t.Fatalf("Create plan-b failed: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		planA.AddStep(fmt.Sprintf("a-%d", i), "Step from first planner", nil, nil)
+		if err := first.Save(planA); err != nil {
+			t.Fatalf("Save through first planner failed at iteration %d: %v", i, err)
+		}
+		planB.AddStep(fmt.Sprintf("b-%d", i), "Step from second planner", nil, nil)
+		if err := second.Save(planB); err != nil {
+			t.Fatalf("Save through second planner failed at iteration %d: %v", i, err)
+		}
+	}
+
+	// Each planner sees the other's writes.
+	gotB, err := first.Get("plan-b")
+	if err != nil {
+		t.Fatalf("Get plan-b through first planner failed: %v", err)
+	}
+	if len(gotB.Steps) != 10 {
+		t.Errorf("plan-b has %d steps, want 10", len(gotB.Steps))
+	}
+	gotA, err := second.Get("plan-a")
+	if err != nil {
+		t.Fatalf("Get plan-a through second planner failed: %v", err)
+	}
+	if len(gotA.Steps) != 10 {
+		t.Errorf("plan-a has %d steps, want 10", len(gotA.Steps))
+	}
+}
+
+// TestPlanner_Clone tests copying a plan into a new plan with statuses reset.
+func TestPlanner_Clone(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	source, err := planner.Create("source")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	source.AddStep("step1", "First step", []string{"AC1"}, []Reference{{URL: "https://example.com/1"}})
+	source.AddStep("step2", "Second step", []string{"AC2.1", "AC2.2"}, nil)
+	if err := source.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	if err := planner.Save(source); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if _, err := planner.Clone("source", "copy"); err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+
+	copied, err := planner.Get("copy")
+	if err != nil {
+		t.Fatalf("Get of cloned plan failed: %v", err)
+	}
+	if len(copied.Steps) != 2 {
+		t.Fatalf("Cloned plan has %d steps, want 2", len(copied.Steps))
+	}
+	if copied.Steps[0].ID() != "step1" || copied.Steps[1].ID() != "step2" {
+		t.Errorf("Cloned step order mismatch: got %s, %s", copied.Steps[0].ID(), copied.Steps[1].ID())
+	}
+	for _, step := range copied.Steps {
+		if step.Status() != "TODO" {
+			t.Errorf("Cloned step %s has status %s, want TODO", step.ID(), step.Status())
+		}
+	}
+	if !reflect.DeepEqual(copied.Steps[0].References(), []Reference{{URL: "https://example.com/1"}}) {
+		t.Errorf("Cloned references mismatch: got %v", copied.Steps[0].References())
+	}
+	if !reflect.DeepEqual(copied.Steps[1].AcceptanceCriteria(), []string{"AC2.1", "AC2.2"}) {
+		t.Errorf("Cloned acceptance criteria mismatch: got %v", copied.Steps[1].AcceptanceCriteria())
+	}
+
+	// The source plan is left untouched.
+	original, err := planner.Get("source")
+	if err != nil {
+		t.Fatalf("Get of source plan failed: %v", err)
+	}
+	if original.Steps[0].Status() != "DONE" {
+		t.Errorf("Source step status changed to %s, want DONE", original.Steps[0].Status())
+	}
+
+	if _, err := planner.Clone("source", "copy"); err == nil {
+		t.Error("Expected error when cloning into an existing plan, got nil")
+	}
+	if _, err := planner.Clone("missing", "other"); err == nil {
+		t.Error("Expected error when cloning a non-existent plan, got nil")
+	}
+}
+
+// TestPlan_UpdateStep tests partial in-memory updates of a step.
+func TestPlan_UpdateStep(t *testing.T) {
+	plan := &Plan{ID: "test-update-step", Steps: []*Step{}}
+	plan.AddStep("step1", "Original", []string{"AC1"}, []Reference{{URL: "ref1"}})
+	plan.AddStep("step2", "Other", nil, nil)
+	if err := plan.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+
+	// Only the description changes.
+	description := "Updated"
+	if err := plan.UpdateStep("step1", &description, nil, nil); err != nil {
+		t.Fatalf("UpdateStep failed: %v", err)
+	}
+	step := plan.Steps[0]
+	if step.Description() != "Updated" {
+		t.Errorf("Description = %s, want Updated", step.Description())
+	}
+	if !reflect.DeepEqual(step.AcceptanceCriteria(), []string{"AC1"}) {
+		t.Errorf("Acceptance criteria changed unexpectedly: %v", step.AcceptanceCriteria())
+	}
+	if !reflect.DeepEqual(step.References(), []Reference{{URL: "ref1"}}) {
+		t.Errorf("References changed unexpectedly: %v", step.References())
+	}
+
+	// Criteria and references are replaced, status and position are kept.
+	if err := plan.UpdateStep("step1", nil, []string{"AC-new"}, []Reference{}); err != nil {
+		t.Fatalf("UpdateStep failed: %v", err)
+	}
+	if !reflect.DeepEqual(step.AcceptanceCriteria(), []string{"AC-new"}) {
+		t.Errorf("Acceptance criteria = %v, want [AC-new]", step.AcceptanceCriteria())
+	}
+	if len(step.References()) != 0 {
+		t.Errorf("References = %v, want empty", step.References())
+	}
+	if step.Status() != "DONE" {
+		t.Errorf("Status = %s, want DONE", step.Status())
+	}
+	if plan.Steps[0].ID() != "step1" {
+		t.Errorf("Step order changed: first step is %s", plan.Steps[0].ID())
+	}
+
+	if err := plan.UpdateStep("missing", &description, nil, nil); err == nil {
+		t.Error("Expected error when updating non-existent step, got nil")
+	}
+}
+
+// TestPlanner_Tags tests that tags persist through Save/Get and can be used to filter plans.
+func TestPlanner_Tags(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	tagged, err := planner.Create("tagged-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	tagged.AddTag("project-x")
+	tagged.AddTag("backend")
+	tagged.AddTag("project-x") // Duplicate is ignored
+	if err := planner.Save(tagged); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	untagged, err := planner.Create("untagged-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := planner.Save(untagged); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	retrieved, err := planner.Get("tagged-plan")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !reflect.DeepEqual(retrieved.Tags(), []string{"backend", "project-x"}) {
+		t.Errorf("Tags = %v, want [backend project-x]", retrieved.Tags())
+	}
+
+	plans, err := planner.ListByTag("project-x")
+	if err != nil {
+		t.Fatalf("ListByTag failed: %v", err)
+	}
+	if len(plans) != 1 || plans[0].Name != "tagged-plan" {
+		t.Errorf("ListByTag(project-x) = %v, want only tagged-plan", plans)
+	}
+
+	if !retrieved.RemoveTag("project-x") {
+		t.Error("RemoveTag returned false for an existing tag")
+	}
+	if retrieved.RemoveTag("missing") {
+		t.Error("RemoveTag returned true for a missing tag")
+	}
+	if err := planner.Save(retrieved); err != nil {
+		t.Fatalf("Save after RemoveTag failed: %v", err)
+	}
+
+	plans, err = planner.ListByTag("project-x")
+	if err != nil {
+		t.Fatalf("ListByTag failed: %v", err)
+	}
+	if len(plans) != 0 {
+		t.Errorf("ListByTag(project-x) after removal = %v, want none", plans)
+	}
+}
+
+// TestPlanner_TimeTracking tests that estimates and logged time persist and show up in Inspect.
+func TestPlanner_TimeTracking(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("time-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "Estimated step", nil, nil)
+	plan.Steps[0].SetEstimateMinutes(30)
+	plan.AddStep("step2", "Unestimated step", nil, nil)
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	retrieved, err := planner.Get("time-plan")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if err := retrieved.LogTime("step1", 20); err != nil {
+		t.Fatalf("LogTime failed: %v", err)
+	}
+	if err := retrieved.LogTime("step1", 25); err != nil {
+		t.Fatalf("LogTime failed: %v", err)
+	}
+	if err := retrieved.LogTime("missing", 5); err == nil {
+		t.Error("Expected error when logging time on non-existent step, got nil")
+	}
+	if err := planner.Save(retrieved); err != nil {
+		t.Fatalf("Save after LogTime failed: %v", err)
+	}
+
+	final, err := planner.Get("time-plan")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if final.Steps[0].EstimateMinutes() != 30 || final.Steps[0].ActualMinutes() != 45 {
+		t.Errorf("step1 time = %d/%d, want 45/30", final.Steps[0].ActualMinutes(), final.Steps[0].EstimateMinutes())
+	}
+	if final.Steps[1].EstimateMinutes() != 0 || final.Steps[1].ActualMinutes() != 0 {
+		t.Errorf("step2 time = %d/%d, want 0/0", final.Steps[1].ActualMinutes(), final.Steps[1].EstimateMinutes())
+	}
+
+	output := final.Inspect()
+	if !strings.Contains(output, "Time: 45 min spent of 30 min estimated") {
+		t.Errorf("Inspect output missing step time:\n%s", output)
+	}
+	if !strings.Contains(output, "Total time: 45 min spent of 30 min estimated") {
+		t.Errorf("Inspect output missing total time:\n%s", output)
+	}
+}
+
+// TestPlan_Progress tests counting completed steps.
+func TestPlan_Progress(t *testing.T) {
+	plan := &Plan{ID: "test-progress", Steps: []*Step{}}
+
+	done, total := plan.Progress()
+	if done != 0 || total != 0 {
+		t.Errorf("Progress of empty plan = %d/%d, want 0/0", done, total)
+	}
+
+	plan.AddStep("step1", "Step 1", nil, nil)
+	plan.AddStep("step2", "Step 2", nil, nil)
+	plan.AddStep("step3", "Step 3", nil, nil)
+	if err := plan.MarkAsCompleted("step2"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+
+	done, total = plan.Progress()
+	if done != 1 || total != 3 {
+		t.Errorf("Progress = %d/%d, want 1/3", done, total)
+	}
+}
+
+// TestPlan_FindStep tests looking up a single step by ID.
+func TestPlan_FindStep(t *testing.T) {
+	plan := &Plan{ID: "test-find-step", Steps: []*Step{}}
+	plan.AddStep("step1", "Step 1", nil, nil)
+	plan.AddStep("step2", "Step 2", nil, nil)
+
+	step, err := plan.FindStep("step2")
+	if err != nil {
+		t.Fatalf("FindStep failed: %v", err)
+	}
+	if step != plan.Steps[1] {
+		t.Errorf("FindStep returned %s, want step2", step.ID())
+	}
+
+	if _, err := plan.FindStep("missing"); err == nil {
+		t.Error("Expected error when finding non-existent step, got nil")
+	}
+}
+
+// TestPlanner_SaveManyAcceptanceCriteria tests that criteria spanning several
+// batched inserts keep their order.
+func TestPlanner_SaveManyAcceptanceCriteria(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	criteria := make([]string, maxCriteriaPerInsert*2+3)
+	for i := range criteria {
+		criteria[i] = fmt.Sprintf("criterion %d", i)
+	}
+
+	plan, err := planner.Create("many-criteria")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "Step with many criteria", criteria, nil)
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	// Saving again replaces the criteria instead of duplicating them.
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Second Save failed: %v", err)
+	}
+
+	retrieved, err := planner.Get("many-criteria")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !reflect.DeepEqual(retrieved.Steps[0].AcceptanceCriteria(), criteria) {
+		t.Errorf("Acceptance criteria mismatch after save: got %d criteria, want %d", len(retrieved.Steps[0].AcceptanceCriteria()), len(criteria))
+	}
+}
+
+// BenchmarkPlanner_Save measures saving a plan with many steps and acceptance
+// criteria. Criteria are written with one INSERT per step rather than one per criterion.
+func BenchmarkPlanner_Save(b *testing.B) {
+	planner, err := New(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatalf("Failed to create planner: %v", err)
+	}
+	defer planner.Close()
+
+	plan, err := planner.Create("bench-plan")
+	if err != nil {
+		b.Fatalf("Create failed: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		criteria := make([]string, 20)
+		for j := range criteria {
+			criteria[j] = fmt.Sprintf("criterion %d.%d", i, j)
+		}
+		plan.AddStep(fmt.Sprintf("step-%d", i), "Benchmark step", criteria, nil)
+	}
+	if err := planner.Save(plan); err != nil {
+		b.Fatalf("Initial Save failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := planner.Save(plan); err != nil {
+			b.Fatalf("Save failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkPlanner_ListSteps measures listing the steps with a given status
+// across many plans, with and without the indexes on steps(status) and
+// steps(plan_id, status). Most steps are done, so the indexes let the query
+// skip them instead of scanning the whole steps table.
+func BenchmarkPlanner_ListSteps(b *testing.B) {
+	populate := func(name string) *Planner {
+		planner, err := New(filepath.Join(b.TempDir(), name))
+		if err != nil {
+			b.Fatalf("Failed to create planner: %v", err)
+		}
+		for i := 0; i < 200; i++ {
+			plan, err := planner.Create(fmt.Sprintf("plan-%d", i))
+			if err != nil {
+				b.Fatalf("Create failed: %v", err)
+			}
+			for j := 0; j < 100; j++ {
+				plan.AddStep(fmt.Sprintf("step-%d", j), "Benchmark step", nil, nil)
+				if j > 0 {
+					plan.MarkAsCompleted(fmt.Sprintf("step-%d", j))
+				}
+			}
+			if err := planner.Save(plan); err != nil {
+				b.Fatalf("Save failed: %v", err)
+			}
+		}
+		return planner
+	}
+
+	indexed := populate("indexed.db")
+	defer indexed.Close()
+	unindexed := populate("unindexed.db")
+	defer unindexed.Close()
+	if _, err := unindexed.db.Exec("DROP INDEX idx_steps_status; DROP INDEX idx_steps_plan_status"); err != nil {
+		b.Fatalf("Failed to drop indexes: %v", err)
+	}
+
+	for _, bench := range []struct {
+		name    string
+		planner *Planner
+	}{{"indexed", indexed}, {"unindexed", unindexed}} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				steps, err := bench.planner.ListSteps("TODO")
+				if err != nil {
+					b.Fatalf("ListSteps failed: %v", err)
+				}
+				if len(steps) != 200 {
+					b.Fatalf("Expected 200 steps, got %d", len(steps))
+				}
+			}
+		})
+	}
+}
+
+// TestPlanner_List_CreatedAt tests that List reports when each plan was created.
+func TestPlanner_List_CreatedAt(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("created-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	plans, err := planner.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(plans) != 1 {
+		t.Fatalf("List returned %d plans, want 1", len(plans))
+	}
+	if plans[0].CreatedAt.IsZero() {
+		t.Error("CreatedAt is zero, want the plan's creation time")
+	}
+}
+
+// TestPlanner_Save_ConcurrentModification tests that saving a stale copy of a plan
+// fails instead of overwriting changes saved in the meantime.
+func TestPlanner_Save_ConcurrentModification(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	first, err := BuildPlan(planner, "versioned-plan", StepSpec{ID: "step1", Description: "Step 1"})
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	second, err := planner.Get("versioned-plan")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	first.AddStep("step2", "Step 2 from first editor", nil, nil)
+	if err := planner.Save(first); err != nil {
+		t.Fatalf("Save of first copy failed: %v", err)
+	}
+
+	second.AddStep("step3", "Step 3 from second editor", nil, nil)
+	err = planner.Save(second)
+	if !errors.Is(err, ErrConcurrentModification) {
+		t.Fatalf("Save of stale copy returned %v, want ErrConcurrentModification", err)
+	}
+
+	// The first editor can keep saving its up-to-date copy.
+	first.AddStep("step4", "Another step from first editor", nil, nil)
+	if err := planner.Save(first); err != nil {
+		t.Fatalf("Second save of first copy failed: %v", err)
+	}
+
+	final, err := planner.Get("versioned-plan")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	var ids []string
+	for _, step := range final.Steps {
+		ids = append(ids, step.ID())
+	}
+	if !reflect.DeepEqual(ids, []string{"step1", "step2", "step4"}) {
+		t.Errorf("Final steps = %v, want [step1 step2 step4]", ids)
+	}
+}
+
+// TestPlanner_BlockedSteps tests blocking and unblocking steps and how blocked
+// steps affect NextStep, IsCompleted and Inspect.
+func TestPlanner_BlockedSteps(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("blocked-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "Step 1", nil, nil)
+	plan.AddStep("step2", "Step 2", nil, nil)
+
+	if err := plan.MarkAsBlocked("step1", "waiting for API access"); err != nil {
+		t.Fatalf("MarkAsBlocked failed: %v", err)
+	}
+	if err := plan.MarkAsBlocked("step2", ""); err == nil {
+		t.Error("Expected error when blocking without a reason, got nil")
+	}
+	if err := plan.MarkAsBlocked("missing", "reason"); err == nil {
+		t.Error("Expected error when blocking non-existent step, got nil")
+	}
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	retrieved, err := planner.Get("blocked-plan")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	step1 := retrieved.Steps[0]
+	if step1.Status() != "BLOCKED" {
+		t.Errorf("step1 status = %s, want BLOCKED", step1.Status())
+	}
+	if step1.BlockedReason() != "waiting for API access" {
+		t.Errorf("step1 blocked reason = %q, want %q", step1.BlockedReason(), "waiting for API access")
+	}
+	if !strings.Contains(retrieved.Inspect(), "Blocked: waiting for API access") {
+		t.Errorf("Inspect output missing blocked reason:\n%s", retrieved.Inspect())
+	}
+
+	// NextStep skips the blocked step.
+	if next := retrieved.NextStep(); next == nil || next.ID() != "step2" {
+		t.Errorf("NextStep = %v, want step2", next)
+	}
+
+	// A plan with only blocked steps left has no next step but is not completed.
+	if err := retrieved.MarkAsCompleted("step2"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	if next := retrieved.NextStep(); next != nil {
+		t.Errorf("NextStep = %s, want nil", next.ID())
+	}
+	if retrieved.IsCompleted() {
+		t.Error("IsCompleted = true with a blocked step remaining, want false")
+	}
+
+	if err := retrieved.Unblock("step2"); err == nil {
+		t.Error("Expected error when unblocking a step that is not blocked, got nil")
+	}
+	if err := retrieved.Unblock("step1"); err != nil {
+		t.Fatalf("Unblock failed: %v", err)
+	}
+	if step1.Status() != "TODO" || step1.BlockedReason() != "" {
+		t.Errorf("After Unblock step1 = %s/%q, want TODO with no reason", step1.Status(), step1.BlockedReason())
+	}
+	if err := planner.Save(retrieved); err != nil {
+		t.Fatalf("Save after Unblock failed: %v", err)
+	}
+}
+
+// TestPlanner_Stats tests aggregate statistics across plans.
+func TestPlanner_Stats(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	stats, err := planner.Stats()
+	if err != nil {
+		t.Fatalf("Stats on empty database failed: %v", err)
+	}
+	if stats != (Stats{}) {
+		t.Errorf("Stats on empty database = %+v, want zero value", stats)
+	}
+
+	done, err := planner.Create("done-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	done.AddStep("step1", "Step 1", nil, nil)
+	done.MarkAsCompleted("step1")
+	if err := planner.Save(done); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	active, err := planner.Create("active-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	active.AddStep("step1", "Step 1", nil, nil)
+	active.AddStep("step2", "Step 2", nil, nil)
+	active.AddStep("step3", "Step 3", nil, nil)
+	active.MarkAsCompleted("step1")
+	active.MarkAsBlocked("step2", "waiting")
+	if err := planner.Save(active); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	empty, err := planner.Create("empty-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := planner.Save(empty); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	stats, err = planner.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	want := Stats{
+		TotalPlans:          3,
+		CompletedPlans:      1,
+		TotalSteps:          4,
+		DoneSteps:           2,
+		TodoSteps:           1,
+		BlockedSteps:        1,
+		AverageStepsPerPlan: 4.0 / 3.0,
+	}
+	if stats != want {
+		t.Errorf("Stats = %+v, want %+v", stats, want)
+	}
+}
+
+// TestNewWithOptionsLogger tests that a configured logger receives executed SQL and its arguments.
+func TestNewWithOptionsLogger(t *testing.T) {
+	var buf bytes.Buffer
+	dbPath := filepath.Join(t.TempDir(), "verbose.db")
+	p, err := NewWithOptions(dbPath, Options{Logger: log.New(&buf, "", 0)})
+	if err != nil {
+		t.Fatalf("NewWithOptions failed: %v", err)
+	}
+	defer p.Close()
+
+	plan, err := p.Create("logged-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("s1", "Logged step", []string{"AC1"}, nil)
+	if err := p.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := p.Get("logged-plan"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "INSERT INTO plans") {
+		t.Errorf("Expected plan insert to be logged, got:\n%s", output)
+	}
+	if !strings.Contains(output, "logged-plan") {
+		t.Errorf("Expected statement arguments to be logged, got:\n%s", output)
+	}
+	if !strings.Contains(output, "SELECT") {
+		t.Errorf("Expected queries to be logged, got:\n%s", output)
+	}
+}
+
+// TestPlanner_Archive tests that archived plans are hidden from List until unarchived.
+func TestPlanner_Archive(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, name := range []string{"active-plan", "old-plan"} {
+		plan, err := planner.Create(name)
+		if err != nil {
+			t.Fatalf("Create(%s) failed: %v", name, err)
+		}
+		if err := planner.Save(plan); err != nil {
+			t.Fatalf("Save(%s) failed: %v", name, err)
+		}
+	}
+
+	if err := planner.Archive("old-plan"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+
+	plans, err := planner.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(plans) != 1 || plans[0].Name != "active-plan" {
+		t.Errorf("List() = %v, want only active-plan", plans)
+	}
+
+	plans, err = planner.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll failed: %v", err)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("ListAll() returned %d plans, want 2", len(plans))
+	}
+	for _, info := range plans {
+		if info.Archived != (info.Name == "old-plan") {
+			t.Errorf("Plan '%s' has Archived = %v", info.Name, info.Archived)
+		}
+	}
+
+	// Archived plans can still be retrieved
+	if _, err := planner.Get("old-plan"); err != nil {
+		t.Errorf("Get of archived plan failed: %v", err)
+	}
+
+	if err := planner.Unarchive("old-plan"); err != nil {
+		t.Fatalf("Unarchive failed: %v", err)
+	}
+	plans, err = planner.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(plans) != 2 {
+		t.Errorf("List() after Unarchive returned %d plans, want 2", len(plans))
+	}
+
+	if err := planner.Archive("missing-plan"); err == nil {
+		t.Error("Expected error when archiving a non-existent plan")
+	}
+}
+
+// TestPlan_MoveStep tests moving single steps relative to others and to either end of the plan.
+func TestPlan_MoveStep(t *testing.T) {
+	plan := &Plan{ID: "test-move-step", Steps: []*Step{}}
+	for _, id := range []string{"step1", "step2", "step3", "step4"} {
+		plan.AddStep(id, "Step "+id, nil, nil)
+	}
+
+	stepIDs := func() []string {
+		var ids []string
+		for _, step := range plan.Steps {
+			ids = append(ids, step.ID())
+		}
+		return ids
+	}
+
+	testCases := []struct {
+		id       string
+		position Position
+		want     []string
+	}{
+		{"step4", Position{ToTop: true}, []string{"step4", "step1", "step2", "step3"}},
+		{"step1", Position{After: "step3"}, []string{"step4", "step2", "step3", "step1"}},
+		{"step2", Position{Before: "step4"}, []string{"step2", "step4", "step3", "step1"}},
+		{"step4", Position{ToBottom: true}, []string{"step2", "step3", "step1", "step4"}},
+	}
+	for _, tc := range testCases {
+		if err := plan.MoveStep(tc.id, tc.position); err != nil {
+			t.Fatalf("MoveStep(%s, %+v) failed: %v", tc.id, tc.position, err)
+		}
+		if got := stepIDs(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("After MoveStep(%s, %+v) order = %v, want %v", tc.id, tc.position, got, tc.want)
+		}
+	}
+
+	if err := plan.MoveStep("missing", Position{ToTop: true}); err == nil {
+		t.Error("Expected error when moving non-existent step")
+	}
+	if err := plan.MoveStep("step1", Position{Before: "missing"}); err == nil {
+		t.Error("Expected error when anchor step does not exist")
+	}
+	if err := plan.MoveStep("step1", Position{}); err == nil {
+		t.Error("Expected error when no position is given")
+	}
+	if err := plan.MoveStep("step1", Position{ToTop: true, After: "step2"}); err == nil {
+		t.Error("Expected error when several positions are given")
+	}
+	if got, want := stepIDs(), []string{"step2", "step3", "step1", "step4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Failed moves changed the order to %v, want %v", got, want)
+	}
+}
+
+// TestPlanner_TitledReferences tests parsing, rendering and persisting references with titles.
+func TestPlanner_TitledReferences(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	parsed := []Reference{
+		ParseReference("Design doc | https://example.com/design"),
+		ParseReference("https://example.com/plain"),
+	}
+	want := []Reference{
+		{Title: "Design doc", URL: "https://example.com/design"},
+		{URL: "https://example.com/plain"},
+	}
+	if !reflect.DeepEqual(parsed, want) {
+		t.Fatalf("ParseReference results = %v, want %v", parsed, want)
+	}
+	if got := parsed[0].String(); got != "[Design doc](https://example.com/design)" {
+		t.Errorf("String() of titled reference = %q", got)
+	}
+	if got := parsed[1].String(); got != "https://example.com/plain" {
+		t.Errorf("String() of untitled reference = %q", got)
+	}
+
+	plan, err := planner.Create("titled-refs")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "Step with titled refs", nil, parsed)
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	retrieved, err := planner.Get("titled-refs")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !reflect.DeepEqual(retrieved.Steps[0].References(), want) {
+		t.Errorf("Persisted references = %v, want %v", retrieved.Steps[0].References(), want)
+	}
+	if !strings.Contains(retrieved.Inspect(), "1. [Design doc](https://example.com/design)") {
+		t.Errorf("Inspect() does not render titled reference as a link:\n%s", retrieved.Inspect())
+	}
+}
+
+// TestStep_MarshalJSON tests the JSON encoding shared by the MCP tool and the CLI.
+func TestStep_MarshalJSON(t *testing.T) {
+	plan := &Plan{ID: "test-json", Steps: []*Step{}}
+	plan.AddStep("step1", "Step 1", []string{"AC1"}, []Reference{{Title: "Doc", URL: "https://example.com"}, {URL: "/tmp/file"}})
+
+	data, err := json.Marshal(plan.Steps[0])
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"acceptance_criteria":["AC1"],"description":"Step 1","id":"step1","references":["Doc|https://example.com","/tmp/file"],"status":"TODO"}`
+	if string(data) != want {
+		t.Errorf("Marshal(step) = %s, want %s", data, want)
+	}
+
+	var nilStep *Step
+	data, err = json.Marshal(nilStep)
+	if err != nil {
+		t.Fatalf("Marshal of nil step failed: %v", err)
+	}
+	if string(data) != "null" {
+		t.Errorf("Marshal(nil step) = %s, want null", data)
+	}
+}
+
+// TestPlan_AddSteps tests adding several steps at once and that invalid specs leave the plan unchanged.
+func TestPlan_AddSteps(t *testing.T) {
+	plan := &Plan{ID: "test-add-steps", Steps: []*Step{}}
+	plan.AddStep("existing", "Existing step", nil, nil)
+
+	err := plan.AddSteps([]StepSpec{
+		{ID: "step1", Description: "Step 1", AcceptanceCriteria: []string{"AC1"}, References: []string{"Doc|https://example.com"}},
+		{ID: "step2", Description: "Step 2"},
+	})
+	if err != nil {
+		t.Fatalf("AddSteps failed: %v", err)
+	}
+	if len(plan.Steps) != 3 || plan.Steps[1].ID() != "step1" || plan.Steps[2].ID() != "step2" {
+		t.Fatalf("Unexpected steps after AddSteps: %v", plan.Steps)
+	}
+	if want := []Reference{{Title: "Doc", URL: "https://example.com"}}; !reflect.DeepEqual(plan.Steps[1].References(), want) {
+		t.Errorf("References = %v, want %v", plan.Steps[1].References(), want)
+	}
+
+	invalid := [][]StepSpec{
+		{{ID: "step3", Description: "Step 3"}, {ID: "existing", Description: "Duplicate of existing"}},
+		{{ID: "step3", Description: "Step 3"}, {ID: "step3", Description: "Duplicate in batch"}},
+		{{ID: "step3", Description: "Step 3"}, {Description: "Missing ID"}},
+		{{ID: "step3"}},
+	}
+	for _, specs := range invalid {
+		if err := plan.AddSteps(specs); err == nil {
+			t.Errorf("Expected error for specs %v", specs)
+		}
+		if len(plan.Steps) != 3 {
+			t.Fatalf("Failed AddSteps(%v) modified the plan, now has %d steps", specs, len(plan.Steps))
+		}
+	}
+}
+
+// TestPlanner_Save_DuplicateStepID tests that Save rejects plans with duplicate step IDs without writing anything.
+func TestPlanner_Save_DuplicateStepID(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("duplicate-steps")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "First", nil, nil)
+	plan.AddStep("step2", "Second", nil, nil)
+	plan.AddStep("step1", "First again", nil, nil)
+
+	err = planner.Save(plan)
+	if !errors.Is(err, ErrDuplicateStepID) {
+		t.Fatalf("Save returned %v, want ErrDuplicateStepID", err)
+	}
+	if !strings.Contains(err.Error(), "step1") {
+		t.Errorf("Error %q does not name the duplicate step", err)
+	}
+
+	if _, err := planner.Get("duplicate-steps"); err == nil {
+		t.Error("Expected plan not to be saved after duplicate step ID error")
+	}
+}
+
+// TestPlanner_RemoveAll tests that all plans and their steps are removed and counted.
+func TestPlanner_RemoveAll(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, name := range []string{"plan1", "plan2", "plan3"} {
+		plan, err := planner.Create(name)
+		if err != nil {
+			t.Fatalf("Create(%s) failed: %v", name, err)
+		}
+		plan.AddStep("step1", "Step", []string{"AC"}, []Reference{{URL: "https://example.com"}})
+		if err := planner.Save(plan); err != nil {
+			t.Fatalf("Save(%s) failed: %v", name, err)
+		}
+	}
+	if err := planner.Archive("plan3"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+
+	removed, err := planner.RemoveAll()
+	if err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("RemoveAll removed %d plans, want 3", removed)
+	}
+
+	plans, err := planner.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll failed: %v", err)
+	}
+	if len(plans) != 0 {
+		t.Errorf("Expected no plans after RemoveAll, got %v", plans)
+	}
+
+	var steps int
+	if err := planner.db.QueryRow("SELECT COUNT(*) FROM steps").Scan(&steps); err != nil {
+		t.Fatalf("Counting steps failed: %v", err)
+	}
+	if steps != 0 {
+		t.Errorf("Expected steps to be removed with their plans, %d left", steps)
+	}
+
+	removed, err = planner.RemoveAll()
+	if err != nil || removed != 0 {
+		t.Errorf("RemoveAll on empty database = %d, %v; want 0, nil", removed, err)
+	}
+}
+
+// TestWithRetry tests that only busy errors are retried.
+func TestWithRetry(t *testing.T) {
+	defer func(delay time.Duration) { retryBaseDelay = delay }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+
+	busy := fmt.Errorf("wrapped: %w", sqlite3.Error{Code: sqlite3.ErrBusy})
+	calls := 0
+	err := withRetry(3, func() error {
+		calls++
+		return busy
+	})
+	if !errors.Is(err, busy) || calls != 3 {
+		t.Errorf("withRetry with busy errors returned %v after %d calls, want busy error after 3 calls", err, calls)
+	}
+
+	other := errors.New("other failure")
+	calls = 0
+	err = withRetry(3, func() error {
+		calls++
+		return other
+	})
+	if err != other || calls != 1 {
+		t.Errorf("withRetry with other error returned %v after %d calls, want it after 1 call", err, calls)
+	}
+
+	calls = 0
+	err = withRetry(3, func() error {
+		calls++
+		if calls < 2 {
+			return busy
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("withRetry returned %v after %d calls, want nil after 2 calls", err, calls)
+	}
+}
+
+// TestPlanner_Save_RetriesWhileLocked tests that Save succeeds once another
+// connection releases its write lock.
+func TestPlanner_Save_RetriesWhileLocked(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "locked.db")
+	planner, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer planner.Close()
+
+	// Fail immediately on a locked database so that only the retries can make Save succeed.
+	planner.db.SetMaxOpenConns(1)
+	if _, err := planner.db.Exec("PRAGMA busy_timeout = 0"); err != nil {
+		t.Fatalf("Failed to disable busy timeout: %v", err)
+	}
+
+	other, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open second connection: %v", err)
+	}
+	defer other.Close()
+	conn, err := other.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get second connection: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(context.Background(), "BEGIN IMMEDIATE"); err != nil {
+		t.Fatalf("Failed to take write lock: %v", err)
+	}
+	released := make(chan error, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, err := conn.ExecContext(context.Background(), "COMMIT")
+		released <- err
+	}()
+
+	plan, err := planner.Create("locked-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "Step", nil, nil)
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save while locked failed: %v", err)
+	}
+	if err := <-released; err != nil {
+		t.Fatalf("Failed to release write lock: %v", err)
+	}
+
+	if _, err := planner.Get("locked-plan"); err != nil {
+		t.Errorf("Get after retried Save failed: %v", err)
+	}
+}
+
+// TestPlanner_GetMany tests that GetMany loads the same plans as Get with a fixed number of queries.
+func TestPlanner_GetMany(t *testing.T) {
+	var buf bytes.Buffer
+	planner, err := NewWithOptions(filepath.Join(t.TempDir(), "get-many.db"), Options{Logger: log.New(&buf, "", 0)})
+	if err != nil {
+		t.Fatalf("NewWithOptions failed: %v", err)
+	}
+	defer planner.Close()
+
+	names := []string{"plan-a", "plan-b", "empty-plan"}
+	for i, name := range names[:2] {
+		plan, err := planner.Create(name)
+		if err != nil {
+			t.Fatalf("Create(%s) failed: %v", name, err)
+		}
+		plan.AddTag("dashboard")
+		for j := 0; j < 3; j++ {
+			plan.AddStep(fmt.Sprintf("step%d", j), fmt.Sprintf("Step %d of %s", j, name),
+				[]string{"AC1", "AC2"}, []Reference{{Title: "Doc", URL: fmt.Sprintf("https://example.com/%d/%d", i, j)}})
+		}
+		plan.Reorder([]string{"step2", "step0", "step1"})
+		if err := plan.MarkAsCompleted("step2"); err != nil {
+			t.Fatalf("MarkAsCompleted failed: %v", err)
+		}
+		if err := planner.Save(plan); err != nil {
+			t.Fatalf("Save(%s) failed: %v", name, err)
+		}
+	}
+	empty, err := planner.Create("empty-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := planner.Save(empty); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	buf.Reset()
+	plans, err := planner.GetMany(append(names, "missing-plan"))
+	if err != nil {
+		t.Fatalf("GetMany failed: %v", err)
+	}
+	if queries := strings.Count(buf.String(), "SELECT"); queries != 5 {
+		t.Errorf("GetMany issued %d queries, want 5", queries)
+	}
+
+	if len(plans) != len(names) {
+		t.Fatalf("GetMany returned %d plans, want %d", len(plans), len(names))
+	}
+	if _, ok := plans["missing-plan"]; ok {
+		t.Error("Expected missing plan to be absent from GetMany result")
+	}
+	for _, name := range names {
+		want, err := planner.Get(name)
+		if err != nil {
+			t.Fatalf("Get(%s) failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(plans[name], want) {
+			t.Errorf("GetMany plan %s differs from Get:\ngot:  %s\nwant: %s", name, plans[name].Inspect(), want.Inspect())
+		}
+	}
+
+	plans, err = planner.GetMany(nil)
+	if err != nil || len(plans) != 0 {
+		t.Errorf("GetMany(nil) = %v, %v; want empty map", plans, err)
+	}
+}
+
+// TestPlanner_CompletedAt tests that completion times are recorded, persisted and cleared.
+func TestPlanner_CompletedAt(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("completion-times")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "First", nil, nil)
+	plan.AddStep("step2", "Second", nil, nil)
+
+	if _, ok := plan.Steps[0].CompletedAt(); ok {
+		t.Error("Expected new step to have no completion time")
+	}
+
+	before := time.Now().Add(-time.Second)
+	if err := plan.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	completedAt, ok := plan.Steps[0].CompletedAt()
+	if !ok || completedAt.Before(before) || completedAt.After(time.Now().Add(time.Second)) {
+		t.Fatalf("CompletedAt() = %v, %v; want the current time", completedAt, ok)
+	}
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	retrieved, err := planner.Get("completion-times")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	loaded, ok := retrieved.Steps[0].CompletedAt()
+	if !ok || !loaded.Equal(completedAt) {
+		t.Errorf("Loaded CompletedAt() = %v, %v; want %v", loaded, ok, completedAt)
+	}
+	if _, ok := retrieved.Steps[1].CompletedAt(); ok {
+		t.Error("Expected TODO step to have no completion time")
+	}
+	if !strings.Contains(retrieved.Inspect(), "Completed: ") {
+		t.Errorf("Inspect() does not show completion time:\n%s", retrieved.Inspect())
+	}
+
+	// Completing an already completed step keeps the original time
+	if err := retrieved.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	if again, _ := retrieved.Steps[0].CompletedAt(); !again.Equal(completedAt) {
+		t.Errorf("CompletedAt() changed to %v after completing again, want %v", again, completedAt)
+	}
+
+	if err := retrieved.MarkAsIncomplete("step1"); err != nil {
+		t.Fatalf("MarkAsIncomplete failed: %v", err)
+	}
+	if err := planner.Save(retrieved); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	var stored sql.NullTime
+	if err := planner.db.QueryRow("SELECT completed_at FROM steps WHERE plan_id = ? AND id = ?", "completion-times", "step1").Scan(&stored); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if stored.Valid {
+		t.Errorf("Expected completed_at to be NULL after marking incomplete, got %v", stored.Time)
+	}
+}
+
+// TestPlanner_SetStepStatus tests updating a single step's status without saving the whole plan.
+func TestPlanner_SetStepStatus(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("single-step")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "First", []string{"Done right"}, nil)
+	plan.AddStep("step2", "Second", nil, nil)
+	if err := plan.MarkAsBlocked("step2", "waiting"); err != nil {
+		t.Fatalf("MarkAsBlocked failed: %v", err)
+	}
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	stale, err := planner.Get("single-step")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	if err := planner.SetStepStatus("single-step", "step1", "done"); err != nil {
+		t.Fatalf("SetStepStatus(DONE) failed: %v", err)
+	}
+	if err := planner.SetStepStatus("single-step", "step2", "TODO"); err != nil {
+		t.Fatalf("SetStepStatus(TODO) failed: %v", err)
+	}
+
+	retrieved, err := planner.Get("single-step")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if retrieved.Steps[0].Status() != "DONE" {
+		t.Errorf("step1 status = %s, want DONE", retrieved.Steps[0].Status())
+	}
+	if _, ok := retrieved.Steps[0].CompletedAt(); !ok {
+		t.Error("Expected step1 to have a completion time")
+	}
+	if !reflect.DeepEqual(retrieved.Steps[0].AcceptanceCriteria(), []string{"Done right"}) {
+		t.Errorf("step1 acceptance criteria = %v, want unchanged", retrieved.Steps[0].AcceptanceCriteria())
+	}
+	if retrieved.Steps[1].Status() != "TODO" || retrieved.Steps[1].BlockedReason() != "" {
+		t.Errorf("step2 = %s (%q), want TODO without a blocked reason", retrieved.Steps[1].Status(), retrieved.Steps[1].BlockedReason())
+	}
+
+	if err := planner.Save(stale); !errors.Is(err, ErrConcurrentModification) {
+		t.Errorf("Save of stale copy returned %v, want ErrConcurrentModification", err)
+	}
+
+	if err := planner.SetStepStatus("single-step", "step1", "TODO"); err != nil {
+		t.Fatalf("SetStepStatus(TODO) failed: %v", err)
+	}
+	retrieved, err = planner.Get("single-step")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if _, ok := retrieved.Steps[0].CompletedAt(); ok {
+		t.Error("Expected completion time to be cleared")
+	}
+
+	if err := planner.SetStepStatus("single-step", "step1", "BLOCKED"); err == nil {
+		t.Error("Expected an error for an unsupported status")
+	}
+	if err := planner.SetStepStatus("single-step", "missing", "DONE"); err == nil {
+		t.Error("Expected an error for a missing step")
+	}
+	if err := planner.SetStepStatus("missing", "step1", "DONE"); err == nil {
+		t.Error("Expected an error for a missing plan")
+	}
+}
+
+// TestPlanner_ListByStatus tests filtering plan summaries by their computed status.
+func TestPlanner_ListByStatus(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	done, err := planner.Create("done-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	done.AddStep("step1", "First", nil, nil)
+	if err := done.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	todo, err := planner.Create("todo-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	todo.AddStep("step1", "First", nil, nil)
+	empty, err := planner.Create("empty-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, plan := range []*Plan{done, todo, empty} {
+		if err := planner.Save(plan); err != nil {
+			t.Fatalf("Save(%s) failed: %v", plan.ID, err)
+		}
+	}
+
+	names := func(plans []PlanInfo) []string {
+		var result []string
+		for _, info := range plans {
+			result = append(result, info.Name)
+		}
+		return result
+	}
+
+	plans, err := planner.ListByStatus("done")
+	if err != nil {
+		t.Fatalf("ListByStatus(done) failed: %v", err)
+	}
+	if got := names(plans); !reflect.DeepEqual(got, []string{"done-plan"}) {
+		t.Errorf("ListByStatus(done) = %v, want [done-plan]", got)
+	}
+
+	plans, err = planner.ListByStatus("TODO")
+	if err != nil {
+		t.Fatalf("ListByStatus(TODO) failed: %v", err)
+	}
+	if got := names(plans); !reflect.DeepEqual(got, []string{"empty-plan", "todo-plan"}) {
+		t.Errorf("ListByStatus(TODO) = %v, want [empty-plan todo-plan]", got)
+	}
+
+	if _, err := planner.ListByStatus("blocked"); err == nil {
+		t.Error("Expected an error for an invalid status")
+	}
+}
+
+// TestMakePlannerResources tests listing and reading plans as MCP resources.
+func TestMakePlannerResources(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "resources.db")
+	planner, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer planner.Close()
+
+	plan, err := planner.Create("my plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "Write the docs", []string{"Docs are published"}, nil)
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	info, err := MakePlannerResources(dbPath)
+	if err != nil {
+		t.Fatalf("MakePlannerResources failed: %v", err)
+	}
+
+	resources, err := info.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(resources) != 1 || resources[0].URI != "plan://my%20plan" || resources[0].Name != "my plan" {
+		t.Fatalf("List() = %+v, want one resource plan://my%%20plan", resources)
+	}
+
+	var req mcp.ReadResourceRequest
+	req.Params.URI = resources[0].URI
+	contents, err := info.Handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+	if len(contents) != 1 {
+		t.Fatalf("Handler returned %d contents, want 1", len(contents))
+	}
+	text, ok := contents[0].(mcp.TextResourceContents)
+	if !ok || text.Text != plan.Inspect() || text.MIMEType != "text/plain" {
+		t.Errorf("Handler returned %+v, want the inspected plan as plain text", contents[0])
+	}
+
+	req.Params.URI = PlanURI("missing")
+	if _, err := info.Handler(context.Background(), req); err == nil {
+		t.Error("Expected an error for a missing plan")
+	}
+}
+
+// TestPlanner_Touch tests that Touch bumps updated_at without changing the plan.
+func TestPlanner_Touch(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Insert rows with old timestamps directly; updating them would fire the
+	// updated_at triggers and reset the timestamps to now.
+	old := "2000-01-01 00:00:00"
+	if _, err := planner.db.Exec("INSERT INTO plans (id, created_at, updated_at) VALUES (?, ?, ?)", "touched", old, old); err != nil {
+		t.Fatalf("Failed to insert plan: %v", err)
+	}
+	if _, err := planner.db.Exec("INSERT INTO steps (id, plan_id, description, status, step_order, updated_at) VALUES (?, ?, ?, ?, ?, ?)", "step1", "touched", "First", "TODO", 0, old); err != nil {
+		t.Fatalf("Failed to insert step: %v", err)
+	}
+
+	plan, err := planner.Get("touched")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	if err := planner.Touch("touched"); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+
+	plans, err := planner.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(plans) != 1 || plans[0].UpdatedAt.Year() == 2000 {
+		t.Errorf("List() = %+v, want updated_at to be bumped", plans)
+	}
+	if plans[0].CreatedAt.Year() != 2000 {
+		t.Errorf("CreatedAt = %v, want it unchanged", plans[0].CreatedAt)
+	}
+
+	var stepUpdatedAt time.Time
+	if err := planner.db.QueryRow("SELECT updated_at FROM steps WHERE plan_id = ?", "touched").Scan(&stepUpdatedAt); err != nil {
+		t.Fatalf("Failed to query steps.updated_at: %v", err)
+	}
+	if stepUpdatedAt.Year() != 2000 {
+		t.Errorf("steps.updated_at = %v, want it unchanged", stepUpdatedAt)
+	}
+
+	// The version is unchanged, so the plan loaded before can still be saved
+	if err := planner.Save(plan); err != nil {
+		t.Errorf("Save after Touch failed: %v", err)
+	}
+
+	if err := planner.Touch("missing"); !errors.Is(err, ErrPlanNotFound) {
+		t.Errorf("Touch(missing) returned %v, want ErrPlanNotFound", err)
+	}
+}
+
+// TestPlan_AddStepChecked tests that invalid and duplicate step IDs are rejected.
+func TestPlan_AddStepChecked(t *testing.T) {
+	plan := &Plan{ID: "checked"}
+	if err := plan.AddStepChecked("step-1", "First", nil, nil); err != nil {
+		t.Fatalf("AddStepChecked failed: %v", err)
+	}
+
+	invalid := []string{
+		"",
+		"   ",
+		" padded",
+		"--flag",
+		"line\nbreak",
+		strings.Repeat("x", MaxStepIDLength+1),
+		"step-1", // duplicate
+	}
+	for _, id := range invalid {
+		if err := plan.AddStepChecked(id, "Invalid", nil, nil); err == nil {
+			t.Errorf("AddStepChecked(%q) succeeded, want an error", id)
+		}
+	}
+	if len(plan.Steps) != 1 {
+		t.Errorf("Plan has %d steps, want 1", len(plan.Steps))
+	}
+
+	if err := plan.AddStepChecked(strings.Repeat("x", MaxStepIDLength), "Long", nil, nil); err != nil {
+		t.Errorf("AddStepChecked with an ID of maximum length failed: %v", err)
+	}
+	if err := plan.AddSteps([]StepSpec{{ID: "-x", Description: "Flag-like"}}); err == nil {
+		t.Error("AddSteps with a flag-like ID succeeded, want an error")
+	}
+}
+
+// TestPlanner_HealthCheck tests that a fresh database is healthy and that problems are reported.
+func TestPlanner_HealthCheck(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	report, err := planner.HealthCheck()
+	if err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+	if !report.OK() {
+		t.Errorf("HealthCheck() found problems in a fresh database: %v", report.Problems)
+	}
+	if report.SQLiteVersion == "" || report.JournalMode != "wal" || !report.ForeignKeys {
+		t.Errorf("HealthCheck() = %+v, want a version, WAL and foreign keys", report)
+	}
+
+	// Keep the single connection so the pragma applies to the next check
+	planner.db.SetMaxOpenConns(1)
+	if _, err := planner.db.Exec("PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatalf("Failed to disable foreign keys: %v", err)
+	}
+	if _, err := planner.db.Exec("DROP TABLE plan_tags"); err != nil {
+		t.Fatalf("Failed to drop table: %v", err)
+	}
+
+	report, err = planner.HealthCheck()
+	if err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+	if report.OK() || report.ForeignKeys {
+		t.Errorf("HealthCheck() = %+v, want foreign keys reported as disabled", report)
+	}
+	if !reflect.DeepEqual(report.MissingTables, []string{"plan_tags"}) {
+		t.Errorf("MissingTables = %v, want [plan_tags]", report.MissingTables)
+	}
+	if len(report.Problems) != 2 {
+		t.Errorf("Problems = %v, want 2 problems", report.Problems)
+	}
+}
+
+// failingWriter accepts a number of writes and then fails.
+type failingWriter struct {
+	remaining int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.remaining == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.remaining--
+	return len(p), nil
+}
+
+// TestPlan_WriteInspect tests that WriteInspect streams the same text as Inspect and reports write errors.
+func TestPlan_WriteInspect(t *testing.T) {
+	plan := &Plan{ID: "streamed"}
+	plan.AddStep("step1", "First", []string{"Works"}, []Reference{{Title: "Docs", URL: "https://example.com"}})
+	plan.AddStep("step2", "Second", nil, nil)
+	plan.Steps[1].SetEstimateMinutes(30)
+
+	var buf bytes.Buffer
+	if err := plan.WriteInspect(&buf); err != nil {
+		t.Fatalf("WriteInspect failed: %v", err)
+	}
+	if buf.String() != plan.Inspect() {
+		t.Errorf("WriteInspect wrote:\n%s\nwant Inspect output:\n%s", buf.String(), plan.Inspect())
+	}
+	if !strings.Contains(buf.String(), "1. [Docs](https://example.com)") {
+		t.Errorf("WriteInspect output is missing the reference:\n%s", buf.String())
+	}
+
+	if err := plan.WriteInspect(&failingWriter{remaining: 3}); err == nil || err.Error() != "disk full" {
+		t.Errorf("WriteInspect to a failing writer returned %v, want the write error", err)
+	}
+}
+
+// TestPlan_ResetAll tests that all steps are set back to TODO and the change is persisted.
+func TestPlan_ResetAll(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("runbook")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "First", nil, nil)
+	plan.AddStep("step2", "Second", nil, nil)
+	plan.AddStep("step3", "Third", nil, nil)
+	if err := plan.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	if err := plan.MarkAsBlocked("step2", "waiting"); err != nil {
+		t.Fatalf("MarkAsBlocked failed: %v", err)
+	}
+	if err := plan.LogTime("step1", 15); err != nil {
+		t.Fatalf("LogTime failed: %v", err)
+	}
+
+	if reset := plan.ResetAll(); reset != 2 {
+		t.Errorf("ResetAll() = %d, want 2", reset)
+	}
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	retrieved, err := planner.Get("runbook")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	for _, step := range retrieved.Steps {
+		if step.Status() != "TODO" || step.BlockedReason() != "" {
+			t.Errorf("Step '%s' = %s (%q), want TODO without a blocked reason", step.ID(), step.Status(), step.BlockedReason())
+		}
+		if _, ok := step.CompletedAt(); ok {
+			t.Errorf("Step '%s' still has a completion time", step.ID())
+		}
+	}
+	if retrieved.Steps[0].ActualMinutes() != 15 {
+		t.Errorf("ActualMinutes() = %d, want logged time to be kept", retrieved.Steps[0].ActualMinutes())
+	}
+	if reset := retrieved.ResetAll(); reset != 0 {
+		t.Errorf("ResetAll() on a reset plan = %d, want 0", reset)
+	}
+}
+
+// TestPlan_RemainingCount tests counting the steps that are not done.
+func TestPlan_RemainingCount(t *testing.T) {
+	plan := &Plan{ID: "remaining"}
+	if got := plan.RemainingCount(); got != 0 {
+		t.Errorf("RemainingCount() of an empty plan = %d, want 0", got)
+	}
+
+	plan.AddStep("step1", "First", nil, nil)
+	plan.AddStep("step2", "Second", nil, nil)
+	plan.AddStep("step3", "Third", nil, nil)
+	if err := plan.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	if err := plan.MarkAsBlocked("step3", "waiting"); err != nil {
+		t.Fatalf("MarkAsBlocked failed: %v", err)
+	}
+	if got := plan.RemainingCount(); got != 2 {
+		t.Errorf("RemainingCount() = %d, want 2", got)
+	}
+}
+
+// TestNew_InMemory tests that ":memory:" databases work without touching the file system.
+func TestNew_InMemory(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+	before, err := os.ReadDir(wd)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+
+	first, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New(:memory:) failed: %v", err)
+	}
+	defer first.Close()
+
+	plan, err := first.Create("ephemeral")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "First", []string{"Done"}, []Reference{{URL: "https://example.com"}})
+	if err := first.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	retrieved, err := first.Get("ephemeral")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if retrieved.Inspect() != plan.Inspect() {
+		t.Errorf("Get() returned a different plan:\n%s\nwant:\n%s", retrieved.Inspect(), plan.Inspect())
+	}
+
+	// Every New on ":memory:" gets a fresh database
+	second, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New(:memory:) failed: %v", err)
+	}
+	defer second.Close()
+	if _, err := second.Get("ephemeral"); err == nil {
+		t.Error("Expected a second in-memory planner not to see the first one's plans")
+	}
+
+	after, err := os.ReadDir(wd)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("Directory %s has %d entries after opening in-memory databases, want %d", wd, len(after), len(before))
+	}
+}
+
+// TestPlanner_Vacuum tests that vacuuming shrinks the database file after plans are removed.
+func TestPlanner_Vacuum(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "vacuum.db")
+	planner, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer planner.Close()
+
+	for i := 0; i < 20; i++ {
+		plan, err := planner.Create(fmt.Sprintf("plan-%d", i))
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		plan.AddStep("step1", strings.Repeat("x", 10000), nil, nil)
+		if err := planner.Save(plan); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	if _, err := planner.RemoveAll(); err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+	if _, err := planner.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	before, err := os.Stat(dbPath)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+
+	if err := planner.Vacuum(); err != nil {
+		t.Fatalf("Vacuum failed: %v", err)
+	}
+
+	after, err := os.Stat(dbPath)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if after.Size() >= before.Size() {
+		t.Errorf("Database size after Vacuum = %d bytes, want less than %d bytes", after.Size(), before.Size())
+	}
+
+	// The planner is still usable afterwards
+	if _, err := planner.List(); err != nil {
+		t.Errorf("List after Vacuum failed: %v", err)
+	}
+}
+
+// TestStep_AddRemoveCriterion tests editing a step's acceptance criteria and persisting the result.
+func TestStep_AddRemoveCriterion(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	criteria := []string{"first", "second"}
+	plan, err := planner.Create("criteria")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "First", criteria, nil)
+	step := plan.Steps[0]
+
+	step.AddCriterion("third")
+	if err := step.RemoveCriterion(1); err != nil {
+		t.Fatalf("RemoveCriterion(1) failed: %v", err)
+	}
+	if !reflect.DeepEqual(criteria, []string{"first", "second"}) {
+		t.Errorf("RemoveCriterion modified the caller's slice: %v", criteria)
+	}
+	for _, index := range []int{0, 3, -1} {
+		if err := step.RemoveCriterion(index); err == nil {
+			t.Errorf("RemoveCriterion(%d) succeeded, want an out-of-range error", index)
+		}
+	}
+
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	retrieved, err := planner.Get("criteria")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	want := []string{"second", "third"}
+	if got := retrieved.Steps[0].AcceptanceCriteria(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AcceptanceCriteria() = %v, want %v", got, want)
+	}
+}
+
+// TestPlan_ToDOT tests the Graphviz output for a plan.
+func TestPlan_ToDOT(t *testing.T) {
+	plan := &Plan{ID: "graph"}
+	plan.AddStep("step1", "First", nil, nil)
+	plan.AddStep("step2", `Say "hi"`, nil, nil)
+	plan.AddStep("step3", "Third", nil, nil)
+	if err := plan.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	if err := plan.MarkAsBlocked("step3", "waiting"); err != nil {
+		t.Fatalf("MarkAsBlocked failed: %v", err)
+	}
+
+	dot := plan.ToDOT()
+	for _, want := range []string{
+		"digraph \"graph\" {\n",
+		`"step1" [label="step1\n[DONE]", tooltip="First", fillcolor="palegreen"];`,
+		`"step2" [label="step2\n[TODO]", tooltip="Say \"hi\"", fillcolor="white"];`,
+		`"step3" [label="step3\n[BLOCKED]", tooltip="Third", fillcolor="lightsalmon"];`,
+		`"step1" -> "step2";`,
+		`"step2" -> "step3";`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("ToDOT() is missing %q:\n%s", want, dot)
+		}
+	}
+	if strings.Count(dot, "->") != 2 {
+		t.Errorf("ToDOT() has %d edges, want 2:\n%s", strings.Count(dot, "->"), dot)
+	}
+	if !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("ToDOT() is not terminated:\n%s", dot)
+	}
+}
+
+// TestPlanner_Merge tests appending the steps of one plan to another.
+func TestPlanner_Merge(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	dest, err := planner.Create("dest")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	dest.AddStep("setup", "Set up", nil, nil)
+	if err := planner.Save(dest); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	src, err := planner.Create("src")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	src.AddStep("setup", "Set up again", []string{"AC1"}, nil)
+	src.AddStep("build", "Build", []string{"AC2"}, []Reference{{URL: "https://example.com/build"}})
+	if err := src.MarkAsCompleted("build"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	if err := planner.Save(src); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	err = planner.Merge("dest", "src")
+	if !errors.Is(err, ErrDuplicateStepID) {
+		t.Fatalf("Merge with conflicting step IDs returned %v, want ErrDuplicateStepID", err)
+	}
+	unchanged, err := planner.Get("dest")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(unchanged.Steps) != 1 {
+		t.Errorf("Failed merge changed destination plan to %d steps, want 1", len(unchanged.Steps))
+	}
+
+	if err := planner.MergeWithOptions("dest", "src", MergeOptions{RenameConflicts: true, RemoveSource: true}); err != nil {
+		t.Fatalf("MergeWithOptions failed: %v", err)
+	}
+
+	merged, err := planner.Get("dest")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	var ids []string
+	for _, step := range merged.Steps {
+		ids = append(ids, step.ID())
+	}
+	if want := []string{"setup", "setup-2", "build"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Merged step IDs = %v, want %v", ids, want)
+	}
+	if got := merged.Steps[1].AcceptanceCriteria(); !reflect.DeepEqual(got, []string{"AC1"}) {
+		t.Errorf("Merged acceptance criteria = %v, want [AC1]", got)
+	}
+	if merged.Steps[2].Status() != "DONE" {
+		t.Errorf("Merged step status = %s, want DONE", merged.Steps[2].Status())
+	}
+	if got := merged.Steps[2].References(); !reflect.DeepEqual(got, []Reference{{URL: "https://example.com/build"}}) {
+		t.Errorf("Merged references = %v", got)
+	}
+
+	if _, err := planner.Get("src"); err == nil {
+		t.Error("Expected source plan to be removed, but Get succeeded")
+	}
+
+	if err := planner.Merge("dest", "dest"); err == nil {
+		t.Error("Expected error when merging a plan into itself, got nil")
+	}
+	if err := planner.Merge("dest", "missing"); err == nil {
+		t.Error("Expected error when merging a non-existent plan, got nil")
+	}
+}
+
+// TestPlanner_Undo tests reverting logged operations.
+func TestPlanner_Undo(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, err := planner.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("Undo on empty log returned %v, want ErrNothingToUndo", err)
+	}
+
+	plan, err := planner.Create("undo")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "First", []string{"AC1"}, []Reference{{Title: "Docs", URL: "https://example.com"}})
+	plan.AddStep("step2", "Second", nil, nil)
+	plan.AddTag("work")
+	if err := plan.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Undo removing a step
+	plan.RemoveSteps([]string{"step1"})
+	if err := planner.SaveLogged(plan, "remove-steps"); err != nil {
+		t.Fatalf("SaveLogged failed: %v", err)
+	}
+	op, err := planner.Undo()
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if op.Name != "remove-steps" || !reflect.DeepEqual(op.Plans, []string{"undo"}) {
+		t.Errorf("Undo returned %+v, want remove-steps of [undo]", op)
+	}
+	restored, err := planner.Get("undo")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(restored.Steps) != 2 || restored.Steps[0].ID() != "step1" {
+		t.Fatalf("Restored plan has %d steps, want step1 and step2", len(restored.Steps))
+	}
+	if restored.Steps[0].Status() != "DONE" {
+		t.Errorf("Restored step status = %s, want DONE", restored.Steps[0].Status())
+	}
+	if got := restored.Steps[0].AcceptanceCriteria(); !reflect.DeepEqual(got, []string{"AC1"}) {
+		t.Errorf("Restored acceptance criteria = %v, want [AC1]", got)
+	}
+	if got := restored.Steps[0].References(); !reflect.DeepEqual(got, []Reference{{Title: "Docs", URL: "https://example.com"}}) {
+		t.Errorf("Restored references = %v", got)
+	}
+	if !reflect.DeepEqual(restored.Tags(), []string{"work"}) {
+		t.Errorf("Restored tags = %v, want [work]", restored.Tags())
+	}
+
+	// The copy loaded before the undo is stale
+	plan.AddStep("step3", "Third", nil, nil)
+	if err := planner.Save(plan); !errors.Is(err, ErrConcurrentModification) {
+		t.Errorf("Save of stale plan returned %v, want ErrConcurrentModification", err)
+	}
+
+	// Undo removing an archived plan
+	if err := planner.Archive("undo"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if errs := planner.Remove([]string{"undo"}); errs["undo"] != nil {
+		t.Fatalf("Remove failed: %v", errs["undo"])
+	}
+	if _, err := planner.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	all, err := planner.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll failed: %v", err)
+	}
+	if len(all) != 1 || all[0].Name != "undo" || !all[0].Archived || all[0].TotalTasks != 2 {
+		t.Errorf("ListAll after undoing remove = %+v, want archived plan 'undo' with 2 steps", all)
+	}
+
+	// Undo compacting
+	if err := planner.SetStepStatus("undo", "step2", "DONE"); err != nil {
+		t.Fatalf("SetStepStatus failed: %v", err)
+	}
+	if err := planner.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if op, err := planner.Undo(); err != nil || op.Name != "compact" {
+		t.Fatalf("Undo returned %+v, %v, want compact", op, err)
+	}
+	if _, err := planner.Get("undo"); err != nil {
+		t.Errorf("Get after undoing compact failed: %v", err)
+	}
+
+	if _, err := planner.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Undo after reverting everything returned %v, want ErrNothingToUndo", err)
+	}
+}
+
+// TestPlanner_ListPaged tests listing plans one page at a time.
+func TestPlanner_ListPaged(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, name := range []string{"charlie", "alpha", "echo", "bravo", "delta"} {
+		plan, err := planner.Create(name)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := planner.Save(plan); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	if err := planner.Archive("bravo"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+
+	var names []string
+	for offset := 0; ; offset += 2 {
+		page, err := planner.ListPaged(2, offset)
+		if err != nil {
+			t.Fatalf("ListPaged(2, %d) failed: %v", offset, err)
+		}
+		if len(page) > 2 {
+			t.Fatalf("ListPaged(2, %d) returned %d plans", offset, len(page))
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, info := range page {
+			names = append(names, info.Name)
+		}
+	}
+	if want := []string{"alpha", "charlie", "delta", "echo"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Paged plan names = %v, want %v", names, want)
+	}
+
+	if _, err := planner.ListPaged(0, 0); err == nil {
+		t.Error("Expected error for limit 0, got nil")
+	}
+	if _, err := planner.ListPaged(1, -1); err == nil {
+		t.Error("Expected error for negative offset, got nil")
+	}
+}
+
+// TestPlanner_List_CompletionPercent tests the completion percentage of plan summaries.
+func TestPlanner_List_CompletionPercent(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	empty, err := planner.Create("empty")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := planner.Save(empty); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	partial, err := planner.Create("partial")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	partial.AddStep("step1", "First", nil, nil)
+	partial.AddStep("step2", "Second", nil, nil)
+	partial.AddStep("step3", "Third", nil, nil)
+	partial.AddStep("step4", "Fourth", nil, nil)
+	if err := partial.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	if err := planner.Save(partial); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	plans, err := planner.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	percents := make(map[string]float64)
+	for _, info := range plans {
+		percents[info.Name] = info.CompletionPercent
+	}
+	if percents["empty"] != 0 {
+		t.Errorf("CompletionPercent of plan without tasks = %v, want 0", percents["empty"])
+	}
+	if percents["partial"] != 25 {
+		t.Errorf("CompletionPercent of plan with 1/4 tasks done = %v, want 25", percents["partial"])
+	}
+}
+
+// TestPlan_ToJSON tests the JSON output for a plan.
+func TestPlan_ToJSON(t *testing.T) {
+	plan := &Plan{ID: "json", tags: []string{"work"}}
+	plan.AddStep("step1", "First", []string{"AC1"}, []Reference{{Title: "Docs", URL: "https://example.com"}})
+	if err := plan.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+
+	output, err := plan.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+
+	var decoded struct {
+		ID    string   `json:"id"`
+		Tags  []string `json:"tags"`
+		Steps []struct {
+			ID                 string   `json:"id"`
+			Status             string   `json:"status"`
+			AcceptanceCriteria []string `json:"acceptance_criteria"`
+			References         []string `json:"references"`
+		} `json:"steps"`
+	}
+	if err := json.Unmarshal(output, &decoded); err != nil {
+		t.Fatalf("ToJSON output is not valid JSON: %v\n%s", err, output)
+	}
+	if decoded.ID != "json" || !reflect.DeepEqual(decoded.Tags, []string{"work"}) || len(decoded.Steps) != 1 {
+		t.Fatalf("ToJSON() = %s", output)
+	}
+	step := decoded.Steps[0]
+	if step.ID != "step1" || step.Status != "DONE" {
+		t.Errorf("Step = %+v, want step1 with status DONE", step)
+	}
+	if !reflect.DeepEqual(step.AcceptanceCriteria, []string{"AC1"}) {
+		t.Errorf("Acceptance criteria = %v, want [AC1]", step.AcceptanceCriteria)
+	}
+	if !reflect.DeepEqual(step.References, []string{"Docs|https://example.com"}) {
+		t.Errorf("References = %v, want [Docs|https://example.com]", step.References)
+	}
+}
+
+// TestPlan_ExportMarkdown tests the markdown checklist for a plan.
+func TestPlan_ExportMarkdown(t *testing.T) {
+	plan := &Plan{ID: "checklist"}
+	plan.AddStep("step1", "First", []string{"AC1"}, nil)
+	plan.AddStep("step2", "Second", nil, []Reference{{URL: "https://example.com"}})
+	plan.AddStep("step3", "", nil, nil)
+	if err := plan.MarkAsCompleted("step1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	if err := plan.MarkAsBlocked("step3", "waiting"); err != nil {
+		t.Fatalf("MarkAsBlocked failed: %v", err)
+	}
+
+	want := "# checklist\n\n" +
+		"- [x] **step1**: First\n" +
+		"  - AC1\n" +
+		"- [ ] **step2**: Second\n" +
+		"  - See https://example.com\n" +
+		"- [ ] **step3** (blocked: waiting)\n"
+	if got := plan.ExportMarkdown(); got != want {
+		t.Errorf("ExportMarkdown() = %q, want %q", got, want)
+	}
+}
+
+// TestPlanner_Exists tests checking whether a plan exists.
+func TestPlanner_Exists(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	exists, err := planner.Exists("present")
+	if err != nil || exists {
+		t.Fatalf("Exists before save = %v, %v, want false, nil", exists, err)
+	}
+
+	plan, err := planner.Create("present")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := planner.Archive("present"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+
+	exists, err = planner.Exists("present")
+	if err != nil || !exists {
+		t.Errorf("Exists of archived plan = %v, %v, want true, nil", exists, err)
+	}
+
+	// A failing query is reported as an error rather than as a missing plan
+	if _, err := planner.db.Exec("ALTER TABLE plans RENAME TO plans_renamed"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if _, err := planner.Exists("present"); err == nil {
+		t.Error("Expected error when the plans table is missing, got nil")
+	}
+}
+
+// TestPlan_Validate tests plan-wide validation and its use in Save.
+func TestPlan_Validate(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	valid := &Plan{ID: "valid"}
+	valid.AddStep("step1", "First", nil, []Reference{{URL: "https://example.com"}})
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate of valid plan failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		plan *Plan
+	}{
+		{"empty plan ID", &Plan{ID: " "}},
+		{"empty step ID", &Plan{ID: "p", Steps: []*Step{{id: "", status: "TODO"}}}},
+		{"invalid status", &Plan{ID: "p", Steps: []*Step{{id: "step1", status: "todo"}}}},
+		{"blank reference", &Plan{ID: "p", Steps: []*Step{{id: "step1", status: "TODO", references: []Reference{{Title: "Docs", URL: " "}}}}}},
+		{"duplicate step ID", &Plan{ID: "p", Steps: []*Step{{id: "step1", status: "TODO"}, {id: "step1", status: "DONE"}}}},
+	}
+	for _, tt := range tests {
+		if err := tt.plan.Validate(); err == nil {
+			t.Errorf("Validate with %s returned nil, want error", tt.name)
+		}
+	}
+	if err := tests[4].plan.Validate(); !errors.Is(err, ErrDuplicateStepID) {
+		t.Errorf("Validate with duplicate step ID returned %v, want ErrDuplicateStepID", err)
+	}
+
+	// Save rejects invalid plans before writing anything
+	invalid, err := planner.Create("invalid")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	invalid.AddStep("step1", "First", nil, []Reference{{URL: ""}})
+	if err := planner.Save(invalid); err == nil {
+		t.Fatal("Save of invalid plan succeeded, want error")
+	}
+	exists, err := planner.Exists("invalid")
+	if err != nil || exists {
+		t.Errorf("Exists after rejected save = %v, %v, want false, nil", exists, err)
+	}
+}
+
+// TestStep_AddReference tests that references with the same URL are only stored once.
+func TestStep_AddReference(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("refs")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "First", nil, []Reference{
+		{URL: "https://a.example"},
+		{Title: "B", URL: "https://b.example"},
+		{Title: "A again", URL: "https://a.example"},
+	})
+
+	step := plan.Steps[0]
+	if step.AddReference(Reference{Title: "Duplicate", URL: "https://b.example"}) {
+		t.Error("AddReference of an existing URL returned true, want false")
+	}
+	if !step.AddReference(Reference{URL: "https://c.example"}) {
+		t.Error("AddReference of a new URL returned false, want true")
+	}
+
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	retrieved, err := planner.Get("refs")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	want := []Reference{
+		{URL: "https://a.example"},
+		{Title: "B", URL: "https://b.example"},
+		{URL: "https://c.example"},
+	}
+	if got := retrieved.Steps[0].References(); !reflect.DeepEqual(got, want) {
+		t.Errorf("References() = %v, want %v", got, want)
+	}
+}
+
+// TestStep_Order tests that steps report their position as stored in the database.
+func TestStep_Order(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("order-plan")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("a", "Step A", []string{"AC"}, nil)
+	plan.AddStep("b", "Step B", []string{"AC"}, nil)
+	plan.AddStep("c", "Step C", []string{"AC"}, nil)
+	plan.Reorder([]string{"c", "a"})
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	retrieved, err := planner.Get("order-plan")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	for i, id := range []string{"c", "a", "b"} {
+		step := retrieved.Steps[i]
+		if step.ID() != id || step.Order() != i {
+			t.Errorf("Steps[%d] = %s with Order() %d, want %s with Order() %d", i, step.ID(), step.Order(), id, i)
+		}
+	}
+}
+
+// TestPlan_RemoveCompleted tests removing all DONE steps from a plan.
+func TestPlan_RemoveCompleted(t *testing.T) {
+	plan := &Plan{ID: "prune", Steps: []*Step{}}
+	plan.AddStep("a", "Step A", []string{"AC"}, nil)
+	plan.AddStep("b", "Step B", []string{"AC"}, nil)
+	plan.AddStep("c", "Step C", []string{"AC"}, nil)
+	plan.AddStep("d", "Step D", []string{"AC"}, nil)
+	plan.MarkAsCompleted("a")
+	plan.MarkAsCompleted("c")
+	plan.MarkAsBlocked("d", "waiting")
+
+	if removed := plan.RemoveCompleted(); removed != 2 {
+		t.Errorf("RemoveCompleted() = %d, want 2", removed)
+	}
+	var ids []string
+	for _, step := range plan.Steps {
+		ids = append(ids, step.ID())
+	}
+	if want := []string{"b", "d"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("remaining steps = %v, want %v", ids, want)
+	}
+
+	if removed := plan.RemoveCompleted(); removed != 0 {
+		t.Errorf("second RemoveCompleted() = %d, want 0", removed)
+	}
+}
+
+// TestPlanner_CheckIntegrity tests that orphaned rows are reported.
+func TestPlanner_CheckIntegrity(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("integrity")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("step1", "Step 1", []string{"AC1"}, nil)
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	problems, err := planner.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity failed: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("CheckIntegrity() = %v, want no problems", problems)
+	}
+
+	// Delete the plan without cascading, as a tool that does not enforce foreign keys would
+	planner.db.SetMaxOpenConns(1)
+	if _, err := planner.db.Exec("PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatalf("Failed to disable foreign keys: %v", err)
+	}
+	if _, err := planner.db.Exec("DELETE FROM plans WHERE id = 'integrity'"); err != nil {
+		t.Fatalf("Failed to delete plan: %v", err)
+	}
+
+	problems, err = planner.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity failed: %v", err)
+	}
+	want := []string{"orphaned row in 'steps' (plan_id 'integrity', id 'step1') references a missing row in 'plans'"}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("CheckIntegrity() = %v, want %v", problems, want)
+	}
+}
+
+// TestPlanner_Templates tests saving a plan as a template and creating plans from it.
+func TestPlanner_Templates(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("release-1.0")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("build", "Build", []string{"Artifacts exist"}, []Reference{{Title: "CI", URL: "https://ci.example.com"}})
+	plan.AddStep("publish", "Publish", []string{"Release is public"}, nil)
+	plan.Steps[0].SetEstimateMinutes(30)
+	plan.MarkAsCompleted("build")
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if err := planner.SaveTemplate("release-1.0", "release"); err != nil {
+		t.Fatalf("SaveTemplate failed: %v", err)
+	}
+	if err := planner.SaveTemplate("release-1.0", "release"); err == nil {
+		t.Error("SaveTemplate with an existing template name succeeded, want error")
+	}
+	if err := planner.SaveTemplate("missing", "other"); err == nil {
+		t.Error("SaveTemplate of a missing plan succeeded, want error")
+	}
+
+	// Templates are not plans
+	plans, err := planner.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(plans) != 1 {
+		t.Errorf("List() returned %d plans, want 1", len(plans))
+	}
+
+	created, err := planner.InstantiateTemplate("release", "release-1.1")
+	if err != nil {
+		t.Fatalf("InstantiateTemplate failed: %v", err)
+	}
+	retrieved, err := planner.Get("release-1.1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(created.Steps) != 2 || len(retrieved.Steps) != 2 {
+		t.Fatalf("InstantiateTemplate created %d steps, stored %d, want 2", len(created.Steps), len(retrieved.Steps))
+	}
+	build := retrieved.Steps[0]
+	if build.ID() != "build" || build.Status() != "TODO" || build.EstimateMinutes() != 30 {
+		t.Errorf("first step = %s %s estimate %d, want build TODO estimate 30", build.ID(), build.Status(), build.EstimateMinutes())
+	}
+	if !reflect.DeepEqual(build.AcceptanceCriteria(), []string{"Artifacts exist"}) {
+		t.Errorf("AcceptanceCriteria() = %v, want [Artifacts exist]", build.AcceptanceCriteria())
+	}
+	if !reflect.DeepEqual(build.References(), []Reference{{Title: "CI", URL: "https://ci.example.com"}}) {
+		t.Errorf("References() = %v, want the CI reference", build.References())
+	}
+
+	if _, err := planner.InstantiateTemplate("release", "release-1.1"); err == nil {
+		t.Error("InstantiateTemplate into an existing plan succeeded, want error")
+	}
+	if _, err := planner.InstantiateTemplate("missing", "new-plan"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("InstantiateTemplate of a missing template = %v, want ErrTemplateNotFound", err)
+	}
+}
+
+// TestPlanner_ListAssigned tests storing step assignees and listing steps by assignee.
+func TestPlanner_ListAssigned(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, name := range []string{"beta", "alpha", "archived"} {
+		plan, err := planner.Create(name)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		plan.AddStep("one", "Step one", []string{"AC"}, nil)
+		plan.AddStep("two", "Step two", []string{"AC"}, nil)
+		plan.AddStep("three", "Step three", []string{"AC"}, nil)
+		plan.Steps[0].SetAssignee("bob")
+		plan.Steps[1].SetAssignee("alice")
+		plan.Steps[2].SetAssignee("alice")
+		if err := planner.Save(plan); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	if err := planner.Archive("archived"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+
+	retrieved, err := planner.Get("alpha")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got := retrieved.Steps[0].Assignee(); got != "bob" {
+		t.Errorf("Assignee() = %q, want bob", got)
+	}
+	if !strings.Contains(retrieved.Inspect(), "Assignee: alice") {
+		t.Errorf("Inspect() does not show the assignee:\n%s", retrieved.Inspect())
+	}
+
+	assigned, err := planner.ListAssigned("alice")
+	if err != nil {
+		t.Fatalf("ListAssigned failed: %v", err)
+	}
+	var got []string
+	for _, a := range assigned {
+		got = append(got, a.Plan+"/"+a.Step.ID())
+	}
+	want := []string{"alpha/two", "alpha/three", "beta/two", "beta/three"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListAssigned(alice) = %v, want %v", got, want)
+	}
+
+	assigned, err = planner.ListAssigned("carol")
+	if err != nil {
+		t.Fatalf("ListAssigned failed: %v", err)
+	}
+	if len(assigned) != 0 {
+		t.Errorf("ListAssigned(carol) returned %d steps, want none", len(assigned))
+	}
+}
+
+// TestPlanner_CompleteAndNext tests completing a step and getting the next one in one call.
+func TestPlanner_CompleteAndNext(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("complete-next")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddStep("one", "Step one", []string{"AC1"}, nil)
+	plan.AddStep("two", "Step two", []string{"AC2a", "AC2b"}, []Reference{{Title: "Doc", URL: "https://example.com"}})
+	plan.AddStep("three", "Step three", []string{"AC3"}, nil)
+	plan.MarkAsBlocked("three", "waiting")
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	next, err := planner.CompleteAndNext("complete-next", "one")
+	if err != nil {
+		t.Fatalf("CompleteAndNext failed: %v", err)
+	}
+	if next == nil || next.ID() != "two" {
+		t.Fatalf("CompleteAndNext() = %v, want step two", next)
+	}
+	if !reflect.DeepEqual(next.AcceptanceCriteria(), []string{"AC2a", "AC2b"}) {
+		t.Errorf("AcceptanceCriteria() = %v, want [AC2a AC2b]", next.AcceptanceCriteria())
+	}
+	if !reflect.DeepEqual(next.References(), []Reference{{Title: "Doc", URL: "https://example.com"}}) {
+		t.Errorf("References() = %v, want the Doc reference", next.References())
+	}
+	if next.Order() != 1 {
+		t.Errorf("Order() = %d, want 1", next.Order())
+	}
+
+	retrieved, err := planner.Get("complete-next")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if status := retrieved.Steps[0].Status(); status != "DONE" {
+		t.Errorf("status of step one = %s, want DONE", status)
+	}
+
+	// Only a blocked step is left
+	next, err = planner.CompleteAndNext("complete-next", "two")
+	if err != nil {
+		t.Fatalf("CompleteAndNext failed: %v", err)
+	}
+	if next != nil {
+		t.Errorf("CompleteAndNext() = %s, want nil", next.ID())
+	}
+
+	if _, err := planner.CompleteAndNext("complete-next", "missing"); err == nil {
+		t.Error("CompleteAndNext with a missing step succeeded, want error")
+	}
+	if _, err := planner.CompleteAndNext("missing", "one"); err == nil {
+		t.Error("CompleteAndNext with a missing plan succeeded, want error")
+	}
+}
+
+// TestPlanner_CountSteps tests counting the steps of a plan without loading it.
+func TestPlanner_CountSteps(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("count")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	total, done, err := planner.CountSteps("count")
+	if err != nil {
+		t.Fatalf("CountSteps failed: %v", err)
+	}
+	if total != 0 || done != 0 {
+		t.Errorf("CountSteps() of an empty plan = %d, %d, want 0, 0", total, done)
+	}
+
+	plan.AddStep("one", "Step one", []string{"AC"}, nil)
+	plan.AddStep("two", "Step two", []string{"AC"}, nil)
+	plan.AddStep("three", "Step three", []string{"AC"}, nil)
+	plan.MarkAsCompleted("one")
+	plan.MarkAsBlocked("three", "waiting")
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	total, done, err = planner.CountSteps("count")
+	if err != nil {
+		t.Fatalf("CountSteps failed: %v", err)
+	}
+	wantDone, wantTotal := plan.Progress()
+	if total != wantTotal || done != wantDone {
+		t.Errorf("CountSteps() = %d, %d, want %d, %d", total, done, wantTotal, wantDone)
+	}
+
+	if _, _, err := planner.CountSteps("missing"); !errors.Is(err, ErrPlanNotFound) {
+		t.Errorf("CountSteps of a missing plan = %v, want ErrPlanNotFound", err)
+	}
+}
+
+// TestValidateArguments tests that invalid manage_plan arguments are rejected with precise messages.
+func TestValidateArguments(t *testing.T) {
+	tests := []struct {
+		action string
+		args   map[string]any
+		want   string // Substring of the error, empty if the arguments are valid
+	}{
+		{"set_status", map[string]any{"plan_name": "p", "step_id": "s", "status": "completed"}, ""},
+		{"set_status", map[string]any{"plan_name": "p", "step_id": "s", "status": "DONE"}, "invalid status: DONE"},
+		{"set_status", map[string]any{"plan_name": "p", "step_id": "s"}, "status is required"},
+		{"set_status", map[string]any{"plan_name": "p", "step_id": "  ", "status": "completed"}, "step_id must not be empty"},
+		{"inspect", map[string]any{"plan_name": ""}, "plan_name must not be empty"},
+		{"inspect", map[string]any{"plan_name": 42}, "plan_name must be a string"},
+		{"list_plans", map[string]any{"plan_name": "", "plan_status": "open"}, "invalid plan_status: open"},
+		{"list_plans", map[string]any{"plan_name": ""}, ""},
+		{"remove_steps", map[string]any{"plan_name": "p", "step_ids": []any{}}, "step_ids must not be empty"},
+		{"remove_steps", map[string]any{"plan_name": "p", "step_ids": []any{"a", ""}}, "step_ids[1] must not be empty"},
+		{"remove_steps", map[string]any{"plan_name": "p", "step_ids": "a"}, "step_ids must be an array of strings"},
+		{"reorder_steps", map[string]any{"plan_name": "p", "step_order": []any{"a", "b", "a"}}, "step 'a' more than once"},
+		{"add_steps", map[string]any{"plan_name": "p", "step_id": "-s", "description": "d"}, "invalid step_id"},
+		{"add_steps", map[string]any{"plan_name": "p", "step_id": "s", "description": "d", "references": []any{"https://example.com"}}, ""},
+		{"frobnicate", map[string]any{"plan_name": "p"}, "unknown action: frobnicate"},
+		{"inspect", map[string]any{"plan_name": "p", "status": "DONE", "step_ids": "a", "extra": 1}, ""},
+		{"list_plans", map[string]any{"plan_name": 42}, ""},
+	}
+
+	for _, tt := range tests {
+		var req mcp.CallToolRequest
+		req.Params.Arguments = tt.args
+		err := validateArguments(tt.action, req)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("validateArguments(%s, %v) = %v, want nil", tt.action, tt.args, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("validateArguments(%s, %v) = %v, want error containing %q", tt.action, tt.args, err, tt.want)
+		}
+	}
+}
+
+// TestPlan_ExportCSV tests the CSV export, including quoting of commas and quotes.
+func TestPlan_ExportCSV(t *testing.T) {
+	plan := &Plan{ID: "csv-plan", Steps: []*Step{}}
+	plan.AddStep("one", `Say "hi", then leave`, []string{"AC, with comma", "AC2"}, []Reference{{Title: "Doc", URL: "https://example.com"}, {URL: "/tmp/file"}})
+	plan.AddStep("two", "Plain", nil, nil)
+	plan.MarkAsCompleted("two")
+
+	var buf strings.Builder
+	if err := plan.ExportCSV(&buf); err != nil {
+		t.Fatalf("ExportCSV failed: %v", err)
+	}
+	want := `step_id,status,description,acceptance_criteria,references
+one,TODO,"Say ""hi"", then leave","AC, with comma;AC2",Doc|https://example.com;/tmp/file
+two,DONE,Plain,,
+`
+	if buf.String() != want {
+		t.Errorf("ExportCSV() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	other := &Plan{ID: "other", Steps: []*Step{}}
+	other.AddStep("three", "Third", []string{"AC3"}, nil)
+	buf.Reset()
+	if err := ExportPlansCSV(&buf, []*Plan{plan, other}, true); err != nil {
+		t.Fatalf("ExportPlansCSV failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || lines[0] != "plan,step_id,status,description,acceptance_criteria,references" || lines[3] != "other,three,TODO,Third,AC3," {
+		t.Errorf("ExportPlansCSV() =\n%s", buf.String())
+	}
+}
+
+// TestPlanner_GetSummary tests that GetSummary loads steps without criteria
+// and references, and that the summary cannot be saved.
+func TestPlanner_GetSummary(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("summary-plan")
+	if err != nil {
+		t.Fatalf("Failed to create plan: %v", err)
+	}
+	plan.AddStep("first", "First step", []string{"AC1"}, []Reference{{URL: "https://example.com"}})
+	plan.AddStep("second", "Second step", nil, nil)
+	plan.MarkAsCompleted("first")
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Failed to save plan: %v", err)
+	}
+
+	summary, err := planner.GetSummary("summary-plan")
+	if err != nil {
+		t.Fatalf("GetSummary failed: %v", err)
+	}
+	if len(summary.Steps) != 2 || summary.Steps[0].ID() != "first" || summary.Steps[1].ID() != "second" {
+		t.Fatalf("Expected steps first and second, got %v", summary.Steps)
+	}
+	if summary.Steps[0].Status() != "DONE" || summary.Steps[0].Description() != "First step" {
+		t.Errorf("Expected first step to be DONE with its description, got %s %q", summary.Steps[0].Status(), summary.Steps[0].Description())
+	}
+	if len(summary.Steps[0].AcceptanceCriteria()) != 0 || len(summary.Steps[0].References()) != 0 {
+		t.Errorf("Expected no criteria or references in summary, got %v and %v", summary.Steps[0].AcceptanceCriteria(), summary.Steps[0].References())
+	}
+
+	if err := planner.Save(summary); err == nil {
+		t.Error("Expected saving a summary to fail")
+	}
+
+	full, err := planner.Get("summary-plan")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(full.Steps[0].AcceptanceCriteria()) != 1 || len(full.Steps[0].References()) != 1 {
+		t.Errorf("Expected criteria and references to be kept, got %v and %v", full.Steps[0].AcceptanceCriteria(), full.Steps[0].References())
+	}
+
+	if _, err := planner.GetSummary("missing"); err == nil {
+		t.Error("Expected error for missing plan")
+	}
+}
+
+// TestReference_Validate tests which references are accepted as URLs or local paths.
+func TestReference_Validate(t *testing.T) {
+	valid := []string{"https://example.com/design", "mailto:team@example.com", "/etc/hosts", "./notes.txt", "../README.md", "~/todo.md", "docs/spec.md", "README.md"}
+	for _, text := range valid {
+		if err := (Reference{URL: text}).Validate(); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", text, err)
+		}
+	}
+
+	invalid := []string{"", "doc-1", "see the docs", "https://exa mple.com", "http://[::1"}
+	for _, text := range invalid {
+		if err := (Reference{URL: text}).Validate(); err == nil {
+			t.Errorf("Expected %q to be invalid", text)
+		}
+	}
+}
+
+// TestPlanner_History tests that status changes persisted by Save and
+// SetStepStatus are recorded in order, and that unchanged statuses are not.
+func TestPlanner_History(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("history-plan")
+	if err != nil {
+		t.Fatalf("Failed to create plan: %v", err)
+	}
+	plan.AddStep("first", "First step", []string{"AC1"}, nil)
+	plan.AddStep("second", "Second step", []string{"AC2"}, nil)
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Failed to save plan: %v", err)
+	}
+
+	history, err := planner.History("history-plan")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("Expected no history for new steps, got %v", history)
+	}
+
+	plan, _ = planner.Get("history-plan")
+	plan.MarkAsCompleted("first")
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Failed to save plan: %v", err)
+	}
+	if err := planner.SetStepStatus("history-plan", "first", "TODO"); err != nil {
+		t.Fatalf("SetStepStatus failed: %v", err)
+	}
+	if err := planner.SetStepStatus("history-plan", "second", "TODO"); err != nil {
+		t.Fatalf("SetStepStatus failed: %v", err)
+	}
+	if _, err := planner.CompleteAndNext("history-plan", "second"); err != nil {
+		t.Fatalf("CompleteAndNext failed: %v", err)
+	}
+
+	history, err = planner.History("history-plan")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	want := []StatusChange{
+		{StepID: "first", OldStatus: "TODO", NewStatus: "DONE"},
+		{StepID: "first", OldStatus: "DONE", NewStatus: "TODO"},
+		{StepID: "second", OldStatus: "TODO", NewStatus: "DONE"},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("Expected %d changes, got %v", len(want), history)
+	}
+	for i, change := range history {
+		if change.StepID != want[i].StepID || change.OldStatus != want[i].OldStatus || change.NewStatus != want[i].NewStatus {
+			t.Errorf("Change %d: expected %v, got %v", i, want[i], change)
+		}
+		if change.ChangedAt.IsZero() {
+			t.Errorf("Change %d: expected a change time", i)
+		}
+	}
+
+	if _, err := planner.History("missing"); !errors.Is(err, ErrPlanNotFound) {
+		t.Errorf("Expected ErrPlanNotFound for missing plan, got %v", err)
+	}
+}
+
+// TestPlanner_WithTx tests that the operations of a Tx are committed together,
+// and that nothing is written when the function fails.
+func TestPlanner_WithTx(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	source, _ := planner.Create("tx-source")
+	source.AddStep("one", "Step one", []string{"AC1"}, nil)
+	if err := planner.Save(source); err != nil {
+		t.Fatalf("Failed to save plan: %v", err)
+	}
+
+	failing := errors.New("stop")
+	var created *Plan
+	err := planner.WithTx(func(tx *Tx) error {
+		created, _ = planner.Create("tx-dest")
+		created.AddStep("two", "Step two", []string{"AC2"}, nil)
+		if err := tx.Save(created); err != nil {
+			return err
+		}
+		if exists, err := tx.Exists("tx-dest"); err != nil || !exists {
+			t.Errorf("Expected the saved plan to exist within the transaction, got %v, %v", exists, err)
+		}
+		if err := tx.Remove([]string{"tx-source"}, "remove"); err != nil {
+			return err
+		}
+		return failing
+	})
+	if !errors.Is(err, failing) {
+		t.Fatalf("Expected the function's error, got %v", err)
+	}
+	if exists, _ := planner.Exists("tx-dest"); exists {
+		t.Error("Expected the plan saved in a failed transaction not to exist")
+	}
+	if exists, _ := planner.Exists("tx-source"); !exists {
+		t.Error("Expected the plan removed in a failed transaction to still exist")
+	}
+	if !created.isNew {
+		t.Error("Expected the plan saved in a failed transaction to be new again")
+	}
+
+	err = planner.WithTx(func(tx *Tx) error {
+		if err := tx.Save(created); err != nil {
+			return err
+		}
+		plan, err := tx.Get("tx-dest")
+		if err != nil {
+			return err
+		}
+		plan.AddStep("three", "Step three", []string{"AC3"}, nil)
+		if err := tx.Save(plan); err != nil {
+			return err
+		}
+		return tx.SetStepStatus("tx-source", "one", "done")
+	})
+	if err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+
+	dest, err := planner.Get("tx-dest")
+	if err != nil {
+		t.Fatalf("Failed to get plan: %v", err)
+	}
+	if len(dest.Steps) != 2 {
+		t.Errorf("Expected 2 steps in committed plan, got %d", len(dest.Steps))
+	}
+	source, _ = planner.Get("tx-source")
+	if source.Steps[0].Status() != "DONE" {
+		t.Errorf("Expected step to be DONE, got %s", source.Steps[0].Status())
+	}
+}
+
+// TestPlanner_MergeWithOptions_Atomic tests that a merge removing the source
+// plan is recorded as one operation, so that Undo reverts both plans.
+func TestPlanner_MergeWithOptions_Atomic(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, name := range []string{"merge-dest", "merge-src"} {
+		plan, _ := planner.Create(name)
+		plan.AddStep(name+"-step", "Step", []string{"AC"}, nil)
+		if err := planner.Save(plan); err != nil {
+			t.Fatalf("Failed to save plan: %v", err)
+		}
+	}
+
+	if err := planner.MergeWithOptions("merge-dest", "merge-src", MergeOptions{RemoveSource: true}); err != nil {
+		t.Fatalf("MergeWithOptions failed: %v", err)
+	}
+	if exists, _ := planner.Exists("merge-src"); exists {
+		t.Error("Expected source plan to be removed")
+	}
+
+	op, err := planner.Undo()
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if op.Name != "merge" || !reflect.DeepEqual(op.Plans, []string{"merge-dest", "merge-src"}) {
+		t.Errorf("Expected to undo the merge of both plans, got %s of %v", op.Name, op.Plans)
+	}
+	if exists, _ := planner.Exists("merge-src"); !exists {
+		t.Error("Expected source plan to be restored")
+	}
+	dest, err := planner.Get("merge-dest")
+	if err != nil {
+		t.Fatalf("Failed to get plan: %v", err)
+	}
+	if len(dest.Steps) != 1 || dest.Steps[0].ID() != "merge-dest-step" {
+		t.Errorf("Expected Undo to take the merged steps out of the destination, got %d steps", len(dest.Steps))
+	}
+}
+
+// TestPlanner_ListSteps tests listing steps by status across plans,
+// skipping archived plans.
+func TestPlanner_ListSteps(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	beta, _ := planner.Create("beta")
+	beta.AddStep("b1", "Beta one", []string{"AC"}, nil)
+	beta.AddStep("b2", "Beta two", []string{"AC"}, nil)
+	beta.MarkAsCompleted("b1")
+	alpha, _ := planner.Create("alpha")
+	alpha.AddStep("a2", "Alpha two", []string{"AC"}, nil)
+	alpha.AddStep("a1", "Alpha one", []string{"AC"}, nil)
+	archived, _ := planner.Create("archived")
+	archived.AddStep("x", "Hidden", []string{"AC"}, nil)
+	for _, plan := range []*Plan{beta, alpha, archived} {
+		if err := planner.Save(plan); err != nil {
+			t.Fatalf("Failed to save plan: %v", err)
+		}
+	}
+	if err := planner.Archive("archived"); err != nil {
+		t.Fatalf("Failed to archive plan: %v", err)
+	}
+
+	todo, err := planner.ListSteps("todo")
+	if err != nil {
+		t.Fatalf("ListSteps failed: %v", err)
+	}
+	want := []StepRef{
+		{Plan: "alpha", StepID: "a2", Description: "Alpha two", Status: "TODO"},
+		{Plan: "alpha", StepID: "a1", Description: "Alpha one", Status: "TODO"},
+		{Plan: "beta", StepID: "b2", Description: "Beta two", Status: "TODO"},
+	}
+	if !reflect.DeepEqual(todo, want) {
+		t.Errorf("ListSteps(todo) = %v, want %v", todo, want)
+	}
+
+	all, err := planner.ListSteps("")
+	if err != nil {
+		t.Fatalf("ListSteps failed: %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("Expected 4 steps without a status filter, got %v", all)
+	}
+
+	if _, err := planner.ListSteps("later"); err == nil {
+		t.Error("Expected error for invalid status")
+	}
+}
+
+// TestPlan_WeightedProgress tests progress weighted by estimates and the
+// fallback to step counts when no step has an estimate.
+func TestPlan_WeightedProgress(t *testing.T) {
+	plan := &Plan{ID: "weighted", Steps: []*Step{}}
+	if progress := plan.WeightedProgress(); progress != 0 {
+		t.Errorf("Expected no progress for a plan without steps, got %v", progress)
+	}
+
+	plan.AddStep("big", "Big step", nil, nil)
+	plan.AddStep("small", "Small step", nil, nil)
+	plan.AddStep("tiny", "Tiny step", nil, nil)
+	plan.MarkAsCompleted("small")
+	plan.MarkAsCompleted("tiny")
+	if progress := plan.WeightedProgress(); progress != 2.0/3.0 {
+		t.Errorf("Expected step count fallback of 2/3, got %v", progress)
+	}
+
+	plan.Steps[0].SetEstimateMinutes(90)
+	plan.Steps[1].SetEstimateMinutes(10)
+	if progress := plan.WeightedProgress(); progress != 0.1 {
+		t.Errorf("Expected 0.1 weighted by estimates, got %v", progress)
+	}
+
+	plan.MarkAsCompleted("big")
+	if progress := plan.WeightedProgress(); progress != 1 {
+		t.Errorf("Expected 1 when all steps are done, got %v", progress)
+	}
+}
+
+// TestMakePlannerToolHandler_ReadOnly tests that a read-only tool only offers
+// and runs actions that do not change plans.
+func TestMakePlannerToolHandler_ReadOnly(t *testing.T) {
+	toolInfo, err := MakePlannerToolHandlerWithOptions(":memory:", Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+
+	offered := toolInfo.Tool.InputSchema.Properties["action"].(map[string]any)["enum"].([]string)
+	if !reflect.DeepEqual(offered, []string{"inspect", "list_plans", "get_next_step", "is_completed"}) {
+		t.Errorf("Expected only read-only actions, got %v", offered)
+	}
+
+	call := func(args map[string]any) *mcp.CallToolResult {
+		var req mcp.CallToolRequest
+		req.Params.Arguments = args
+		result, err := toolInfo.Handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Handler failed: %v", err)
+		}
+		return result
+	}
+
+	result := call(map[string]any{"action": "add_steps", "plan_name": "p", "step_id": "s", "description": "d"})
+	if !result.IsError {
+		t.Error("Expected add_steps to be rejected in read-only mode")
+	}
+	result = call(map[string]any{"action": "list_plans", "plan_name": ""})
+	if result.IsError {
+		t.Errorf("Expected list_plans to be allowed in read-only mode, got %v", result.Content)
+	}
+}
+
+// TestStep_CriterionMet tests checking acceptance criteria, persisting them,
+// and completing steps strictly.
+func TestStep_CriterionMet(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, _ := planner.Create("criteria-plan")
+	plan.AddStep("step", "Step", []string{"First", "Second", "Third"}, nil)
+	step := plan.Steps[0]
+
+	if err := step.SetCriterionMet(4, true); err == nil {
+		t.Error("Expected error for criterion out of range")
+	}
+	if err := step.SetCriterionMet(1, true); err != nil {
+		t.Fatalf("SetCriterionMet failed: %v", err)
+	}
+	if err := step.SetCriterionMet(3, true); err != nil {
+		t.Fatalf("SetCriterionMet failed: %v", err)
+	}
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Failed to save plan: %v", err)
+	}
+
+	plan, err := planner.Get("criteria-plan")
+	if err != nil {
+		t.Fatalf("Failed to get plan: %v", err)
+	}
+	step = plan.Steps[0]
+	if !step.CriterionMet(1) || step.CriterionMet(2) || !step.CriterionMet(3) {
+		t.Errorf("Expected criteria 1 and 3 to be met after reload")
+	}
+	if !strings.Contains(plan.Inspect(), "1. [x] First\n2. [ ] Second\n3. [x] Third\n") {
+		t.Errorf("Expected checkboxes in Inspect, got:\n%s", plan.Inspect())
+	}
+
+	err = plan.MarkAsCompletedStrict("step")
+	if !errors.Is(err, ErrCriteriaNotMet) || !strings.Contains(err.Error(), "criterion 2") {
+		t.Errorf("Expected ErrCriteriaNotMet naming criterion 2, got %v", err)
+	}
+	if step.Status() != "TODO" {
+		t.Errorf("Expected step to stay TODO, got %s", step.Status())
+	}
+
+	if err := step.RemoveCriterion(2); err != nil {
+		t.Fatalf("RemoveCriterion failed: %v", err)
+	}
+	if !step.AllCriteriaMet() {
+		t.Error("Expected the remaining criteria to be met")
+	}
+	if err := plan.MarkAsCompletedStrict("step"); err != nil {
+		t.Errorf("MarkAsCompletedStrict failed: %v", err)
+	}
+	if step.Status() != "DONE" {
+		t.Errorf("Expected step to be DONE, got %s", step.Status())
+	}
+}
+
+// TestPlanner_Checkpoint tests that Checkpoint moves the write-ahead log into
+// the database file, even while another connection keeps the database open.
+func TestPlanner_Checkpoint(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "checkpoint.db")
+	other, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer other.Close()
+
+	toolInfo, err := MakePlannerToolHandler(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	if toolInfo.Planner == nil {
+		t.Fatal("Expected the tool to expose its planner")
+	}
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]any{"action": "add_steps", "plan_name": "p", "step_id": "s1", "description": "Step 1"}
+	result, err := toolInfo.Handler(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("add_steps failed: %v %v", err, result)
+	}
+
+	if err := toolInfo.Planner.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if err := toolInfo.Planner.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	info, err := os.Stat(dbPath + "-wal")
+	if err != nil {
+		t.Fatalf("Failed to stat write-ahead log: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("Expected write-ahead log to be truncated, got %d bytes", info.Size())
+	}
+
+	if _, err := other.Get("p"); err != nil {
+		t.Errorf("Expected plan to be readable after Close, got %v", err)
+	}
+}
+
+// TestPlan_Renumber tests that Renumber assigns sequential IDs, keeps the
+// steps' data and that saving carries their status history over.
+func TestPlan_Renumber(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("renumber-plan")
+	if err != nil {
+		t.Fatalf("Failed to create plan: %v", err)
+	}
+	plan.AddStep("step-1", "First", []string{"AC1"}, nil)
+	plan.AddStep("step-5", "Second", []string{"AC2"}, []Reference{{URL: "docs/second.md"}})
+	plan.AddStep("step-2", "Third", []string{"AC3"}, nil)
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Failed to save plan: %v", err)
+	}
+	if err := planner.SetStepStatus("renumber-plan", "step-5", "DONE"); err != nil {
+		t.Fatalf("SetStepStatus failed: %v", err)
+	}
+
+	plan, _ = planner.Get("renumber-plan")
+	renamed := plan.Renumber("step-")
+	want := map[string]string{"step-5": "step-2", "step-2": "step-3"}
+	if !reflect.DeepEqual(renamed, want) {
+		t.Errorf("Expected mapping %v, got %v", want, renamed)
+	}
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Failed to save renumbered plan: %v", err)
+	}
+
+	plan, _ = planner.Get("renumber-plan")
+	var ids []string
+	for _, step := range plan.Steps {
+		ids = append(ids, step.ID())
+	}
+	if !reflect.DeepEqual(ids, []string{"step-1", "step-2", "step-3"}) {
+		t.Errorf("Expected sequential IDs, got %v", ids)
+	}
+	second := plan.Steps[1]
+	if second.Description() != "Second" || second.Status() != "DONE" || len(second.References()) != 1 || second.AcceptanceCriteria()[0] != "AC2" {
+		t.Errorf("Expected renumbered step to keep its data, got %s %s %v %v", second.Description(), second.Status(), second.AcceptanceCriteria(), second.References())
+	}
+
+	history, err := planner.History("renumber-plan")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 1 || history[0].StepID != "step-2" || history[0].NewStatus != "DONE" {
+		t.Errorf("Expected the completion to move to step-2, got %v", history)
+	}
+
+	if renamed := plan.Renumber("step-"); len(renamed) != 0 {
+		t.Errorf("Expected no changes for a numbered plan, got %v", renamed)
+	}
+}
+
+// TestPlanner_RemoveErr tests that RemoveErr joins the errors of the plans that
+// could not be removed and returns nil when all plans were removed.
+func TestPlanner_RemoveErr(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, name := range []string{"first", "second"} {
+		plan, err := planner.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create plan: %v", err)
+		}
+		if err := planner.Save(plan); err != nil {
+			t.Fatalf("Failed to save plan: %v", err)
+		}
+	}
+
+	err := planner.RemoveErr([]string{"first", "missing", "other-missing"})
+	if err == nil {
+		t.Fatal("Expected an error for missing plans")
+	}
+	for _, name := range []string{"'missing'", "'other-missing'"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected error to mention %s, got %v", name, err)
+		}
+	}
+	if strings.Contains(err.Error(), "'first'") {
+		t.Errorf("Expected no error for an existing plan, got %v", err)
+	}
+	if exists, _ := planner.Exists("first"); !exists {
+		t.Error("Expected no plan to be removed when one removal fails")
+	}
+
+	if err := planner.RemoveErr([]string{"first", "second"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if exists, _ := planner.Exists("second"); exists {
+		t.Error("Expected plan to be removed")
+	}
+}
+
+// TestPlanner_InsertStep tests that InsertStep places a step without saving the
+// whole plan, and keeps the order when the gaps between steps are used up.
+func TestPlanner_InsertStep(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := planner.Create("insert-plan")
+	if err != nil {
+		t.Fatalf("Failed to create plan: %v", err)
+	}
+	plan.AddStep("first", "First", []string{"AC"}, nil)
+	plan.AddStep("last", "Last", []string{"AC"}, nil)
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Failed to save plan: %v", err)
+	}
+
+	insert := func(id string, position Position) {
+		t.Helper()
+		step := NewStep(id, "Step "+id, []string{"AC " + id}, []Reference{{URL: "docs/" + id + ".md"}})
+		if err := planner.InsertStep("insert-plan", step, position); err != nil {
+			t.Fatalf("InsertStep(%s) failed: %v", id, err)
+		}
+	}
+	insert("top", Position{ToTop: true})
+	insert("bottom", Position{})
+	insert("before-last", Position{Before: "last"})
+	// Inserting after the same step again and again uses up the gap after it
+	for i := 1; i <= 12; i++ {
+		insert(fmt.Sprintf("after-%d", i), Position{After: "first"})
+	}
+
+	retrieved, err := planner.Get("insert-plan")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	var ids []string
+	for i, step := range retrieved.Steps {
+		ids = append(ids, step.ID())
+		if step.Order() != i {
+			t.Errorf("Expected step '%s' to have order %d, got %d", step.ID(), i, step.Order())
+		}
+	}
+	want := []string{"top", "first"}
+	for i := 12; i >= 1; i-- {
+		want = append(want, fmt.Sprintf("after-%d", i))
+	}
+	want = append(want, "before-last", "last", "bottom")
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected order %v, got %v", want, ids)
+	}
+	inserted, _ := retrieved.FindStep("before-last")
+	if inserted.AcceptanceCriteria()[0] != "AC before-last" || inserted.References()[0].URL != "docs/before-last.md" {
+		t.Errorf("Expected criteria and references to be stored, got %v %v", inserted.AcceptanceCriteria(), inserted.References())
+	}
+
+	next, err := planner.CompleteAndNext("insert-plan", "top")
+	if err != nil {
+		t.Fatalf("CompleteAndNext failed: %v", err)
+	}
+	if next.ID() != "first" || next.Order() != 1 {
+		t.Errorf("Expected next step 'first' at order 1, got '%s' at %d", next.ID(), next.Order())
+	}
+
+	// Copies loaded before the insert can no longer be saved
+	if err := planner.Save(plan); !errors.Is(err, ErrConcurrentModification) {
+		t.Errorf("Expected ErrConcurrentModification for a stale plan, got %v", err)
+	}
+	if err := planner.InsertStep("insert-plan", NewStep("first", "Again", nil, nil), Position{}); !errors.Is(err, ErrDuplicateStepID) {
+		t.Errorf("Expected ErrDuplicateStepID for a duplicate step ID, got %v", err)
+	}
+	if err := planner.InsertStep("insert-plan", NewStep("x", "X", nil, nil), Position{After: "missing"}); err == nil {
+		t.Error("Expected an error for a missing anchor step")
+	}
+	if err := planner.InsertStep("missing", NewStep("x", "X", nil, nil), Position{}); !errors.Is(err, ErrPlanNotFound) {
+		t.Errorf("Expected ErrPlanNotFound, got %v", err)
+	}
+}
+
+// TestPlanner_Rename tests that renaming a plan moves its steps, tags and
+// history, and rejects missing plans and names that are taken.
+func TestPlanner_Rename(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := BuildPlan(planner, "old",
+		StepSpec{ID: "step-1", Description: "First", AcceptanceCriteria: []string{"AC"}, References: []string{"docs/a.md"}},
+		StepSpec{ID: "step-2", Description: "Second", AcceptanceCriteria: []string{"AC"}},
+	)
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	plan.AddTag("backend")
+	plan.SetOnComplete("echo done")
+	if err := plan.SetRecurrence(RecurrenceDaily); err != nil {
+		t.Fatalf("SetRecurrence failed: %v", err)
+	}
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Failed to save plan: %v", err)
+	}
+	if err := planner.SetStepStatus("old", "step-1", "DONE"); err != nil {
+		t.Fatalf("SetStepStatus failed: %v", err)
+	}
+	if _, err := BuildPlan(planner, "other"); err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+
+	if err := planner.Rename("old", "new"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	if exists, _ := planner.Exists("old"); exists {
+		t.Error("Expected the old name to be gone")
+	}
+	renamed, err := planner.Get("new")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(renamed.Steps) != 2 || renamed.Steps[0].Status() != "DONE" || renamed.Steps[0].References()[0].URL != "docs/a.md" || renamed.Steps[1].AcceptanceCriteria()[0] != "AC" {
+		t.Errorf("Expected steps to be kept, got %+v", renamed.Steps)
+	}
+	if !reflect.DeepEqual(renamed.Tags(), []string{"backend"}) {
+		t.Errorf("Expected tags to be kept, got %v", renamed.Tags())
+	}
+	if renamed.OnComplete() != "echo done" {
+		t.Errorf("Expected the on-complete command to be kept, got %q", renamed.OnComplete())
+	}
+	if renamed.Recurrence() != RecurrenceDaily {
+		t.Errorf("Expected the recurrence to be kept, got %q", renamed.Recurrence())
+	}
+	history, err := planner.History("new")
+	if err != nil || len(history) != 1 {
+		t.Errorf("Expected history to move with the plan, got %v, %v", history, err)
+	}
+	var violations int
+	if err := planner.db.QueryRow("SELECT COUNT(*) FROM pragma_foreign_key_check").Scan(&violations); err != nil || violations != 0 {
+		t.Errorf("Expected no foreign key violations, got %d, %v", violations, err)
+	}
+
+	// Copies loaded before the rename can no longer be saved
+	if err := planner.Save(plan); err == nil {
+		t.Error("Expected saving a copy loaded before the rename to fail")
+	}
+
+	if err := planner.Rename("old", "newer"); !errors.Is(err, ErrPlanNotFound) {
+		t.Errorf("Expected ErrPlanNotFound, got %v", err)
+	}
+	if err := planner.Rename("new", "other"); !errors.Is(err, ErrPlanExists) {
+		t.Errorf("Expected ErrPlanExists, got %v", err)
+	}
+	if err := planner.Rename("new", "new"); err != nil {
+		t.Errorf("Expected renaming a plan to its own name to succeed, got %v", err)
+	}
+	if err := planner.Rename("new", ""); err == nil {
+		t.Error("Expected an error for an empty name")
+	}
+}
+
+// TestMakePlannerToolHandler_RenamePlan tests the rename_plan action and its
+// error messages.
+func TestMakePlannerToolHandler_RenamePlan(t *testing.T) {
+	toolInfo, err := MakePlannerToolHandler(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	defer toolInfo.Planner.Close()
+
+	call := func(args map[string]any) *mcp.CallToolResult {
+		var req mcp.CallToolRequest
+		req.Params.Arguments = args
+		result, err := toolInfo.Handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Handler failed: %v", err)
+		}
+		return result
+	}
+	text := func(result *mcp.CallToolResult) string {
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	for _, name := range []string{"a", "b"} {
+		if result := call(map[string]any{"action": "add_steps", "plan_name": name, "step_id": "s", "description": "d"}); result.IsError {
+			t.Fatalf("add_steps failed: %s", text(result))
+		}
+	}
+
+	result := call(map[string]any{"action": "rename_plan", "plan_name": "a", "old_name": "a", "new_name": "c"})
+	if result.IsError || text(result) != `{"new_name":"c","old_name":"a"}` {
+		t.Errorf("Expected rename to succeed, got %s", text(result))
+	}
+	result = call(map[string]any{"action": "rename_plan", "plan_name": "a", "old_name": "a", "new_name": "d"})
+	if !result.IsError || !strings.Contains(text(result), "old_name must name an existing plan") {
+		t.Errorf("Expected a not found error, got %s", text(result))
+	}
+	result = call(map[string]any{"action": "rename_plan", "plan_name": "c", "old_name": "c", "new_name": "b"})
+	if !result.IsError || !strings.Contains(text(result), "choose a new_name") {
+		t.Errorf("Expected an already exists error, got %s", text(result))
+	}
+	result = call(map[string]any{"action": "rename_plan", "plan_name": "c", "old_name": "c"})
+	if !result.IsError || text(result) != "new_name is required for action rename_plan" {
+		t.Errorf("Expected new_name to be required, got %s", text(result))
+	}
+}
+
+// TestBuildPlan tests creating and saving a plan with steps in a single call.
+func TestBuildPlan(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := BuildPlan(planner, "fixture",
+		StepSpec{ID: "step-1", Description: "First", AcceptanceCriteria: []string{"AC 1", "AC 2"}, References: []string{"Spec|docs/spec.md"}},
+		StepSpec{ID: "step-2", Description: "Second"},
+	)
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[0].ID() != "step-1" || plan.Steps[1].ID() != "step-2" {
+		t.Fatalf("Expected two steps in order, got %+v", plan.Steps)
+	}
+	if !reflect.DeepEqual(plan.Steps[0].AcceptanceCriteria(), []string{"AC 1", "AC 2"}) {
+		t.Errorf("Expected acceptance criteria to be stored, got %v", plan.Steps[0].AcceptanceCriteria())
+	}
+	if ref := plan.Steps[0].References()[0]; ref.Title != "Spec" || ref.URL != "docs/spec.md" {
+		t.Errorf("Expected a titled reference, got %+v", ref)
+	}
+
+	// The returned plan is the stored one and can be changed and saved again
+	plan.Steps[1].status = "DONE"
+	if err := planner.Save(plan); err != nil {
+		t.Errorf("Expected the returned plan to be savable, got %v", err)
+	}
+
+	if _, err := BuildPlan(planner, "fixture"); err == nil {
+		t.Error("Expected an error for an existing plan")
+	}
+	if _, err := BuildPlan(planner, "invalid", StepSpec{ID: "step-1"}); err == nil {
+		t.Error("Expected an error for a step without description")
+	}
+	if exists, _ := planner.Exists("invalid"); exists {
+		t.Error("Expected no plan to be saved for invalid specs")
+	}
+}
+
+// TestNewWithOptions_MaxSteps tests that Get and the other methods loading
+// whole plans refuse plans with more steps than the configured limit, that
+// such plans can still be removed, and that the limit can be disabled.
+func TestNewWithOptions_MaxSteps(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "max-steps.db")
+	limited, err := NewWithOptions(dbPath, Options{MaxSteps: 3})
+	if err != nil {
+		t.Fatalf("Failed to create planner: %v", err)
+	}
+	defer limited.Close()
+
+	specs := []StepSpec{{ID: "s1", Description: "1"}, {ID: "s2", Description: "2"}, {ID: "s3", Description: "3"}}
+	if _, err := BuildPlan(limited, "at-limit", specs...); err != nil {
+		t.Fatalf("Expected a plan at the limit to load, got %v", err)
+	}
+
+	plan, err := limited.Create("too-big")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	plan.AddSteps(append(specs, StepSpec{ID: "s4", Description: "4"}))
+	if err := limited.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := limited.Get("too-big"); !errors.Is(err, ErrTooManySteps) {
+		t.Errorf("Expected ErrTooManySteps from Get, got %v", err)
+	}
+	if _, err := limited.GetSummary("too-big"); !errors.Is(err, ErrTooManySteps) {
+		t.Errorf("Expected ErrTooManySteps from GetSummary, got %v", err)
+	}
+	err = limited.WithTx(func(tx *Tx) error {
+		_, err := tx.Get("too-big")
+		return err
+	})
+	if !errors.Is(err, ErrTooManySteps) {
+		t.Errorf("Expected ErrTooManySteps from Tx.Get, got %v", err)
+	}
+	if _, err := limited.GetMany([]string{"at-limit", "too-big"}); !errors.Is(err, ErrTooManySteps) {
+		t.Errorf("Expected ErrTooManySteps from GetMany, got %v", err)
+	}
+	if _, err := limited.ListSteps(""); !errors.Is(err, ErrTooManySteps) {
+		t.Errorf("Expected ErrTooManySteps from ListSteps, got %v", err)
+	}
+	if _, err := limited.ExportPlan("too-big"); !errors.Is(err, ErrTooManySteps) {
+		t.Errorf("Expected ErrTooManySteps from ExportPlan, got %v", err)
+	}
+	if err := limited.InsertStep("at-limit", NewStep("s4", "4", nil, nil), Position{}); !errors.Is(err, ErrTooManySteps) {
+		t.Errorf("Expected ErrTooManySteps from InsertStep at the limit, got %v", err)
+	}
+	if plan, err := limited.Get("at-limit"); err != nil || len(plan.Steps) != 3 {
+		t.Errorf("Expected a rejected insert to leave the plan loadable with 3 steps, got %v", err)
+	}
+
+	unlimited, err := NewWithOptions(dbPath, Options{MaxSteps: -1})
+	if err != nil {
+		t.Fatalf("Failed to create planner: %v", err)
+	}
+	defer unlimited.Close()
+	if plan, err := unlimited.Get("too-big"); err != nil || len(plan.Steps) != 4 {
+		t.Errorf("Expected all steps without a limit, got %v", err)
+	}
+	data, err := unlimited.ExportPlan("too-big")
+	if err != nil {
+		t.Fatalf("ExportPlan failed without a limit: %v", err)
+	}
+	if err := limited.RemoveErr([]string{"too-big"}); err != nil {
+		t.Fatalf("Expected a plan over the limit to be removable, got %v", err)
+	}
+	if err := limited.ImportPlan(data); !errors.Is(err, ErrTooManySteps) {
+		t.Errorf("Expected ErrTooManySteps from ImportPlan, got %v", err)
+	}
+	if exists, _ := limited.Exists("too-big"); exists {
+		t.Error("Expected a rejected import not to create the plan")
+	}
+
+	defaults, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create planner: %v", err)
+	}
+	defer defaults.Close()
+	if defaults.maxSteps != DefaultMaxSteps {
+		t.Errorf("Expected the default limit %d, got %d", DefaultMaxSteps, defaults.maxSteps)
+	}
+}
+
+// TestPlanner_MovePlan tests moving a plan between databases with all its
+// data, and that failed moves leave both databases unchanged.
+func TestPlanner_MovePlan(t *testing.T) {
+	dir := t.TempDir()
+	source, err := New(filepath.Join(dir, "source.db"))
+	if err != nil {
+		t.Fatalf("Failed to create source planner: %v", err)
+	}
+	defer source.Close()
+	target, err := New(filepath.Join(dir, "target.db"))
+	if err != nil {
+		t.Fatalf("Failed to create target planner: %v", err)
+	}
+	defer target.Close()
+
+	plan, err := BuildPlan(source, "moving",
+		StepSpec{ID: "step-1", Description: "First", AcceptanceCriteria: []string{"AC"}, References: []string{"Docs|https://example.com"}},
+		StepSpec{ID: "step-2", Description: "Second"},
+	)
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	plan.AddTag("scratch")
+	plan.SetOnComplete("echo done")
+	plan.Steps[1].SetAssignee("alice")
+	if err := source.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := source.SetStepStatus("moving", "step-1", "DONE"); err != nil {
+		t.Fatalf("SetStepStatus failed: %v", err)
+	}
+	if err := source.Archive("moving"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+
+	if err := source.MovePlan("moving", target); err != nil {
+		t.Fatalf("MovePlan failed: %v", err)
+	}
+
+	if exists, _ := source.Exists("moving"); exists {
+		t.Error("Expected the plan to be removed from the source")
+	}
+	moved, err := target.Get("moving")
+	if err != nil {
+		t.Fatalf("Get from target failed: %v", err)
+	}
+	if len(moved.Steps) != 2 || moved.Steps[0].Status() != "DONE" || moved.Steps[1].Assignee() != "alice" {
+		t.Fatalf("Expected steps to be moved with their fields, got %+v", moved.Steps)
+	}
+	if _, ok := moved.Steps[0].CompletedAt(); !ok {
+		t.Error("Expected the completion time to be moved")
+	}
+	if ref := moved.Steps[0].References()[0]; ref.Title != "Docs" || ref.URL != "https://example.com" {
+		t.Errorf("Expected references to be moved, got %+v", ref)
+	}
+	if !reflect.DeepEqual(moved.Tags(), []string{"scratch"}) {
+		t.Errorf("Expected tags to be moved, got %v", moved.Tags())
+	}
+	if plans, _ := target.ListAll(); len(plans) != 1 || !plans[0].Archived {
+		t.Errorf("Expected the plan to stay archived, got %+v", plans)
+	}
+	if moved.OnComplete() != "" {
+		t.Errorf("Expected the on-complete command not to be moved, got %q", moved.OnComplete())
+	}
+	if history, err := target.History("moving"); err != nil || len(history) != 1 || history[0].StepID != "step-1" {
+		t.Errorf("Expected the history to be moved, got %v, %v", history, err)
+	}
+
+	// The history does not stay behind in the source
+	var leftover int
+	if err := source.db.QueryRow("SELECT COUNT(*) FROM step_status_history WHERE plan_id = ?", "moving").Scan(&leftover); err != nil || leftover != 0 {
+		t.Errorf("Expected the history to be removed from the source, got %d entries, %v", leftover, err)
+	}
+
+	// The move can be undone in the source, which leaves the copy in target
+	op, err := source.Undo()
+	if err != nil || op.Name != "move" {
+		t.Fatalf("Expected to undo the move, got %v, %v", op, err)
+	}
+	if history, err := source.History("moving"); err != nil || len(history) != 1 {
+		t.Errorf("Expected Undo to restore the history in the source, got %v, %v", history, err)
+	}
+
+	// A plan with the same name in target makes the move fail without changes
+	if err := source.MovePlan("moving", target); !errors.Is(err, ErrPlanExists) {
+		t.Errorf("Expected ErrPlanExists, got %v", err)
+	}
+	if exists, _ := source.Exists("moving"); !exists {
+		t.Error("Expected the plan to stay in the source after a failed move")
+	}
+	if err := source.MovePlan("missing", target); !errors.Is(err, ErrPlanNotFound) {
+		t.Errorf("Expected ErrPlanNotFound, got %v", err)
+	}
+	if err := source.MovePlan("moving", source); err == nil {
+		t.Error("Expected an error when moving a plan into its own database")
+	}
+
+	// Exported plans can be imported under their name once it is free
+	data, err := target.ExportPlan("moving")
+	if err != nil {
+		t.Fatalf("ExportPlan failed: %v", err)
+	}
+	if err := source.ImportPlan(data); !errors.Is(err, ErrPlanExists) {
+		t.Errorf("Expected ErrPlanExists from ImportPlan, got %v", err)
+	}
+	if err := source.ImportPlan([]byte("not json")); err == nil {
+		t.Error("Expected an error for an invalid document")
+	}
+
+	// Imported documents cannot set a command to run
+	if err := source.ImportPlan([]byte(`{"id": "imported", "on_complete": "echo pwned", "steps": []}`)); err != nil {
+		t.Fatalf("ImportPlan failed: %v", err)
+	}
+	if imported, err := source.Get("imported"); err != nil || imported.OnComplete() != "" {
+		t.Errorf("Expected the on-complete command of an imported plan to be ignored, got %v, %v", imported, err)
+	}
+}
+
+// TestPlan_OnComplete tests that the on-complete command of a plan runs once
+// for every change that completes the plan, and not for later saves.
+func TestPlan_OnComplete(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test command uses sh")
+	}
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	logFile := filepath.Join(t.TempDir(), "hook.log")
+	plan, err := BuildPlan(planner, "hooked",
+		StepSpec{ID: "step-1", Description: "First"},
+		StepSpec{ID: "step-2", Description: "Second"},
+	)
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	plan.SetOnComplete(`echo "$TASKED_PLAN" >> ` + logFile)
+	if err := planner.Save(plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// waitForRuns waits until the command ran n times in total
+	waitForRuns := func(n int) {
+		t.Helper()
+		var lines []string
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			data, _ := os.ReadFile(logFile)
+			lines = strings.Fields(string(data))
+			if len(lines) >= n {
+				break
+			}
+		}
+		if len(lines) != n {
+			t.Fatalf("Expected the command to run %d time(s), got %v", n, lines)
+		}
+		for _, line := range lines {
+			if line != "hooked" {
+				t.Errorf("Expected the plan name in the environment, got %q", line)
+			}
+		}
+	}
+
+	// Completing the first of two steps does not complete the plan
+	if err := planner.SetStepStatus("hooked", "step-1", "DONE"); err != nil {
+		t.Fatalf("SetStepStatus failed: %v", err)
+	}
+	retrieved, err := planner.Get("hooked")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if retrieved.OnComplete() != plan.OnComplete() {
+		t.Errorf("Expected the command to be stored, got %q", retrieved.OnComplete())
+	}
+	retrieved.MarkAsCompleted("step-2")
+	if err := planner.Save(retrieved); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	waitForRuns(1)
+
+	// Saving a completed plan again does not run the command again
+	if err := planner.Save(retrieved); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := planner.SetStepStatus("hooked", "step-2", "DONE"); err != nil {
+		t.Fatalf("SetStepStatus failed: %v", err)
+	}
+
+	// Completing the plan once more runs it again
+	if err := planner.SetStepStatus("hooked", "step-2", "TODO"); err != nil {
+		t.Fatalf("SetStepStatus failed: %v", err)
+	}
+	if _, err := planner.CompleteAndNext("hooked", "step-2"); err != nil {
+		t.Fatalf("CompleteAndNext failed: %v", err)
+	}
+	waitForRuns(2)
+
+	// Changes that are rolled back do not run the command
+	if err := planner.SetStepStatus("hooked", "step-2", "TODO"); err != nil {
+		t.Fatalf("SetStepStatus failed: %v", err)
+	}
+	err = planner.WithTx(func(tx *Tx) error {
+		if err := tx.SetStepStatus("hooked", "step-2", "DONE"); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	if err == nil {
+		t.Fatal("Expected the transaction to fail")
+	}
+	time.Sleep(100 * time.Millisecond)
+	waitForRuns(2)
+}
+
+// TestStep_MarkAsCompleted tests completing and reopening a step held directly
+func TestStep_MarkAsCompleted(t *testing.T) {
+	step := NewStep("step-1", "First step", nil, nil)
+	step.blocked = "waiting"
+	step.status = "BLOCKED"
+
+	step.MarkAsCompleted()
+	if step.Status() != "DONE" {
+		t.Errorf("Expected status DONE, got %s", step.Status())
+	}
+	if step.BlockedReason() != "" {
+		t.Errorf("Expected blocked reason to be cleared, got %q", step.BlockedReason())
+	}
+	completedAt, ok := step.CompletedAt()
+	if !ok {
+		t.Fatal("Expected a completion time")
+	}
+
+	// Completing a completed step keeps its completion time
+	step.MarkAsCompleted()
+	if again, _ := step.CompletedAt(); !again.Equal(completedAt) {
+		t.Errorf("Expected completion time %v to be kept, got %v", completedAt, again)
+	}
+
+	step.MarkAsIncomplete()
+	if step.Status() != "TODO" {
+		t.Errorf("Expected status TODO, got %s", step.Status())
+	}
+	if _, ok := step.CompletedAt(); ok {
+		t.Error("Expected completion time to be cleared")
+	}
+}
+
+// TestPlan_InspectFiltered tests showing only some steps, renumbered from 1
+func TestPlan_InspectFiltered(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := BuildPlan(planner, "filtered",
+		StepSpec{ID: "step-1", Description: "First step"},
+		StepSpec{ID: "step-2", Description: "Second step"},
+		StepSpec{ID: "step-3", Description: "Third step"},
+	)
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	if err := plan.MarkAsCompleted("step-1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+
+	output := plan.InspectFiltered(IsIncomplete)
+	if strings.Contains(output, "step-1") {
+		t.Errorf("Expected completed step to be hidden, got:\n%s", output)
+	}
+	if !strings.Contains(output, "## 1. [TODO] step-2") || !strings.Contains(output, "## 2. [TODO] step-3") {
+		t.Errorf("Expected incomplete steps to be numbered from 1, got:\n%s", output)
+	}
+
+	if all := plan.InspectFiltered(func(*Step) bool { return true }); all != plan.Inspect() {
+		t.Errorf("Expected an always-true predicate to match Inspect, got:\n%s", all)
+	}
+}
+
+// TestPlanner_Due tests listing completed recurring plans whose recurrence has elapsed
+func TestPlanner_Due(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, name := range []string{"daily", "weekly", "one-off", "unfinished"} {
+		plan, err := BuildPlan(planner, name, StepSpec{ID: "step-1", Description: "Only step"})
+		if err != nil {
+			t.Fatalf("BuildPlan failed: %v", err)
+		}
+		if name != "unfinished" {
+			if err := plan.MarkAsCompleted("step-1"); err != nil {
+				t.Fatalf("MarkAsCompleted failed: %v", err)
+			}
+		}
+		recurrence := name
+		if name == "one-off" {
+			recurrence = "none"
+		} else if name == "unfinished" {
+			recurrence = "Daily"
+		}
+		if err := plan.SetRecurrence(recurrence); err != nil {
+			t.Fatalf("SetRecurrence(%q) failed: %v", recurrence, err)
+		}
+		if err := planner.Save(plan); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	reloaded, err := planner.Get("unfinished")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if reloaded.Recurrence() != RecurrenceDaily {
+		t.Errorf("Expected recurrence %q, got %q", RecurrenceDaily, reloaded.Recurrence())
+	}
+	if err := reloaded.SetRecurrence("hourly"); err == nil {
+		t.Error("Expected an error for an unknown recurrence")
+	}
+
+	dueNames := func(now time.Time) []string {
+		due, err := planner.Due(now)
+		if err != nil {
+			t.Fatalf("Due failed: %v", err)
+		}
+		names := []string{}
+		for _, info := range due {
+			names = append(names, info.Name)
+		}
+		return names
+	}
+
+	if names := dueNames(time.Now()); len(names) != 0 {
+		t.Errorf("Expected no plans to be due right after completing them, got %v", names)
+	}
+	if names := dueNames(time.Now().Add(25 * time.Hour)); !reflect.DeepEqual(names, []string{"daily"}) {
+		t.Errorf("Expected only the daily plan to be due after a day, got %v", names)
+	}
+	if names := dueNames(time.Now().Add(8 * 24 * time.Hour)); !reflect.DeepEqual(names, []string{"daily", "weekly"}) {
+		t.Errorf("Expected the daily and weekly plans to be due after a week, got %v", names)
+	}
+}
+
+// TestPlanner_Ctx tests that GetCtx, SaveCtx and ListCtx stop once their context is cancelled
+func TestPlanner_Ctx(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan, err := BuildPlan(planner, "ctx-plan", StepSpec{ID: "step-1", Description: "Only step"})
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := planner.GetCtx(ctx, "ctx-plan"); err != nil {
+		t.Fatalf("GetCtx failed: %v", err)
+	}
+	if plans, err := planner.ListCtx(ctx); err != nil || len(plans) != 1 {
+		t.Fatalf("Expected ListCtx to return 1 plan, got %d (%v)", len(plans), err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if _, err := planner.GetCtx(cancelled, "ctx-plan"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected GetCtx to fail with context.Canceled, got %v", err)
+	}
+	if _, err := planner.ListCtx(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected ListCtx to fail with context.Canceled, got %v", err)
+	}
+	if _, err := planner.ListByStatusCtx(cancelled, "todo"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected ListByStatusCtx to fail with context.Canceled, got %v", err)
+	}
+	if _, err := planner.CompleteAndNextCtx(cancelled, "ctx-plan", "step-1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected CompleteAndNextCtx to fail with context.Canceled, got %v", err)
+	}
+
+	if err := plan.MarkAsCompleted("step-1"); err != nil {
+		t.Fatalf("MarkAsCompleted failed: %v", err)
+	}
+	if err := planner.SaveCtx(cancelled, plan); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected SaveCtx to fail with context.Canceled, got %v", err)
+	}
+	reloaded, err := planner.Get("ctx-plan")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if reloaded.Steps[0].Status() != "TODO" {
+		t.Errorf("Expected a cancelled save or completion to write nothing, got status %s", reloaded.Steps[0].Status())
+	}
+
+	// The plan can still be saved with a live context afterwards
+	if err := planner.SaveCtx(ctx, plan); err != nil {
+		t.Fatalf("SaveCtx failed: %v", err)
+	}
+}
+
+// TestPlanner_Remove_History tests that removing a plan removes its status
+// history, so that a new plan with the same name starts without one, and
+// that Undo restores it.
+func TestPlanner_Remove_History(t *testing.T) {
+	planner, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, err := BuildPlan(planner, "recycled", StepSpec{ID: "step-1", Description: "Only step"}); err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	if err := planner.SetStepStatus("recycled", "step-1", "DONE"); err != nil {
+		t.Fatalf("SetStepStatus failed: %v", err)
+	}
+
+	if err := planner.RemoveErr([]string{"recycled"}); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := BuildPlan(planner, "recycled"); err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	if history, err := planner.History("recycled"); err != nil || len(history) != 0 {
+		t.Errorf("Expected a new plan to have no history, got %v, %v", history, err)
+	}
+
+	// Undoing the removal brings the history back with the plan
+	if err := planner.RemoveErr([]string{"recycled"}); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := planner.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if _, err := planner.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	history, err := planner.History("recycled")
+	if err != nil || len(history) != 1 || history[0].StepID != "step-1" || history[0].NewStatus != "DONE" {
+		t.Errorf("Expected Undo to restore the history, got %v, %v", history, err)
+	}
+}
+
+// TestPlan_WriteInspectWith tests formatting the step headlines, e.g. to color them
+func TestPlan_WriteInspectWith(t *testing.T) {
+	plan := &Plan{ID: "formatted", Steps: []*Step{
+		NewStep("step_1", "## 9. [TODO] not a headline", nil, nil),
+	}}
+
+	var out bytes.Buffer
+	err := plan.WriteInspectWith(&out, InspectOptions{
+		FormatStatus: func(status string) string { return "<" + status + ">" },
+		FormatStepID: func(id string) string { return "*" + id + "*" },
+	})
+	if err != nil {
+		t.Fatalf("WriteInspectWith failed: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "## 1. <TODO> *step_1*\n") {
+		t.Errorf("Expected a formatted headline, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "\n## 9. [TODO] not a headline\n") {
+		t.Errorf("Expected the description to be left unformatted, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := plan.WriteInspectWith(&out, InspectOptions{}); err != nil || out.String() != plan.Inspect() {
+		t.Errorf("Expected the zero options to match Inspect, got %v:\n%s", err, out.String())
+	}
+}
+
+// TestNewReadOnly tests reading a database without creating or changing it
+func TestNewReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing?.db")
+	if _, err := NewReadOnly(missing, Options{}); err == nil {
+		t.Fatal("Expected opening a missing database to fail")
+	}
+	if _, err := os.Stat(missing); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the missing database not to be created, got %v", err)
+	}
+
+	dbPath := filepath.Join(dir, "plans #1.db")
+	writer, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	plan, _ := writer.Create("readable")
+	plan.AddStep("step_1", "Read me", nil, nil)
+	if err := writer.Save(plan); err != nil {
+		t.Fatalf("Failed to save plan: %v", err)
+	}
+	writer.Close()
+
+	reader, err := NewReadOnly(dbPath, Options{})
+	if err != nil {
+		t.Fatalf("NewReadOnly failed: %v", err)
+	}
+	defer reader.Close()
+
+	plans, err := reader.List()
+	if err != nil || len(plans) != 1 || plans[0].Name != "readable" {
+		t.Errorf("Expected to list the plan, got %v, %v", plans, err)
+	}
+	if err := reader.RemoveErr([]string{"readable"}); err == nil {
+		t.Error("Expected removing a plan through a read-only planner to fail")
+	}
+}
+
+// TestNew_ConnectionPragmas tests that every connection of the pool enforces
+// foreign keys and waits for locks, not only the first one.
+func TestNew_ConnectionPragmas(t *testing.T) {
+	planner, err := New(filepath.Join(t.TempDir(), "pragmas.db"))
+	if err != nil {
+		t.Fatalf("Failed to create planner: %v", err)
+	}
+	defer planner.Close()
+
+	ctx := context.Background()
+	var conns []*sql.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := planner.db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get connection %d: %v", i, err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+
+	for i, conn := range conns {
+		var foreignKeys, busyTimeout int
+		var journalMode string
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+			t.Fatalf("Failed to query foreign_keys on connection %d: %v", i, err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+			t.Fatalf("Failed to query busy_timeout on connection %d: %v", i, err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode); err != nil {
+			t.Fatalf("Failed to query journal_mode on connection %d: %v", i, err)
+		}
+		if foreignKeys != 1 || busyTimeout != 5000 || journalMode != "wal" {
+			t.Errorf("Connection %d has foreign_keys=%d, busy_timeout=%d, journal_mode=%s; want 1, 5000, wal", i, foreignKeys, busyTimeout, journalMode)
+		}
+	}
+}
+
+// TestPlanner_HealthCheck_ReadOnly tests that a read-only planner reports the
+// tables and columns missing on disk instead of adding them.
+func TestPlanner_HealthCheck_ReadOnly(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if _, err := db.Exec("PRAGMA journal_mode = WAL; CREATE TABLE plans (id TEXT PRIMARY KEY)"); err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+	db.Close()
+
+	planner, err := NewReadOnly(dbPath, Options{})
+	if err != nil {
+		t.Fatalf("NewReadOnly failed: %v", err)
+	}
+	defer planner.Close()
+
+	report, err := planner.HealthCheck()
+	if err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+	if !report.ForeignKeys || report.JournalMode != "wal" {
+		t.Errorf("HealthCheck() = %+v, want foreign keys and WAL", report)
+	}
+	if len(report.MissingTables) != len(expectedTables)-1 {
+		t.Errorf("MissingTables = %v, want every table but plans", report.MissingTables)
+	}
+	if !slices.Contains(report.MissingColumns, "plans.version") {
+		t.Errorf("MissingColumns = %v, want plans.version", report.MissingColumns)
+	}
+}
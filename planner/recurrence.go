@@ -0,0 +1,69 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Recurrences supported by SetRecurrence.
+const (
+	RecurrenceDaily  = "daily"
+	RecurrenceWeekly = "weekly"
+)
+
+// recurrenceIntervals maps each supported recurrence to how long after its
+// last update a completed plan is due again.
+var recurrenceIntervals = map[string]time.Duration{
+	RecurrenceDaily:  24 * time.Hour,
+	RecurrenceWeekly: 7 * 24 * time.Hour,
+}
+
+// Recurrence returns how often the plan is meant to be repeated, e.g.
+// "daily" or "weekly", or an empty string if it is not recurring.
+func (pl *Plan) Recurrence() string {
+	return pl.recurrence
+}
+
+// SetRecurrence sets how often the plan is meant to be repeated in-memory:
+// RecurrenceDaily, RecurrenceWeekly, or "none" or an empty string to make
+// it a one-off plan again. Case and surrounding spaces are ignored.
+// It returns an error for any other recurrence. The recurrence is persisted
+// on the next Save and used by Due.
+func (pl *Plan) SetRecurrence(recurrence string) error {
+	recurrence = strings.ToLower(strings.TrimSpace(recurrence))
+	if recurrence == "none" {
+		recurrence = ""
+	}
+	if _, ok := recurrenceIntervals[recurrence]; recurrence != "" && !ok {
+		return fmt.Errorf("invalid recurrence '%s' (must be '%s', '%s' or 'none')", recurrence, RecurrenceDaily, RecurrenceWeekly)
+	}
+	pl.recurrence = recurrence
+	return nil
+}
+
+// Due returns the recurring plans that are due to be started over as of now:
+// plans that are not archived, have a recurrence, are completed, and were
+// last updated at least one recurrence interval before now, e.g. a day for
+// "daily" plans. Completing the last step of a plan updates it, so this is
+// usually the time since the plan was completed. Plans with a recurrence
+// this version does not know are skipped.
+// The result is ordered by plan name, like List.
+func (p *Planner) Due(now time.Time) ([]PlanInfo, error) {
+	plans, err := p.listPlans("WHERE p.archived = 0 AND p.recurrence IS NOT NULL AND p.recurrence != ''")
+	if err != nil {
+		return nil, err
+	}
+
+	due := []PlanInfo{}
+	for _, info := range plans {
+		interval, ok := recurrenceIntervals[info.Recurrence]
+		if !ok || info.Status != "DONE" {
+			continue
+		}
+		if !info.UpdatedAt.Add(interval).After(now) {
+			due = append(due, info)
+		}
+	}
+	return due, nil
+}
@@ -0,0 +1,115 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPlanExists is returned by Rename when a plan with the new name already exists.
+var ErrPlanExists = errors.New("plan already exists")
+
+// renamedPlanTables are the tables whose plan_id column Rename updates.
+// steps comes first, so that the rows of the other tables are moved along
+// with the steps they belong to.
+var renamedPlanTables = []string{
+	"steps",
+	"step_acceptance_criteria",
+	"step_references",
+	"plan_tags",
+	"step_status_history",
+}
+
+// Rename changes the name of the plan oldName to newName. Its steps, tags and
+// status history move with it, and it keeps its creation time and archived
+// state, and all its other settings, like its on-complete command and its
+// recurrence. The plan's version is incremented, so copies of the plan loaded
+// earlier can no longer be saved. Renaming a plan to its own name does nothing.
+// It returns an error wrapping ErrPlanNotFound if oldName does not exist, and
+// one wrapping ErrPlanExists if newName is already used by another plan.
+// The plan is renamed in a single transaction, see WithTx.
+func (p *Planner) Rename(oldName, newName string) error {
+	return p.WithTx(func(tx *Tx) error {
+		return tx.Rename(oldName, newName)
+	})
+}
+
+// Rename changes the name of a plan like Planner.Rename.
+func (t *Tx) Rename(oldName, newName string) error {
+	if newName == "" {
+		return fmt.Errorf("plan name cannot be empty")
+	}
+
+	exists, err := t.Exists(oldName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("cannot rename plan '%s': %w", oldName, ErrPlanNotFound)
+	}
+	if oldName == newName {
+		return nil
+	}
+	exists, err = t.Exists(newName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("cannot rename plan '%s' to '%s': %w", oldName, newName, ErrPlanExists)
+	}
+
+	// The rows are moved one table at a time, so the foreign keys only hold
+	// again once every table refers to the new name
+	if _, err := t.tx.Exec("PRAGMA defer_foreign_keys = ON"); err != nil {
+		return fmt.Errorf("failed to defer foreign keys: %w", err)
+	}
+
+	// Every other column is copied, so that columns added by later
+	// migrations are not lost
+	copied, err := copiedPlanColumns(t.tx)
+	if err != nil {
+		return err
+	}
+	_, err = t.tx.Exec("INSERT INTO plans (id, version, updated_at"+copied+") SELECT ?, version + 1, CURRENT_TIMESTAMP"+copied+" FROM plans WHERE id = ?", newName, oldName)
+	if err != nil {
+		return fmt.Errorf("failed to create plan '%s': %w", newName, err)
+	}
+
+	for _, table := range renamedPlanTables {
+		if _, err := t.tx.Exec("UPDATE "+table+" SET plan_id = ? WHERE plan_id = ?", newName, oldName); err != nil {
+			return fmt.Errorf("failed to move %s of plan '%s' to '%s': %w", table, oldName, newName, err)
+		}
+	}
+
+	if _, err := t.tx.Exec("DELETE FROM plans WHERE id = ?", oldName); err != nil {
+		return fmt.Errorf("failed to delete plan '%s' after renaming it: %w", oldName, err)
+	}
+	return nil
+}
+
+// copiedPlanColumns returns the columns of the plans table that Rename copies
+// unchanged, that is all but id, version and updated_at, as a list of quoted
+// names that starts with a comma, or an empty string if there are none.
+func copiedPlanColumns(q querier) (string, error) {
+	rows, err := q.Query("SELECT name FROM pragma_table_info('plans')")
+	if err != nil {
+		return "", fmt.Errorf("failed to query columns of plans: %w", err)
+	}
+	defer rows.Close()
+
+	var columns strings.Builder
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", fmt.Errorf("failed to scan column of plans: %w", err)
+		}
+		if name == "id" || name == "version" || name == "updated_at" {
+			continue
+		}
+		columns.WriteString(`, "` + strings.ReplaceAll(name, `"`, `""`) + `"`)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("error iterating columns of plans: %w", err)
+	}
+	return columns.String(), nil
+}
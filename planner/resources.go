@@ -0,0 +1,100 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// planURIScheme prefixes the URIs of plan resources, followed by the escaped plan name.
+const planURIScheme = "plan://"
+
+// ResourceInfo represents the MCP resources that expose plans as documents
+type ResourceInfo struct {
+	// Template matches plan://{name} so that any plan can be read, including
+	// plans created after the resources were last listed.
+	Template mcp.ResourceTemplate
+	Handler  func(context.Context, mcp.ReadResourceRequest) ([]mcp.ResourceContents, error)
+
+	// List returns one resource per plan that is not archived, in the order of Planner.List.
+	List func() ([]mcp.Resource, error)
+
+	// Planner is the planner used by Handler and List. The caller must close
+	// it once the resources are no longer served.
+	Planner *Planner
+}
+
+// PlanURI returns the URI of the resource for the named plan.
+func PlanURI(name string) string {
+	return planURIScheme + url.PathEscape(name)
+}
+
+// MakePlannerResources returns resources exposing every plan as a plain text
+// document with the URI plan://<name>, whose content is the output of Plan.Inspect.
+func MakePlannerResources(databasePath string) (ResourceInfo, error) {
+	return MakePlannerResourcesWithOptions(databasePath, Options{})
+}
+
+// MakePlannerResourcesWithOptions is like MakePlannerResources but opens
+// the planner with the given options.
+func MakePlannerResourcesWithOptions(databasePath string, opts Options) (ResourceInfo, error) {
+	planner, err := NewWithOptions(databasePath, opts)
+	if err != nil {
+		return ResourceInfo{}, fmt.Errorf("failed to initialize planner: %w", err)
+	}
+
+	template := mcp.NewResourceTemplate(planURIScheme+"{name}", "plan",
+		mcp.WithTemplateDescription("A plan with all its steps, acceptance criteria and references"),
+		mcp.WithTemplateMIMEType("text/plain"),
+	)
+
+	handler := func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return readPlanResource(ctx, req.Params.URI, planner)
+	}
+
+	list := func() ([]mcp.Resource, error) {
+		plans, err := planner.List()
+		if err != nil {
+			return nil, err
+		}
+
+		resources := make([]mcp.Resource, 0, len(plans))
+		for _, info := range plans {
+			resources = append(resources, mcp.NewResource(PlanURI(info.Name), info.Name,
+				mcp.WithResourceDescription(fmt.Sprintf("Plan '%s' (%d/%d steps completed)", info.Name, info.CompletedTasks, info.TotalTasks)),
+				mcp.WithMIMEType("text/plain"),
+			))
+		}
+		return resources, nil
+	}
+
+	return ResourceInfo{Template: template, Handler: handler, List: list, Planner: planner}, nil
+}
+
+// readPlanResource loads the plan addressed by uri and returns its inspection text.
+func readPlanResource(ctx context.Context, uri string, p *Planner) ([]mcp.ResourceContents, error) {
+	escaped, ok := strings.CutPrefix(uri, planURIScheme)
+	if !ok {
+		return nil, fmt.Errorf("not a plan URI: %s", uri)
+	}
+	name, err := url.PathUnescape(escaped)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plan URI '%s': %w", uri, err)
+	}
+
+	plan, err := p.GetCtx(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "text/plain",
+			Text:     plan.Inspect(),
+		},
+	}, nil
+}
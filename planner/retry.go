@@ -0,0 +1,44 @@
+package planner
+
+import (
+	"errors"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// maxTransactionAttempts is how often Save, Remove and Compact attempt their
+// transaction before giving up on a busy database.
+const maxTransactionAttempts = 5
+
+// retryBaseDelay is the wait before the first retry; it doubles on every further retry.
+var retryBaseDelay = 10 * time.Millisecond
+
+// withRetry calls fn up to n times, waiting with exponential backoff between
+// attempts, for as long as fn fails because the database is busy or locked.
+// Any other error, or the last busy error, is returned unchanged.
+func withRetry(n int, fn func() error) error {
+	delay := retryBaseDelay
+	var err error
+	for attempt := 1; attempt <= n; attempt++ {
+		err = fn()
+		if err == nil || !isBusyError(err) {
+			return err
+		}
+		if attempt < n {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return err
+}
+
+// isBusyError reports whether err was caused by SQLite being busy or locked
+// by another connection.
+func isBusyError(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+}
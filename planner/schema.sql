@@ -6,6 +6,10 @@ PRAGMA foreign_keys = ON;
 -- plans table: Stores information about each plan
 CREATE TABLE IF NOT EXISTS plans (
     id TEXT PRIMARY KEY NOT NULL, -- Unique identifier for the plan (e.g., "active", "feature-x")
+    version INTEGER NOT NULL DEFAULT 0, -- Incremented on every save to detect concurrent modifications
+    archived INTEGER NOT NULL DEFAULT 0, -- Archived plans are hidden from List
+    on_complete TEXT, -- Shell command run when a change completes the plan, NULL if none
+    recurrence TEXT, -- How often the plan is repeated ("daily" or "weekly"), NULL if it is not recurring
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -23,16 +27,27 @@ CREATE TABLE IF NOT EXISTS steps (
     id TEXT NOT NULL, -- Short identifier for the step (e.g., "add-tests")
     plan_id TEXT NOT NULL, -- Foreign key referencing plans.id
     description TEXT,
-    status TEXT NOT NULL CHECK(status IN ('TODO', 'DONE')),
+    status TEXT NOT NULL CHECK(status IN ('TODO', 'DONE', 'BLOCKED')),
     step_order INTEGER NOT NULL, -- Order of steps within a plan
+    estimate_minutes INTEGER, -- Estimated time for the step, NULL if not estimated
+    actual_minutes INTEGER, -- Time actually spent on the step, NULL if none logged
+    blocked_reason TEXT, -- Why the step is blocked, NULL unless status is BLOCKED
+    completed_at TIMESTAMP, -- When the step was marked as done, NULL if not done
+    assignee TEXT, -- Who owns the step, NULL if unassigned
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     PRIMARY KEY (plan_id, id),
     FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE CASCADE
 );
 
--- Index for faster step lookup by plan_id
-CREATE INDEX IF NOT EXISTS idx_steps_plan_id ON steps(plan_id);
+-- Steps are looked up by plan_id through idx_steps_plan_status below, so the
+-- separate index on plan_id created by older versions is dropped
+DROP INDEX IF EXISTS idx_steps_plan_id;
+
+-- Indexes for queries filtering steps by status, across all plans (e.g. ListSteps)
+-- and within a plan (e.g. CountSteps)
+CREATE INDEX IF NOT EXISTS idx_steps_status ON steps(status);
+CREATE INDEX IF NOT EXISTS idx_steps_plan_status ON steps(plan_id, status);
 
 -- Trigger to update updated_at timestamp on steps table
 CREATE TRIGGER IF NOT EXISTS steps_updated_at
@@ -50,6 +65,7 @@ CREATE TABLE IF NOT EXISTS step_acceptance_criteria (
     step_id TEXT NOT NULL,
     criterion TEXT NOT NULL,
     criterion_order INTEGER NOT NULL, -- Order of criteria for a step
+    met INTEGER NOT NULL DEFAULT 0, -- 1 once the criterion is checked as met, see plan check-criterion
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     PRIMARY KEY (plan_id, step_id, criterion_order),
     FOREIGN KEY (plan_id, step_id) REFERENCES steps(plan_id, id) ON DELETE CASCADE
@@ -77,6 +93,7 @@ CREATE TABLE IF NOT EXISTS step_references (
     plan_id TEXT NOT NULL,
     step_id TEXT NOT NULL,
     reference_url TEXT NOT NULL,
+    title TEXT, -- Optional human-readable title, NULL if the reference has none
     reference_order INTEGER NOT NULL, -- Order of references for a step
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     PRIMARY KEY (plan_id, step_id, reference_order),
@@ -101,3 +118,44 @@ BEGIN
 END;
 
 
+
+-- plan_tags table: Stores tags used to categorize plans
+CREATE TABLE IF NOT EXISTS plan_tags (
+    plan_id TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (plan_id, tag),
+    FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE CASCADE
+);
+
+-- Index for faster plan lookup by tag
+CREATE INDEX IF NOT EXISTS idx_plan_tags_tag ON plan_tags(tag);
+
+-- operations_log table: Records destructive operations so that they can be undone
+CREATE TABLE IF NOT EXISTS operations_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    operation TEXT NOT NULL, -- Name of the operation, e.g. "remove-steps" or "compact"
+    snapshot TEXT NOT NULL, -- JSON array with the state of every affected plan before the operation
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- plan_templates table: Stores reusable plan shapes, see Planner.SaveTemplate
+CREATE TABLE IF NOT EXISTS plan_templates (
+    name TEXT PRIMARY KEY NOT NULL, -- Unique name of the template
+    steps TEXT NOT NULL, -- JSON array of the template's steps
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- step_status_history table: Records every persisted change of a step's status, see Planner.History
+-- There is no foreign key to plans, so the history of a plan survives its removal and restoration with Undo
+CREATE TABLE IF NOT EXISTS step_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    plan_id TEXT NOT NULL,
+    step_id TEXT NOT NULL,
+    old_status TEXT NOT NULL,
+    new_status TEXT NOT NULL,
+    changed_at TIMESTAMP NOT NULL
+);
+
+-- Index for faster history lookup by plan_id
+CREATE INDEX IF NOT EXISTS idx_step_status_history_plan_id ON step_status_history(plan_id);
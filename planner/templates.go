@@ -0,0 +1,106 @@
+package planner
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrTemplateNotFound is returned by InstantiateTemplate when no template with
+// the given name exists.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// templateStep is a step as stored in a template. Only the parts of a step
+// that describe the work are kept; status and logged time are not.
+type templateStep struct {
+	ID          string      `json:"id"`
+	Description string      `json:"description"`
+	Acceptance  []string    `json:"acceptance"`
+	References  []Reference `json:"references"`
+	Estimate    int         `json:"estimate"`
+}
+
+// SaveTemplate stores the steps of the plan named planName as a template
+// named templateName. Descriptions, acceptance criteria, references and
+// estimates are kept; statuses, blocked reasons and logged time are not.
+// Templates are stored separately from plans, so they are not listed by List.
+// It returns an error if the plan does not exist or the template already exists.
+func (p *Planner) SaveTemplate(planName, templateName string) error {
+	plan, err := p.Get(planName)
+	if err != nil {
+		return err
+	}
+
+	steps := make([]templateStep, len(plan.Steps))
+	for i, step := range plan.Steps {
+		steps[i] = templateStep{
+			ID:          step.id,
+			Description: step.description,
+			Acceptance:  step.acceptance,
+			References:  step.references,
+			Estimate:    step.estimate,
+		}
+	}
+	encoded, err := json.Marshal(steps)
+	if err != nil {
+		return fmt.Errorf("failed to encode template '%s': %w", templateName, err)
+	}
+
+	var one int
+	err = p.db.QueryRow("SELECT 1 FROM plan_templates WHERE name = ?", templateName).Scan(&one)
+	if err == nil {
+		return fmt.Errorf("template with name '%s' already exists", templateName)
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check whether template '%s' exists: %w", templateName, err)
+	}
+
+	_, err = p.db.Exec("INSERT INTO plan_templates (name, steps) VALUES (?, ?)", templateName, string(encoded))
+	if err != nil {
+		return fmt.Errorf("failed to save template '%s': %w", templateName, err)
+	}
+	return nil
+}
+
+// InstantiateTemplate creates and saves a new plan named planName with the
+// steps of the template named templateName, all with status "TODO".
+// It returns an error wrapping ErrTemplateNotFound if the template does not
+// exist, and an error if a plan named planName already exists.
+func (p *Planner) InstantiateTemplate(templateName, planName string) (*Plan, error) {
+	var encoded string
+	err := p.db.QueryRow("SELECT steps FROM plan_templates WHERE name = ?", templateName).Scan(&encoded)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("cannot instantiate template '%s': %w", templateName, ErrTemplateNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query template '%s': %w", templateName, err)
+	}
+
+	var steps []templateStep
+	if err := json.Unmarshal([]byte(encoded), &steps); err != nil {
+		return nil, fmt.Errorf("failed to decode template '%s': %w", templateName, err)
+	}
+
+	exists, err := p.Exists(planName)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("plan with name '%s' already exists", planName)
+	}
+
+	plan, err := p.Create(planName)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		plan.AddStep(step.ID, step.Description, step.Acceptance, step.References)
+		plan.Steps[len(plan.Steps)-1].estimate = step.Estimate
+	}
+
+	if err := p.Save(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
@@ -3,7 +3,10 @@ package planner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -12,6 +15,10 @@ import (
 type ToolInfo struct {
 	Tool    mcp.Tool
 	Handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+
+	// Planner is the planner used by Handler. The caller must close it
+	// once the tool is no longer served.
+	Planner *Planner
 }
 
 // MakePlannerToolHandler returns a single tool handler that provides access to all planner operations.
@@ -32,46 +39,85 @@ type ToolInfo struct {
 // - get_next_step → get_next_step action
 // - is_plan_completed → is_completed action
 func MakePlannerToolHandler(databasePath string) (ToolInfo, error) {
-	planner, err := New(databasePath)
+	return MakePlannerToolHandlerWithOptions(databasePath, Options{})
+}
+
+// MakePlannerToolHandlerWithOptions is like MakePlannerToolHandler but opens
+// the planner with the given options.
+func MakePlannerToolHandlerWithOptions(databasePath string, opts Options) (ToolInfo, error) {
+	planner, err := NewWithOptions(databasePath, opts)
 	if err != nil {
 		return ToolInfo{}, fmt.Errorf("failed to initialize planner: %w", err)
 	}
 
+	// In read-only mode, only actions that do not change plans are offered
+	description := "Manage plans and their steps with various operations. Steps can include references to relevant files, URLs, or documentation."
+	enabled := actions
+	if opts.ReadOnly {
+		description = "Inspect plans and their steps. The server is read-only, so plans cannot be changed. Steps can include references to relevant files, URLs, or documentation."
+		enabled = nil
+		for _, action := range actions {
+			if readOnlyActions[action] {
+				enabled = append(enabled, action)
+			}
+		}
+	}
+
 	// Create the unified manage_plan tool
 	tool := mcp.NewTool("manage_plan",
-		mcp.WithDescription("Manage plans and their steps with various operations. Steps can include references to relevant files, URLs, or documentation."),
+		mcp.WithDescription(description),
 
 		// Required parameters
 		mcp.WithString("plan_name", mcp.Required(), mcp.Description("Name of the plan to operate on")),
-		mcp.WithString("action", mcp.Required(), mcp.Enum(
-			"add_steps",
-			"inspect",
-			"list_plans",
-			"remove_plans",
-			"compact_plans",
-			"remove_steps",
-			"reorder_steps",
-			"set_status",
-			"get_next_step",
-			"is_completed",
-		), mcp.Description("Action to perform")),
+		mcp.WithString("action", mcp.Required(), mcp.Enum(enabled...), mcp.Description("Action to perform")),
 
 		// Conditional parameters based on action
-		mcp.WithString("step_id", mcp.Description("ID of the step (required for set_status, single step operations)")),
+		mcp.WithString("step_id", mcp.Description("ID of the step (required for set_status, complete_and_next, single step operations)")),
 		mcp.WithString("description", mcp.Description("Description of the step (required for add_steps when adding single step)")),
 		mcp.WithArray("acceptance_criteria", mcp.WithStringItems(), mcp.Description("Acceptance criteria for the step (for add_steps)")),
-		mcp.WithArray("references", mcp.WithStringItems(), mcp.Description("References for the step (for add_steps) - URLs, file paths, or other resource identifiers (1-5 items)")),
+		mcp.WithArray("references", mcp.WithStringItems(), mcp.Description("References for the step (for add_steps) - URLs, file paths, or other resource identifiers, optionally titled as \"Title|URL\" (1-5 items)")),
 		mcp.WithArray("step_ids", mcp.WithStringItems(), mcp.Description("IDs of steps (required for remove_steps)")),
 		mcp.WithArray("step_order", mcp.WithStringItems(), mcp.Description("New order of step IDs (required for reorder_steps)")),
 		mcp.WithArray("plan_names", mcp.WithStringItems(), mcp.Description("Names of plans to remove (required for remove_plans)")),
+		mcp.WithString("old_name", mcp.Description("Current name of the plan (required for rename_plan)")),
+		mcp.WithString("new_name", mcp.Description("New name of the plan (required for rename_plan)")),
 		mcp.WithString("status", mcp.Enum("completed", "incomplete"), mcp.Description("Status to set for step (required for set_status)")),
+		mcp.WithString("plan_status", mcp.Enum("done", "todo"), mcp.Description("Only list plans with this status (optional for list_plans)")),
 	)
 
 	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if action := req.GetString("action", ""); opts.ReadOnly && !readOnlyActions[action] {
+			return mcp.NewToolResultError(fmt.Sprintf("action %s is not available: the server is read-only", action)), nil
+		}
 		return handleManagePlan(ctx, req, planner)
 	}
 
-	return ToolInfo{Tool: tool, Handler: handler}, nil
+	return ToolInfo{Tool: tool, Handler: handler, Planner: planner}, nil
+}
+
+// actions lists the actions of the manage_plan tool in the order they are offered.
+var actions = []string{
+	"add_steps",
+	"inspect",
+	"list_plans",
+	"remove_plans",
+	"rename_plan",
+	"compact_plans",
+	"remove_steps",
+	"reorder_steps",
+	"set_status",
+	"get_next_step",
+	"complete_and_next",
+	"is_completed",
+}
+
+// readOnlyActions are the actions that do not change the database.
+// With Options.ReadOnly, the manage_plan tool only offers these.
+var readOnlyActions = map[string]bool{
+	"inspect":       true,
+	"list_plans":    true,
+	"get_next_step": true,
+	"is_completed":  true,
 }
 
 // handleManagePlan is the main handler that dispatches to specific action handlers
@@ -81,6 +127,10 @@ func handleManagePlan(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
+	if err := validateArguments(action, req); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	switch action {
 	case "add_steps":
 		return handleAddSteps(ctx, req, p)
@@ -90,6 +140,8 @@ func handleManagePlan(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 		return handleListPlans(ctx, req, p)
 	case "remove_plans":
 		return handleRemovePlans(ctx, req, p)
+	case "rename_plan":
+		return handleRenamePlan(ctx, req, p)
 	case "compact_plans":
 		return handleCompactPlans(ctx, req, p)
 	case "remove_steps":
@@ -100,6 +152,8 @@ func handleManagePlan(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 		return handleSetStatus(ctx, req, p)
 	case "get_next_step":
 		return handleGetNextStep(ctx, req, p)
+	case "complete_and_next":
+		return handleCompleteAndNext(ctx, req, p)
 	case "is_completed":
 		return handleIsPlanCompleted(ctx, req, p)
 	default:
@@ -107,6 +161,139 @@ func handleManagePlan(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 	}
 }
 
+// actionArguments lists the arguments each action requires.
+// Required strings must not be blank and required arrays must not be empty.
+var actionArguments = map[string][]string{
+	"add_steps":         {"plan_name", "step_id", "description"},
+	"inspect":           {"plan_name"},
+	"list_plans":        {},
+	"remove_plans":      {"plan_names"},
+	"rename_plan":       {"old_name", "new_name"},
+	"compact_plans":     {},
+	"remove_steps":      {"plan_name", "step_ids"},
+	"reorder_steps":     {"plan_name", "step_order"},
+	"set_status":        {"plan_name", "step_id", "status"},
+	"get_next_step":     {"plan_name"},
+	"complete_and_next": {"plan_name", "step_id"},
+	"is_completed":      {"plan_name"},
+}
+
+// optionalArguments lists the arguments an action reads if they are given,
+// in addition to those of actionArguments.
+var optionalArguments = map[string][]string{
+	"add_steps":  {"acceptance_criteria", "references"},
+	"list_plans": {"plan_status"},
+}
+
+// arrayArguments are the arguments that take an array of strings.
+// Every item of these arrays must be a non-blank string.
+var arrayArguments = map[string]bool{
+	"acceptance_criteria": true,
+	"references":          true,
+	"step_ids":            true,
+	"step_order":          true,
+	"plan_names":          true,
+}
+
+// argumentValues lists the values accepted by enumerated arguments.
+var argumentValues = map[string][]string{
+	"status":      {"completed", "incomplete"},
+	"plan_status": {"done", "todo"},
+}
+
+// validateArguments checks the arguments of a manage_plan call before the
+// action's handler runs, so that callers get a precise message instead of an
+// error from deep within the planner: the action must exist, its required
+// arguments must be given and not be blank, its arguments must have the declared
+// type, and enumerated arguments must have one of the declared values.
+// Only the arguments the action declares in actionArguments and
+// optionalArguments are checked; any others are ignored, like the handler does.
+func validateArguments(action string, req mcp.CallToolRequest) error {
+	required, ok := actionArguments[action]
+	if !ok {
+		return fmt.Errorf("unknown action: %s", action)
+	}
+
+	args := req.GetArguments()
+	for _, name := range required {
+		if value, ok := args[name]; !ok || value == nil {
+			return fmt.Errorf("%s is required for action %s", name, action)
+		}
+	}
+
+	for _, name := range slices.Concat(required, optionalArguments[action]) {
+		value := args[name]
+		if value == nil {
+			continue
+		}
+		if arrayArguments[name] {
+			items, err := stringItems(name, value)
+			if err != nil {
+				return err
+			}
+			if len(items) == 0 && slices.Contains(required, name) {
+				return fmt.Errorf("%s must not be empty for action %s", name, action)
+			}
+			continue
+		}
+
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", name)
+		}
+		if strings.TrimSpace(text) == "" && slices.Contains(required, name) {
+			return fmt.Errorf("%s must not be empty for action %s", name, action)
+		}
+		if allowed, ok := argumentValues[name]; ok && text != "" && !slices.Contains(allowed, text) {
+			return fmt.Errorf("invalid %s: %s (must be '%s')", name, text, strings.Join(allowed, "' or '"))
+		}
+	}
+
+	if action == "add_steps" {
+		if err := ValidateStepID(req.GetString("step_id", "")); err != nil {
+			return fmt.Errorf("invalid step_id: %w", err)
+		}
+	}
+	if action == "reorder_steps" {
+		seen := make(map[string]bool)
+		for _, id := range req.GetStringSlice("step_order", nil) {
+			if seen[id] {
+				return fmt.Errorf("step_order contains step '%s' more than once", id)
+			}
+			seen[id] = true
+		}
+	}
+
+	return nil
+}
+
+// stringItems returns the items of the array argument name, or an error if
+// value is not an array or has items that are not non-blank strings.
+func stringItems(name string, value any) ([]string, error) {
+	var items []string
+	switch v := value.(type) {
+	case []string:
+		items = v
+	case []any:
+		for i, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s[%d] must be a string", name, i)
+			}
+			items = append(items, text)
+		}
+	default:
+		return nil, fmt.Errorf("%s must be an array of strings", name)
+	}
+
+	for i, item := range items {
+		if strings.TrimSpace(item) == "" {
+			return nil, fmt.Errorf("%s[%d] must not be empty", name, i)
+		}
+	}
+	return items, nil
+}
+
 // Action handlers
 
 func handleAddSteps(ctx context.Context, req mcp.CallToolRequest, p *Planner) (*mcp.CallToolResult, error) {
@@ -116,13 +303,18 @@ func handleAddSteps(ctx context.Context, req mcp.CallToolRequest, p *Planner) (*
 	}
 
 	// Get or create the plan
-	plan, err := p.Get(planName)
+	exists, err := p.Exists(planName)
 	if err != nil {
-		// If plan doesn't exist, create it
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var plan *Plan
+	if exists {
+		plan, err = p.GetCtx(ctx, planName)
+	} else {
 		plan, err = p.Create(planName)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("failed to create plan: %s", err.Error())), nil
-		}
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get or create plan: %s", err.Error())), nil
 	}
 
 	// Add single step using individual parameters
@@ -137,11 +329,16 @@ func handleAddSteps(ctx context.Context, req mcp.CallToolRequest, p *Planner) (*
 	}
 
 	acceptanceCriteria := req.GetStringSlice("acceptance_criteria", []string{})
-	references := req.GetStringSlice("references", []string{})
-	plan.AddStep(stepID, description, acceptanceCriteria, references)
+	var references []Reference
+	for _, text := range req.GetStringSlice("references", []string{}) {
+		references = append(references, ParseReference(text))
+	}
+	if err := plan.AddStepChecked(stepID, description, acceptanceCriteria, references); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	// Save the plan
-	err = p.Save(plan)
+	err = p.SaveCtx(ctx, plan)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -160,7 +357,7 @@ func handleInspectPlan(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	plan, err := p.Get(planName)
+	plan, err := p.GetCtx(ctx, planName)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -169,15 +366,8 @@ func handleInspectPlan(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 	// For compatibility, return detailed JSON format like the old get_plan
 	steps := make([]map[string]interface{}, len(plan.Steps))
 	for i, step := range plan.Steps {
-		steps[i] = map[string]interface{}{
-			"id":                  step.ID(),
-			"description":         step.Description(),
-			"status":              step.Status(),
-			"acceptance_criteria": step.AcceptanceCriteria(),
-			"references":          step.References(),
-		}
+		steps[i] = orderedStep(step)
 	}
-
 	result, _ := json.Marshal(map[string]interface{}{
 		"id":    plan.ID,
 		"steps": steps,
@@ -187,7 +377,13 @@ func handleInspectPlan(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 }
 
 func handleListPlans(ctx context.Context, req mcp.CallToolRequest, p *Planner) (*mcp.CallToolResult, error) {
-	plans, err := p.List()
+	var plans []PlanInfo
+	var err error
+	if status := req.GetString("plan_status", ""); status != "" {
+		plans, err = p.ListByStatusCtx(ctx, status)
+	} else {
+		plans, err = p.ListCtx(ctx)
+	}
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -202,22 +398,48 @@ func handleRemovePlans(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	results := p.Remove(planNames)
+	if err := p.RemoveErr(planNames); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
-	// Convert results to a JSON-serializable format
 	jsonResults := make(map[string]string)
-	for name, err := range results {
-		if err != nil {
-			jsonResults[name] = err.Error()
-		} else {
-			jsonResults[name] = "success"
-		}
+	for _, name := range planNames {
+		jsonResults[name] = "success"
 	}
 
 	result, _ := json.Marshal(jsonResults)
 	return mcp.NewToolResultText(string(result)), nil
 }
 
+func handleRenamePlan(ctx context.Context, req mcp.CallToolRequest, p *Planner) (*mcp.CallToolResult, error) {
+	oldName, err := req.RequireString("old_name")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	newName, err := req.RequireString("new_name")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Name the argument at fault, so that the agent knows what to change
+	err = p.Rename(oldName, newName)
+	switch {
+	case errors.Is(err, ErrPlanNotFound):
+		return mcp.NewToolResultError(fmt.Sprintf("plan '%s' not found: old_name must name an existing plan", oldName)), nil
+	case errors.Is(err, ErrPlanExists):
+		return mcp.NewToolResultError(fmt.Sprintf("plan '%s' already exists: choose a new_name that is not used yet", newName)), nil
+	case err != nil:
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result, _ := json.Marshal(map[string]string{
+		"old_name": oldName,
+		"new_name": newName,
+	})
+	return mcp.NewToolResultText(string(result)), nil
+}
+
 func handleCompactPlans(ctx context.Context, req mcp.CallToolRequest, p *Planner) (*mcp.CallToolResult, error) {
 	err := p.Compact()
 	if err != nil {
@@ -239,7 +461,7 @@ func handleRemoveSteps(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 	}
 
 	// Get the plan
-	plan, err := p.Get(planName)
+	plan, err := p.GetCtx(ctx, planName)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -247,8 +469,8 @@ func handleRemoveSteps(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 	// Remove the steps
 	removedCount := plan.RemoveSteps(stepIDs)
 
-	// Save the plan
-	err = p.Save(plan)
+	// Save the plan, recording the removed steps in the operations log
+	err = p.SaveLogged(plan, "remove-steps")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -268,7 +490,7 @@ func handleReorderSteps(ctx context.Context, req mcp.CallToolRequest, p *Planner
 	}
 
 	// Get the plan
-	plan, err := p.Get(planName)
+	plan, err := p.GetCtx(ctx, planName)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -277,7 +499,7 @@ func handleReorderSteps(ctx context.Context, req mcp.CallToolRequest, p *Planner
 	plan.Reorder(stepOrder)
 
 	// Save the plan
-	err = p.Save(plan)
+	err = p.SaveCtx(ctx, plan)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -302,7 +524,7 @@ func handleSetStatus(ctx context.Context, req mcp.CallToolRequest, p *Planner) (
 	}
 
 	// Get the plan
-	plan, err := p.Get(planName)
+	plan, err := p.GetCtx(ctx, planName)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -322,7 +544,7 @@ func handleSetStatus(ctx context.Context, req mcp.CallToolRequest, p *Planner) (
 	}
 
 	// Save the plan
-	err = p.Save(plan)
+	err = p.SaveCtx(ctx, plan)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -337,7 +559,7 @@ func handleGetNextStep(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 	}
 
 	// Get the plan
-	plan, err := p.Get(planName)
+	plan, err := p.GetCtx(ctx, planName)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -347,25 +569,52 @@ func handleGetNextStep(ctx context.Context, req mcp.CallToolRequest, p *Planner)
 		return mcp.NewToolResultText("No incomplete steps found"), nil
 	}
 
-	result, _ := json.Marshal(map[string]interface{}{
-		"id":                  nextStep.ID(),
-		"description":         nextStep.Description(),
-		"status":              nextStep.Status(),
-		"acceptance_criteria": nextStep.AcceptanceCriteria(),
-		"references":          nextStep.References(),
-	})
+	result, _ := json.Marshal(orderedStep(nextStep))
 
 	return mcp.NewToolResultText(string(result)), nil
 }
 
+func handleCompleteAndNext(ctx context.Context, req mcp.CallToolRequest, p *Planner) (*mcp.CallToolResult, error) {
+	planName, err := req.RequireString("plan_name")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	stepID, err := req.RequireString("step_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	nextStep, err := p.CompleteAndNextCtx(ctx, planName, stepID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if nextStep == nil {
+		return mcp.NewToolResultText(fmt.Sprintf("Step '%s' marked as completed in plan '%s'. No incomplete steps found", stepID, planName)), nil
+	}
+
+	result, _ := json.Marshal(orderedStep(nextStep))
+
+	return mcp.NewToolResultText(string(result)), nil
+}
+
+// orderedStep returns the JSON fields of step together with its 0-based
+// position in the plan as "order", so that clients do not depend on the
+// order of the steps array.
+func orderedStep(step *Step) map[string]interface{} {
+	fields := step.jsonFields()
+	fields["order"] = step.Order()
+	return fields
+}
+
 func handleIsPlanCompleted(ctx context.Context, req mcp.CallToolRequest, p *Planner) (*mcp.CallToolResult, error) {
 	planName, err := req.RequireString("plan_name")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	// Get the plan
-	plan, err := p.Get(planName)
+	// Get the plan, without criteria and references
+	plan, err := p.GetSummary(planName)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -0,0 +1,161 @@
+package planner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ExportPlan returns the plan planName as a JSON document that ImportPlan
+// can read, e.g. to copy the plan into another database. The document holds
+// everything needed to recreate the plan: its tags, archived state, creation
+// time, steps with all their fields, and its status history.
+// It returns an error wrapping ErrPlanNotFound if the plan does not exist, and
+// one wrapping ErrTooManySteps if it has more steps than the planner's limit.
+func (p *Planner) ExportPlan(planName string) ([]byte, error) {
+	return exportPlan(p.db, planName, p.maxSteps)
+}
+
+// ExportPlan exports a plan like Planner.ExportPlan.
+func (t *Tx) ExportPlan(planName string) ([]byte, error) {
+	return exportPlan(t.tx, planName, t.maxSteps)
+}
+
+// exportPlan implements ExportPlan on a database or inside a transaction,
+// loading at most maxSteps steps, unless maxSteps is 0.
+func exportPlan(q querier, planName string, maxSteps int) ([]byte, error) {
+	snapshots, err := snapshotPlans(q, []string{planName}, maxSteps)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("cannot export plan '%s': %w", planName, ErrPlanNotFound)
+	}
+
+	// The document is the plan's state as recorded for removed plans
+	if err := addHistory(q, snapshots); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(snapshots[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plan '%s': %w", planName, err)
+	}
+	return data, nil
+}
+
+// ImportPlan creates the plan described by data, as returned by ExportPlan,
+// in this planner's database, keeping the name of the exported plan.
+// An on-complete command in data is ignored, since it would be run when the
+// plan is completed: the imported plan has none until SetOnComplete is used.
+// It returns an error wrapping ErrPlanExists if a plan with this name
+// already exists, and one wrapping ErrTooManySteps if data has more steps
+// than the planner's limit, see Options.MaxSteps.
+// The plan is imported in a single transaction, see WithTx.
+func (p *Planner) ImportPlan(data []byte) error {
+	return p.WithTx(func(tx *Tx) error {
+		return tx.ImportPlan(data)
+	})
+}
+
+// ImportPlan imports a plan like Planner.ImportPlan.
+func (t *Tx) ImportPlan(data []byte) error {
+	var export planSnapshot
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("failed to decode plan: %w", err)
+	}
+	if export.ID == "" {
+		return fmt.Errorf("cannot import plan without a name")
+	}
+	if t.maxSteps > 0 && len(export.Steps) > t.maxSteps {
+		return fmt.Errorf("cannot import plan '%s' with more than %d steps: %w", export.ID, t.maxSteps, ErrTooManySteps)
+	}
+
+	exists, err := t.Exists(export.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("cannot import plan '%s': %w", export.ID, ErrPlanExists)
+	}
+
+	// Documents may come from elsewhere, so they must not set a command to run
+	export.OnComplete = ""
+
+	// History left behind by a plan removed before Remove deleted history
+	// must not be mixed into the imported one
+	if export.History == nil {
+		export.History = []StatusChange{}
+	}
+	return restorePlan(t.tx, export)
+}
+
+// MovePlan moves the plan planName from this planner's database into the
+// database of target, using ExportPlan and ImportPlan. Either the plan ends
+// up in target and is removed from this database, or neither database is
+// changed. The removal is recorded in the operations log as "move", so
+// Undo restores the plan in this database without removing it from target.
+// The status history of the plan is moved as well: like for Remove, it is
+// deleted from this database in the same transaction as the plan, so that a
+// later plan with the same name does not inherit it, and Undo restores it.
+// The plan's on-complete command is not moved, see ImportPlan.
+// It returns an error wrapping ErrPlanNotFound if the plan does not exist,
+// and one wrapping ErrPlanExists if target already has a plan with its name.
+func (p *Planner) MovePlan(planName string, target *Planner) error {
+	if p.db == target.db {
+		return fmt.Errorf("cannot move plan '%s' into the database it is stored in", planName)
+	}
+
+	// The plan is removed from this database in a transaction that commits
+	// only after the import into target has been committed. If it cannot be
+	// committed, the imported plan is removed from target again.
+	imported := false
+	var historyMark int64
+	removeImported := func() error {
+		return target.WithTx(func(ttx *Tx) error {
+			if _, err := ttx.tx.Exec("DELETE FROM plans WHERE id = ?", planName); err != nil {
+				return fmt.Errorf("failed to remove plan '%s' from target database: %w", planName, err)
+			}
+			if _, err := ttx.tx.Exec("DELETE FROM step_status_history WHERE plan_id = ? AND id > ?", planName, historyMark); err != nil {
+				return fmt.Errorf("failed to remove status history of plan '%s' from target database: %w", planName, err)
+			}
+			return nil
+		})
+	}
+
+	err := p.WithTx(func(tx *Tx) error {
+		// A busy database makes WithTx try again, after an earlier attempt
+		// may already have imported the plan
+		if imported {
+			if err := removeImported(); err != nil {
+				return err
+			}
+			imported = false
+		}
+
+		data, err := tx.ExportPlan(planName)
+		if err != nil {
+			return err
+		}
+		if err := tx.Remove([]string{planName}, "move"); err != nil {
+			return err
+		}
+
+		err = target.WithTx(func(ttx *Tx) error {
+			if err := ttx.tx.QueryRow("SELECT COALESCE(MAX(id), 0) FROM step_status_history").Scan(&historyMark); err != nil {
+				return fmt.Errorf("failed to query status history of target database: %w", err)
+			}
+			return ttx.ImportPlan(data)
+		})
+		if err != nil {
+			return err
+		}
+		imported = true
+		return nil
+	})
+	if err != nil && imported {
+		if removeErr := removeImported(); removeErr != nil {
+			return errors.Join(err, fmt.Errorf("plan '%s' was copied to the target database but is still stored in the source: %w", planName, removeErr))
+		}
+	}
+	return err
+}
@@ -0,0 +1,181 @@
+package planner
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Tx gives access to the plan operations of a Planner within a single
+// database transaction, so that composite operations either apply completely
+// or not at all. A Tx is only valid inside the function passed to WithTx.
+type Tx struct {
+	tx       *sql.Tx
+	saved    []savedPlan       // Plans saved in the transaction, see rollback
+	maxSteps int               // Limit of steps loaded per plan, see Options.MaxSteps
+	hooks    []*completionHook // Run once the transaction is committed
+}
+
+// savedPlan is the in-memory state of a plan before it was saved in a Tx.
+type savedPlan struct {
+	plan    *Plan
+	isNew   bool
+	version int
+	renamed map[string]string
+}
+
+// WithTx calls fn with a Tx and commits the transaction if fn returns nil.
+// If fn returns an error, or the commit fails, the transaction is rolled back,
+// nothing fn did is written to the database, and plans saved through the Tx
+// are restored to their in-memory state from before the transaction.
+// While the database is busy, the transaction is retried with backoff, so fn
+// may be called more than once.
+// Since in-memory databases only have a single connection, fn must not call
+// methods of the Planner that access the database; it must use the Tx instead.
+func (p *Planner) WithTx(fn func(*Tx) error) error {
+	return withRetry(maxTransactionAttempts, func() error {
+		tx, err := p.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		t := &Tx{tx: tx, maxSteps: p.maxSteps}
+
+		if err := fn(t); err != nil {
+			t.rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			t.rollback()
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		for _, hook := range t.hooks {
+			hook.run()
+		}
+		return nil
+	})
+}
+
+// rollback rolls back the transaction and restores the in-memory state of
+// the plans saved in it.
+func (t *Tx) rollback() {
+	t.tx.Rollback()
+	for i := len(t.saved) - 1; i >= 0; i-- {
+		t.saved[i].plan.isNew = t.saved[i].isNew
+		t.saved[i].plan.version = t.saved[i].version
+		t.saved[i].plan.renamed = t.saved[i].renamed
+	}
+}
+
+// Get retrieves a plan and its steps like Planner.Get, seeing the changes
+// made earlier in the transaction.
+func (t *Tx) Get(name string) (*Plan, error) {
+	return getPlan(t.tx, name, t.maxSteps)
+}
+
+// Exists reports whether a plan with the given name exists, like Planner.Exists.
+func (t *Tx) Exists(name string) (bool, error) {
+	return planExists(t.tx, name)
+}
+
+// Save persists the plan like Planner.Save. The plan's in-memory state is
+// updated immediately, so it can be saved again in the same transaction.
+func (t *Tx) Save(plan *Plan) error {
+	return t.save(plan, "")
+}
+
+// SaveLogged is like Save, but records the state of the plan before the
+// change in the operations log, like Planner.SaveLogged.
+func (t *Tx) SaveLogged(plan *Plan, operation string) error {
+	return t.save(plan, operation)
+}
+
+// save implements Save and SaveLogged.
+func (t *Tx) save(plan *Plan, operation string) error {
+	if err := checkSavable(plan); err != nil {
+		return err
+	}
+
+	var logged *loggedOperation
+	if operation != "" && !plan.isNew {
+		snapshots, err := snapshotPlans(t.tx, []string{plan.ID}, 0)
+		if err != nil {
+			return err
+		}
+		logged = &loggedOperation{name: operation, plans: snapshots}
+	}
+
+	hook, err := watchCompletion(t.tx, plan.ID, func() error {
+		return savePlan(t.tx, plan, logged)
+	})
+	if err != nil {
+		return err
+	}
+	if hook != nil {
+		t.hooks = append(t.hooks, hook)
+	}
+	t.saved = append(t.saved, savedPlan{plan: plan, isNew: plan.isNew, version: plan.version, renamed: plan.renamed})
+	plan.markSaved()
+	return nil
+}
+
+// SetStepStatus changes the status of a single step like Planner.SetStepStatus.
+func (t *Tx) SetStepStatus(planName, stepID, status string) error {
+	status = strings.ToUpper(status)
+	if status != "TODO" && status != "DONE" {
+		return fmt.Errorf("invalid status '%s' (must be 'TODO' or 'DONE')", status)
+	}
+	hook, err := watchCompletion(t.tx, planName, func() error {
+		return setStepStatus(t.tx, planName, stepID, status)
+	})
+	if err != nil {
+		return err
+	}
+	if hook != nil {
+		t.hooks = append(t.hooks, hook)
+	}
+	return nil
+}
+
+// Remove deletes the named plans like Planner.Remove and records them in the
+// operations log under operation, e.g. "remove", so that they can be restored
+// with Undo. Unlike Planner.Remove, the first plan that cannot be removed
+// fails the whole call.
+func (t *Tx) Remove(planNames []string, operation string) error {
+	snapshots, err := t.remove(planNames)
+	if err != nil {
+		return err
+	}
+	return logOperation(t.tx, &loggedOperation{name: operation, plans: snapshots})
+}
+
+// remove deletes the named plans and their history like Remove, but returns
+// their snapshots instead of writing them to the operations log, so that the
+// caller can log them together with other changes.
+func (t *Tx) remove(planNames []string) ([]planSnapshot, error) {
+	snapshots, err := snapshotPlans(t.tx, planNames, 0)
+	if err == nil {
+		err = addHistory(t.tx, snapshots)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record plans for remove: %w", err)
+	}
+
+	for _, name := range planNames {
+		result, err := t.tx.Exec("DELETE FROM plans WHERE id = ?", name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute delete for plan '%s': %w", name, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to check delete of plan '%s': %w", name, err)
+		}
+		if rowsAffected == 0 {
+			return nil, fmt.Errorf("plan '%s' not found for deletion", name)
+		}
+		if err := deleteHistory(t.tx, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return snapshots, nil
+}
@@ -1,20 +1,67 @@
 package tasked
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/dhamidi/tasked/planner"
 )
 
 type Settings struct {
 	DatabaseFile string
+	Verbose      bool   // Log executed SQL statements to stderr
+	ConfigFile   string // Path to the config file (default: ~/.tasked/config.toml)
+	Color        string // "auto", "always" or "never", see UseColor
+	Quiet        bool   // Suppress success messages, see Successf
+
+	// RequireExistingDB makes OpenPlanner fail instead of creating a
+	// database file that does not exist yet.
+	RequireExistingDB bool
+
+	// Defaults read from the config file by LoadConfig.
+	// Command line flags take precedence over these values.
+	ConfigDatabaseFile string
+	OutputFormat       string // "text" or "json"
+	SortOrder          string // Default sort order for plan list
+	DefaultPlan        string // Plan used when the plan name is omitted, see PlanName
+	MaxSteps           int    // Steps loaded per plan at most, see planner.Options.MaxSteps
+}
+
+// Config is the format of the config file.
+type Config struct {
+	DatabaseFile string `toml:"database_file"`
+	OutputFormat string `toml:"output_format"`
+	SortOrder    string `toml:"sort_order"`
+	DefaultPlan  string `toml:"default_plan"`
+	MaxSteps     int    `toml:"max_steps"`
 }
 
 var GlobalSettings = &Settings{}
 
+// DatabaseEnvVar names the environment variable that selects the database
+// file when no --database-file flag is given.
+const DatabaseEnvVar = "TASKED_DATABASE"
+
+// GetDatabaseFile returns the database file to use. The --database-file flag
+// takes precedence over the TASKED_DATABASE environment variable, which takes
+// precedence over database_file in the config file; the default is ~/.tasked/tasks.db.
 func (s *Settings) GetDatabaseFile() string {
 	if s.DatabaseFile != "" {
 		return s.DatabaseFile
 	}
+	if path := os.Getenv(DatabaseEnvVar); path != "" {
+		return path
+	}
+	if s.ConfigDatabaseFile != "" {
+		return s.ConfigDatabaseFile
+	}
 
 	// Default to ~/.tasked/tasks.db
 	homeDir, err := os.UserHomeDir()
@@ -29,3 +76,160 @@ func (s *Settings) GetDatabaseFile() string {
 
 	return filepath.Join(taskedDir, "tasks.db")
 }
+
+// GetConfigFile returns the path of the config file, ~/.tasked/config.toml
+// unless ConfigFile is set.
+func (s *Settings) GetConfigFile() string {
+	if s.ConfigFile != "" {
+		return s.ConfigFile
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".tasked", "config.toml")
+}
+
+// LoadConfig reads the config file and stores its values as defaults.
+// A missing default config file is not an error; a missing file given
+// explicitly through ConfigFile is. Unknown keys and invalid values are rejected.
+func (s *Settings) LoadConfig() error {
+	path := s.GetConfigFile()
+	if path == "" {
+		return nil
+	}
+
+	var config Config
+	meta, err := toml.DecodeFile(path, &config)
+	if errors.Is(err, fs.ErrNotExist) && s.ConfigFile == "" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("unknown key '%s' in config file %s", undecoded[0], path)
+	}
+
+	if config.OutputFormat != "" && config.OutputFormat != "text" && config.OutputFormat != "json" {
+		return fmt.Errorf("invalid output_format '%s' in config file %s (must be 'text' or 'json')", config.OutputFormat, path)
+	}
+	if config.SortOrder != "" && config.SortOrder != "name" && config.SortOrder != "progress" && config.SortOrder != "created" && config.SortOrder != "updated" {
+		return fmt.Errorf("invalid sort_order '%s' in config file %s (must be 'name', 'progress', 'created' or 'updated')", config.SortOrder, path)
+	}
+
+	s.ConfigDatabaseFile = expandHome(config.DatabaseFile)
+	s.OutputFormat = config.OutputFormat
+	s.SortOrder = config.SortOrder
+	s.DefaultPlan = config.DefaultPlan
+	s.MaxSteps = config.MaxSteps
+	return nil
+}
+
+// PlanName returns the plan name given as the first of args, or the default
+// plan from the config file if args is empty.
+func (s *Settings) PlanName(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	if s.DefaultPlan == "" {
+		return "", fmt.Errorf("no plan name given and no default plan set, run 'plan use <plan-name>' to set one")
+	}
+	return s.DefaultPlan, nil
+}
+
+// SetDefaultPlan stores name as default_plan in the config file, creating the
+// file if it does not exist. The rest of the file, including comments, is kept.
+func (s *Settings) SetDefaultPlan(name string) error {
+	path := s.GetConfigFile()
+	if path == "" {
+		return fmt.Errorf("cannot determine the location of the config file")
+	}
+
+	var entry bytes.Buffer
+	if err := toml.NewEncoder(&entry).Encode(map[string]string{"default_plan": name}); err != nil {
+		return fmt.Errorf("failed to encode default plan: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	// Replace an existing default_plan line, or add one at the top, where it
+	// cannot end up inside a table
+	lines := strings.SplitAfter(string(data), "\n")
+	replaced := false
+	for i, line := range lines {
+		key, _, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(key) == "default_plan" {
+			lines[i] = entry.String()
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		lines = append([]string{entry.String()}, lines...)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for config file %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0644); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+	s.DefaultPlan = name
+	return nil
+}
+
+// expandHome replaces a leading "~/" in path with the user's home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, rest)
+}
+
+// OpenPlanner returns a planner for the configured database file.
+// The connection is shared between all callers in the same process,
+// so the schema is only initialized once. Callers must still Close the planner.
+func (s *Settings) OpenPlanner() (*planner.Planner, error) {
+	return s.OpenPlannerAt(s.GetDatabaseFile())
+}
+
+// OpenPlannerAt is like OpenPlanner but opens the database file at path
+// instead of the configured one.
+func (s *Settings) OpenPlannerAt(path string) (*planner.Planner, error) {
+	if err := s.CheckDatabaseExists(path); err != nil {
+		return nil, err
+	}
+	return planner.NewSharedWithOptions(path, s.PlannerOptions())
+}
+
+// CheckDatabaseExists returns an error if RequireExistingDB is set and there
+// is no database file at path. In-memory databases always pass the check.
+func (s *Settings) CheckDatabaseExists(path string) error {
+	if !s.RequireExistingDB || path == ":memory:" || strings.HasPrefix(path, "file:") {
+		return nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("database not found at %s, run 'plan new' to create one", path)
+	}
+	return nil
+}
+
+// PlannerOptions returns the planner options derived from the settings.
+// With Verbose set, SQL statements are logged to stderr.
+func (s *Settings) PlannerOptions() planner.Options {
+	opts := planner.Options{MaxSteps: s.MaxSteps}
+	if s.Verbose {
+		opts.Logger = log.New(os.Stderr, "sql: ", 0)
+	}
+	return opts
+}